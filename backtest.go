@@ -0,0 +1,152 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/codehakase/kelly/internal/backtest"
+	"github.com/codehakase/kelly/internal/cli"
+	"github.com/codehakase/kelly/internal/formatter"
+	"github.com/codehakase/kelly/pkg/types"
+)
+
+var backtestCommand = &cli.Command{
+	Name:    "backtest",
+	Usage:   "backtest --data odds.csv --odds-cols home,draw,away [flags]",
+	Summary: "Replay a staking method over historical odds",
+	Help:    printBacktestUsage,
+	Flags:   withConfig(backtestFlags),
+}
+
+// backtestFlags defines the flags of "kelly backtest", which replays a
+// staking method over a CSV of historical odds and results.
+func backtestFlags(fs *flag.FlagSet) func(args []string) {
+	var (
+		data        = fs.String("data", "", "CSV of historical odds and results (- for stdin)")
+		method      = fs.String("method", "kelly", "Calculation method (arbitrage, kelly, kelly-simultaneous)")
+		total       = fs.Float64("total", 1000, "Starting bankroll")
+		currency    = fs.String("currency", "₦", "Currency symbol")
+		kellyFrac   = fs.Float64("kelly-fraction", 0, "Fraction of Kelly to stake, e.g. 0.25 (default full Kelly)")
+		maxStakePct = fs.Float64("max-stake-pct", 0, "Maximum stake per option as a percentage of the bankroll")
+		minEdge     = fs.Float64("min-edge", 0, "Minimum edge (p × odds - 1) required to bet an option")
+		fairFrom    = fs.String("fair-from", "", "Derive probabilities by margin removal (multiplicative, additive, power, shin, odds-ratio)")
+		oddsCols    = fs.String("odds-cols", "", "Comma-separated odds columns, one per option (required)")
+		probCols    = fs.String("prob-cols", "", "Comma-separated probability columns matching --odds-cols")
+		sharpCols   = fs.String("sharp-cols", "", "Comma-separated reference odds columns for --fair-from")
+		names       = fs.String("names", "", "Comma-separated option names (default: the odds column names)")
+		resultCol   = fs.String("result-col", "result", "Column holding the winner: option name, letter, number or none")
+		dateCol     = fs.String("date-col", "", "Optional date column copied to the ledger")
+		eventCol    = fs.String("event-col", "", "Optional event column copied to the ledger")
+		ledger      = fs.String("ledger", "", "Write the per-bet ledger as CSV to this file")
+		format      = fs.String("format", "table", "Output format (table, json, csv)")
+	)
+	cli.Alias(fs, "m", "method")
+	cli.Alias(fs, "t", "total")
+	cli.Alias(fs, "c", "currency")
+	cli.Alias(fs, "f", "format")
+	cli.Alias(fs, "fraction", "kelly-fraction")
+
+	return func(args []string) {
+		if *data == "" || *oddsCols == "" {
+			fatal(fmt.Errorf("backtest requires --data and --odds-cols"))
+		}
+		if err := validMethod(types.CalculationMethod(*method)); err != nil {
+			fatal(err)
+		}
+		if m := types.CalculationMethod(*method); m == types.MethodMatched || m == types.MethodHedge || m == types.MethodEachWay ||
+			m == types.MethodParlay {
+			fatal(fmt.Errorf("the %s method cannot be backtested", m))
+		}
+
+		var r io.Reader = os.Stdin
+		if *data != "-" {
+			f, err := os.Open(*data)
+			if err != nil {
+				fatal(err)
+			}
+			defer f.Close()
+			r = f
+		}
+
+		result, err := backtest.Run(r, backtest.Config{
+			Method:           types.CalculationMethod(*method),
+			StartingBankroll: *total,
+			Currency:         *currency,
+			KellyFraction:    *kellyFrac,
+			MaxStakePct:      *maxStakePct,
+			MinEdge:          *minEdge,
+			FairFrom:         types.MarginMethod(*fairFrom),
+			Columns: backtest.Columns{
+				Odds:   splitList(*oddsCols),
+				Probs:  splitList(*probCols),
+				Sharp:  splitList(*sharpCols),
+				Names:  splitList(*names),
+				Result: *resultCol,
+				Date:   *dateCol,
+				Event:  *eventCol,
+			},
+		})
+		if err != nil {
+			fatal(err)
+		}
+
+		if *ledger != "" {
+			out, err := formatter.FormatLedgerCSV(result.Ledger)
+			if err != nil {
+				fatal(err)
+			}
+			if err := os.WriteFile(*ledger, []byte(out), 0o644); err != nil {
+				fatal(err)
+			}
+		}
+
+		var output string
+		switch types.OutputFormat(*format) {
+		case types.OutputJSON:
+			output, err = formatter.FormatBacktestJSON(result)
+		case types.OutputCSV:
+			output, err = formatter.FormatBacktestCSV(result)
+		default:
+			output = formatter.FormatBacktestTable(result)
+		}
+		if err != nil {
+			fatal(err)
+		}
+		fmt.Println(strings.TrimRight(output, "\n"))
+	}
+}
+
+// splitList splits a comma-separated flag value, returning nil when empty.
+func splitList(s string) []string {
+	if strings.TrimSpace(s) == "" {
+		return nil
+	}
+	parts := strings.Split(s, ",")
+	for i := range parts {
+		parts[i] = strings.TrimSpace(parts[i])
+	}
+	return parts
+}
+
+func printBacktestUsage(w io.Writer) {
+	fmt.Fprintf(w, `Kelly - Backtest
+
+USAGE:
+  kelly backtest --data odds.csv --odds-cols home,draw,away [flags]
+
+Replays every row through the chosen method, sizing each bet from the
+bankroll left by the rows before it. Probabilities come from --prob-cols
+or from margin removal with --fair-from (of --sharp-cols when given).
+Rows that cannot be priced are skipped and noted in the ledger.
+
+EXAMPLES:
+  kelly backtest --data odds.csv --method kelly --fraction 0.25 \
+    --odds-cols home,draw,away --prob-cols p_home,p_draw,p_away --result-col ftr
+  kelly backtest --data odds.csv --fair-from shin --odds-cols B365H,B365D,B365A \
+    --sharp-cols PSH,PSD,PSA --names H,D,A --result-col FTR --ledger ledger.csv
+
+`)
+}
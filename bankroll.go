@@ -0,0 +1,208 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/codehakase/kelly/internal/bankroll"
+	"github.com/codehakase/kelly/internal/cli"
+	"github.com/codehakase/kelly/internal/journal"
+	"github.com/codehakase/kelly/pkg/money"
+	"github.com/codehakase/kelly/pkg/types"
+)
+
+var bankrollCommand = &cli.Command{
+	Name:        "bankroll",
+	Usage:       "bankroll <command> [flags]",
+	Summary:     "Manage bankrolls (create, deposit, withdraw, list, show)",
+	Help:        printBankrollUsage,
+	Flags:       func(*flag.FlagSet) func(args []string) { return runBankroll },
+	Subcommands: bankrollSubcommands,
+}
+
+var bankrollSubcommands = []string{"create", "deposit", "withdraw", "default", "list", "show"}
+
+// runBankroll handles the "kelly bankroll" subcommands.
+func runBankroll(args []string) {
+	if len(args) == 0 {
+		printBankrollUsage(os.Stderr)
+		os.Exit(1)
+	}
+
+	sub, args := args[0], args[1:]
+	fs := flag.NewFlagSet("bankroll "+sub, flag.ExitOnError)
+	fs.Usage = func() {
+		printBankrollUsage(fs.Output())
+		fmt.Fprintln(fs.Output(), "FLAGS:")
+		cli.PrintFlags(fs)
+	}
+	run := bankrollFlags(sub, fs)
+	if run == nil {
+		fmt.Fprintf(os.Stderr, "✗ Error: unknown bankroll command '%s'\n", sub)
+		printBankrollUsage(os.Stderr)
+		os.Exit(1)
+	}
+	run(args)
+}
+
+// bankrollFlags defines the flags of a bankroll subcommand and returns the
+// function that parses them and runs it, or nil for an unknown subcommand.
+func bankrollFlags(sub string, fs *flag.FlagSet) func(args []string) {
+	path := fs.String("file", "", "Bankroll file (default $KELLY_BANKROLLS or <config dir>/kelly/bankrolls.json)")
+	fs.String("profile", "", "Config profile to use (default $KELLY_PROFILE or the config's profile)")
+
+	switch sub {
+	case "create":
+		currency := fs.String("currency", "₦", "Currency symbol")
+		makeDefault := fs.Bool("default", false, "Make this the default bankroll")
+		cli.Alias(fs, "c", "currency")
+		return func(args []string) {
+			name := positionalArgs(fs, args, 1)[0]
+			store := openBankrolls(*path)
+			b, err := store.Create(name, *currency)
+			if err != nil {
+				fatal(err)
+			}
+			if *makeDefault {
+				if err := store.SetDefault(b.Name); err != nil {
+					fatal(err)
+				}
+			}
+			fmt.Printf("✓ Created bankroll '%s' (%s)\n", b.Name, b.Currency)
+		}
+
+	case "deposit", "withdraw":
+		note := fs.String("note", "", "Note stored with the transaction")
+		return func(args []string) {
+			pos := positionalArgs(fs, args, 2)
+			amount, err := strconv.ParseFloat(pos[1], 64)
+			if err != nil {
+				fatal(fmt.Errorf("invalid amount '%s'", pos[1]))
+			}
+			store := openBankrolls(*path)
+			var b types.Bankroll
+			if sub == "deposit" {
+				b, err = store.Deposit(pos[0], amount, *note)
+			} else {
+				b, err = store.Withdraw(pos[0], amount, *note)
+			}
+			if err != nil {
+				fatal(err)
+			}
+			fmt.Printf("✓ %s balance: %s%.2f\n", b.Name, b.Currency, b.Balance().Float64())
+		}
+
+	case "default":
+		return func(args []string) {
+			name := positionalArgs(fs, args, 1)[0]
+			if err := openBankrolls(*path).SetDefault(name); err != nil {
+				fatal(err)
+			}
+			fmt.Printf("✓ Default bankroll is now '%s'\n", name)
+		}
+
+	case "list":
+		return func(args []string) {
+			parseFlags(fs, args)
+			bankrolls, defaultName, err := openBankrolls(*path).List()
+			if err != nil {
+				fatal(err)
+			}
+			if len(bankrolls) == 0 {
+				fmt.Println("No bankrolls. Create one with 'kelly bankroll create <name>'.")
+				return
+			}
+			for _, b := range bankrolls {
+				marker := " "
+				if b.Name == defaultName {
+					marker = "*"
+				}
+				fmt.Printf("%s %-20s %s%.2f\n", marker, b.Name, b.Currency, b.Balance().Float64())
+			}
+		}
+
+	case "show":
+		return func(args []string) {
+			parseFlags(fs, args)
+			b, err := openBankrolls(*path).Get(fs.Arg(0))
+			if err != nil {
+				fatal(err)
+			}
+			fmt.Printf("%s: %s%.2f\n\n", b.Name, b.Currency, b.Balance().Float64())
+			for _, tx := range b.Transactions {
+				detail := tx.Note
+				if tx.Kind == types.TransactionBet {
+					detail = fmt.Sprintf("bet #%d", tx.BetID)
+				}
+				fmt.Printf("%s  %-10s %+12.2f  %s\n", tx.At.Local().Format("2006-01-02 15:04"), tx.Kind, tx.Amount.Float64(), detail)
+			}
+		}
+	}
+	return nil
+}
+
+func openBankrolls(path string) *bankroll.Store {
+	if path == "" {
+		var err error
+		if path, err = bankroll.DefaultPath(); err != nil {
+			fatal(err)
+		}
+	}
+	return bankroll.Open(path)
+}
+
+// loadBankroll returns the named bankroll, or the default one when name is
+// empty.
+func loadBankroll(name string) (types.Bankroll, error) {
+	path, err := bankroll.DefaultPath()
+	if err != nil {
+		return types.Bankroll{}, err
+	}
+	return bankroll.Open(path).Get(name)
+}
+
+// pendingStakes returns the capital committed to a bankroll's bets still
+// pending in the default journal, which its balance does not yet reflect.
+func pendingStakes(b types.Bankroll) (money.Amount, error) {
+	path, err := journal.DefaultPath()
+	if err != nil {
+		return 0, err
+	}
+	return journal.Open(path).Pending(b.Name)
+}
+
+// positionalArgs parses fs and returns exactly n positional arguments,
+// which may come before or after the flags.
+func positionalArgs(fs *flag.FlagSet, args []string, n int) []string {
+	var pos []string
+	for len(args) > 0 && len(pos) < n && args[0] != "" && args[0][0] != '-' {
+		pos, args = append(pos, args[0]), args[1:]
+	}
+	parseFlags(fs, args)
+	pos = append(pos, fs.Args()...)
+	if len(pos) != n {
+		fatal(fmt.Errorf("%s expects %d argument(s), got %d", fs.Name(), n, len(pos)))
+	}
+	return pos
+}
+
+func printBankrollUsage(w io.Writer) {
+	fmt.Fprintf(w, `Kelly - Bankroll Manager
+
+USAGE:
+  kelly bankroll create <name> [-c ₦] [--default]  Create a bankroll
+  kelly bankroll deposit <name> <amount>           Add money
+  kelly bankroll withdraw <name> <amount>          Take money out
+  kelly bankroll default <name>                    Set the default bankroll
+  kelly bankroll list                              Show every bankroll and balance
+  kelly bankroll show [name]                       Show a bankroll's transactions
+
+The default bankroll supplies --total for Kelly runs, its balance less the
+stakes of its pending bets, and is credited with the P&L of bets recorded
+against it when they are settled. Bets must be in the bankroll's currency.
+Pick another bankroll with --bankroll.
+`)
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/codehakase/kelly/internal/batch"
+	"github.com/codehakase/kelly/internal/calculator"
+	"github.com/codehakase/kelly/internal/cli"
+	"github.com/codehakase/kelly/internal/config"
+	"github.com/codehakase/kelly/internal/formatter"
+	"github.com/codehakase/kelly/internal/validator"
+	"github.com/codehakase/kelly/pkg/types"
+)
+
+var batchCommand = &cli.Command{
+	Name:    "batch",
+	Usage:   "batch [flags] < markets.csv",
+	Summary: "Calculate many markets from CSV or JSONL",
+	Help:    printBatchUsage,
+	Flags:   withConfig(batchFlags),
+}
+
+// batchFlags defines the flags of "kelly batch", which calculates every
+// market read from a CSV or JSONL input and streams the results. The
+// market flags are the defaults for every row.
+func batchFlags(fs *flag.FlagSet) func(args []string) {
+	var market marketFlags
+	market.register(fs)
+	var (
+		input       = fs.String("input", "-", "CSV or JSONL file of markets (- for stdin)")
+		inputFormat = fs.String("input-format", "", "Input format: csv or jsonl (detected by default)")
+		format      = fs.String("format", "jsonl", "Output format (jsonl, csv)")
+	)
+	cli.Alias(fs, "f", "format")
+
+	return func(args []string) {
+		var r io.Reader = os.Stdin
+		if *input != "-" {
+			f, err := os.Open(*input)
+			if err != nil {
+				fatal(err)
+			}
+			defer f.Close()
+			r = f
+		}
+		out, err := newBatchWriter(os.Stdout, *format)
+		if err != nil {
+			fatal(err)
+		}
+
+		var rows, failed int
+		ignored := make(map[string]bool)
+		err = batch.Read(r, batch.Format(*inputFormat), func(row batch.Row) {
+			rows++
+			err := row.Err
+			if err == nil {
+				var result *types.CalculationResult
+				if result, err = batchRow(fs, row.Values, ignored); err == nil {
+					err = out.write(row.Line, result)
+				}
+			}
+			if err != nil {
+				failed++
+				fmt.Fprintf(os.Stderr, "✗ line %d: %v\n", row.Line, err)
+			}
+		})
+		if err != nil {
+			fatal(err)
+		}
+		if rows == 0 {
+			fatal(errors.New("batch found no markets in its input"))
+		}
+		if failed == rows {
+			os.Exit(1)
+		}
+	}
+}
+
+var errNoBatchMarket = errors.New("needs odds_a and odds_b (or odds), and total (or a bankroll for Kelly)")
+
+// batchRow calculates one market: the batch flags, overridden by the row's
+// values. Values naming no market flag are reported once and ignored.
+func batchRow(batchFlags *flag.FlagSet, values map[string]string, ignored map[string]bool) (*types.CalculationResult, error) {
+	fs := flag.NewFlagSet("batch", flag.ContinueOnError)
+	var market marketFlags
+	market.register(fs)
+
+	// Flags given to batch or configured count as given for every row, so
+	// a currency still beats a bankroll's; defaults stay defaults.
+	var err error
+	fs.VisitAll(func(f *flag.Flag) {
+		from := batchFlags.Lookup(f.Name)
+		if config.IsSet(batchFlags, f.Name) {
+			err = errors.Join(err, fs.Set(f.Name, from.Value.String()))
+		} else {
+			err = errors.Join(err, f.Value.Set(from.Value.String()))
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if fs.Lookup(key) == nil {
+			if !ignored[key] {
+				ignored[key] = true
+				fmt.Fprintf(os.Stderr, "ignoring '%s': not a market flag\n", key)
+			}
+			continue
+		}
+		if err := fs.Set(key, values[key]); err != nil {
+			return nil, fmt.Errorf("%s: %w", key, err)
+		}
+	}
+
+	if !market.hasOdds() || !market.hasTotal() {
+		return nil, errNoBatchMarket
+	}
+	input, err := market.input(fs)
+	if err != nil {
+		return nil, err
+	}
+	if err := validMethod(input.Method); err != nil {
+		return nil, err
+	}
+	if err := validator.ValidateCalculationInput(input); err != nil {
+		return nil, err
+	}
+	return calculator.NewCalculator(input.Method).Calculate(input)
+}
+
+// batchWriter streams results as they are calculated.
+type batchWriter struct {
+	w   io.Writer
+	csv *csv.Writer
+}
+
+func newBatchWriter(w io.Writer, format string) (*batchWriter, error) {
+	switch format {
+	case "jsonl", string(types.OutputJSON):
+		return &batchWriter{w: w}, nil
+	case string(types.OutputCSV):
+		out := &batchWriter{w: w, csv: csv.NewWriter(w)}
+		out.csv.Write(formatter.BatchCSVHeader)
+		out.csv.Flush()
+		return out, out.csv.Error()
+	}
+	return nil, fmt.Errorf("unknown batch format '%s' (use jsonl or csv)", format)
+}
+
+func (b *batchWriter) write(line int, result *types.CalculationResult) error {
+	if b.csv == nil {
+		output, err := formatter.FormatBatchJSON(line, result)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(b.w, output)
+		return err
+	}
+	b.csv.WriteAll(formatter.FormatBatchCSV(line, result))
+	return b.csv.Error()
+}
+
+func printBatchUsage(w io.Writer) {
+	fmt.Fprint(w, `Kelly - Batch Calculation
+
+USAGE:
+  kelly batch [flags] < markets.csv
+  kelly batch --input markets.jsonl [flags]
+
+Reads one market per CSV row or JSON line and writes each result as it is
+calculated, as JSON lines or CSV rows carrying the input line number.
+Columns and fields are named after kelly calc's long flags, with dashes or
+underscores, such as odds_a, odds_b, prob_a, prob_b, name_a, total, or
+odds, probs and names for lists. Flags given to batch are the defaults
+for every row; a row's values override them and empty cells keep them.
+Other columns, such as event, are ignored with a warning.
+
+Rows that cannot be calculated are reported on stderr with their line
+number and skipped. The exit status is non-zero only when every row
+failed.
+
+EXAMPLES:
+  kelly batch -m kelly --kelly-fraction 0.25 < candidates.csv
+  kelly batch --input candidates.jsonl -f csv > stakes.csv
+
+  candidates.csv:
+    event,odds_a,odds_b,prob_a,prob_b,total
+    Arsenal v Spurs,2.10,3.50,0.55,0.40,1000
+
+  candidates.jsonl:
+    {"odds": [2.1, 3.4, 3.6], "probs": [0.5, 0.27, 0.23], "method": "kelly", "total": 1000}
+
+`)
+}
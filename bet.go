@@ -0,0 +1,278 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/codehakase/kelly/internal/cli"
+	"github.com/codehakase/kelly/internal/formatter"
+	"github.com/codehakase/kelly/internal/journal"
+	"github.com/codehakase/kelly/pkg/types"
+)
+
+var betCommand = &cli.Command{
+	Name:        "bet",
+	Usage:       "bet <command> [flags]",
+	Summary:     "Manage the bet journal (add, settle, void, list)",
+	Help:        printBetUsage,
+	Flags:       func(*flag.FlagSet) func(args []string) { return runBet },
+	Subcommands: betSubcommands,
+}
+
+var betSubcommands = []string{"add", "settle", "void", "list"}
+
+// runBet handles the "kelly bet" subcommands that manage the bet journal.
+func runBet(args []string) {
+	if len(args) == 0 {
+		printBetUsage(os.Stderr)
+		os.Exit(1)
+	}
+
+	sub, args := args[0], args[1:]
+	fs := flag.NewFlagSet("bet "+sub, flag.ExitOnError)
+	fs.Usage = func() {
+		printBetUsage(fs.Output())
+		fmt.Fprintln(fs.Output(), "FLAGS:")
+		cli.PrintFlags(fs)
+	}
+	run := betFlags(sub, fs)
+	if run == nil {
+		fmt.Fprintf(os.Stderr, "✗ Error: unknown bet command '%s'\n", sub)
+		printBetUsage(os.Stderr)
+		os.Exit(1)
+	}
+	run(args)
+}
+
+// betFlags defines the flags of a bet subcommand and returns the function
+// that parses them and runs it, or nil for an unknown subcommand.
+func betFlags(sub string, fs *flag.FlagSet) func(args []string) {
+	path := fs.String("journal", "", "Journal file (default $KELLY_JOURNAL or <config dir>/kelly/journal.jsonl)")
+	fs.String("profile", "", "Config profile to use (default $KELLY_PROFILE or the config's profile)")
+
+	switch sub {
+	case "add":
+		file := fs.String("file", "-", "JSON calculation result to record (- for stdin)")
+		bankrollName := fs.String("bankroll", "", "Bankroll the bet is staked from (default bankroll if unset)")
+		return func(args []string) {
+			parseFlags(fs, args)
+			result, err := readResult(*file)
+			if err != nil {
+				fatal(err)
+			}
+			name, err := betBankroll(*bankrollName, result.Currency)
+			if err != nil {
+				fatal(err)
+			}
+			bet, err := openJournal(*path).Add(result, name)
+			if err != nil {
+				fatal(err)
+			}
+			fmt.Printf("✓ Recorded bet #%d (%s, pending)\n", bet.ID, bet.Result.Method)
+		}
+
+	case "settle":
+		winner := fs.String("winner", "", "Winning option: letter (A), number (1), name, or none")
+		return func(args []string) {
+			id := parseBetID(fs, args)
+			j := openJournal(*path)
+			bet, err := j.Settle(id, *winner)
+			if err != nil {
+				// A bet settled in the journal may not have reached its
+				// bankroll; the bankroll records each bet once, so
+				// settling it again retries the update.
+				prev, getErr := j.Get(id)
+				if getErr != nil || prev.Status != types.BetSettled || prev.Bankroll == "" {
+					fatal(err)
+				}
+				bet = prev
+				fmt.Printf("✓ Bet #%d is already settled: winner %s, P&L %s%+.2f\n", bet.ID, bet.Winner, bet.Result.Currency, bet.PnL.Float64())
+			} else {
+				fmt.Printf("✓ Settled bet #%d: winner %s, P&L %s%+.2f\n", bet.ID, bet.Winner, bet.Result.Currency, bet.PnL.Float64())
+			}
+			if bet.Bankroll != "" {
+				b, err := openBankrolls("").Settle(bet)
+				if err != nil {
+					fatal(fmt.Errorf("updating bankroll: %w; run 'kelly bet settle %d' again to retry", err, bet.ID))
+				}
+				fmt.Printf("✓ %s balance: %s%.2f\n", b.Name, b.Currency, b.Balance().Float64())
+			}
+		}
+
+	case "void":
+		return func(args []string) {
+			id := parseBetID(fs, args)
+			bet, err := openJournal(*path).Void(id)
+			if err != nil {
+				fatal(err)
+			}
+			fmt.Printf("✓ Voided bet #%d\n", bet.ID)
+		}
+
+	case "list":
+		format := fs.String("format", "table", "Output format (table, json, csv)")
+		status := fs.String("status", "", "Only show bets with this status (pending, settled, void)")
+		cli.Alias(fs, "f", "format")
+		return func(args []string) {
+			parseFlags(fs, args)
+			bets, err := openJournal(*path).List()
+			if err != nil {
+				fatal(err)
+			}
+			if *status != "" {
+				bets = filterBets(bets, types.BetStatus(*status))
+			}
+			output, err := formatBets(bets, *format)
+			if err != nil {
+				fatal(err)
+			}
+			fmt.Print(output)
+		}
+	}
+	return nil
+}
+
+func openJournal(path string) *journal.Journal {
+	if path == "" {
+		var err error
+		if path, err = journal.DefaultPath(); err != nil {
+			fatal(err)
+		}
+	}
+	return journal.Open(path)
+}
+
+// betBankroll returns the bankroll a new bet is staked from: the named one,
+// the default one, or none when no bankroll has been created. Settling the
+// bet credits its P&L to the bankroll, so a bet in another currency is
+// rejected.
+func betBankroll(name, currency string) (string, error) {
+	bankrolls, defaultName, err := openBankrolls("").List()
+	if err != nil {
+		return "", err
+	}
+	if name == "" {
+		name = defaultName
+	}
+	if name == "" {
+		return "", nil
+	}
+	for _, b := range bankrolls {
+		if !strings.EqualFold(b.Name, name) {
+			continue
+		}
+		if b.Currency != "" && currency != "" && b.Currency != currency {
+			return "", fmt.Errorf("bet is in %s but bankroll '%s' is in %s; recalculate with --currency '%s' or pick another --bankroll",
+				currency, b.Name, b.Currency, b.Currency)
+		}
+		return b.Name, nil
+	}
+	return "", fmt.Errorf("bankroll '%s' not found", name)
+}
+
+// recordBet adds a calculation result to the default journal.
+func recordBet(result *types.CalculationResult, bankrollName string) error {
+	path, err := journal.DefaultPath()
+	if err != nil {
+		return err
+	}
+	name, err := betBankroll(bankrollName, result.Currency)
+	if err != nil {
+		return err
+	}
+	bet, err := journal.Open(path).Add(result, name)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "✓ Recorded bet #%d in %s\n", bet.ID, path)
+	return nil
+}
+
+// parseBetID accepts the bet ID before or after the flags, so both
+// "settle 3 --winner A" and "settle --winner A 3" work.
+func parseBetID(fs *flag.FlagSet, args []string) int {
+	var idArg string
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		idArg, args = args[0], args[1:]
+	}
+	parseFlags(fs, args)
+	if idArg == "" && fs.NArg() > 0 {
+		idArg = fs.Arg(0)
+	}
+	if idArg == "" {
+		fatal(fmt.Errorf("%s requires a bet ID", fs.Name()))
+	}
+	id, err := strconv.Atoi(idArg)
+	if err != nil || id <= 0 {
+		fatal(fmt.Errorf("invalid bet ID '%s'", idArg))
+	}
+	return id
+}
+
+func readResult(file string) (*types.CalculationResult, error) {
+	var r io.Reader = os.Stdin
+	if file != "-" {
+		f, err := os.Open(file)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	var result types.CalculationResult
+	if err := json.NewDecoder(r).Decode(&result); err != nil {
+		return nil, fmt.Errorf("reading calculation result: %w", err)
+	}
+	return &result, nil
+}
+
+func filterBets(bets []types.Bet, status types.BetStatus) []types.Bet {
+	var filtered []types.Bet
+	for _, bet := range bets {
+		if bet.Status == status {
+			filtered = append(filtered, bet)
+		}
+	}
+	return filtered
+}
+
+func formatBets(bets []types.Bet, format string) (string, error) {
+	switch types.OutputFormat(format) {
+	case types.OutputJSON:
+		output, err := formatter.FormatBetsJSON(bets)
+		return output + "\n", err
+	case types.OutputCSV:
+		return formatter.FormatBetsCSV(bets)
+	default:
+		return formatter.FormatBetsTable(bets), nil
+	}
+}
+
+func printBetUsage(w io.Writer) {
+	fmt.Fprintf(w, `Kelly - Bet Journal
+
+USAGE:
+  kelly bet add [--file f] [--bankroll b]  Record a JSON calculation result as a pending bet
+  kelly bet settle <id> --winner <option>  Settle a bet (option letter, number, name, or none;
+                                           the winning legs, e.g. A,C, for a parlay)
+  kelly bet void <id>                      Void a bet and return its stakes
+  kelly bet list [-f table|json|csv] [--status pending|settled|void]
+
+EXAMPLES:
+  kelly -a 2.56 -b 3.85 -t 10000 --record
+  kelly -a 2.56 -b 3.85 -t 10000 -f json | kelly bet add
+  kelly bet settle 1 --winner A
+  kelly bet settle 2 --winner A,C        Settle a parlay by its winning legs
+  kelly bet list --status pending
+
+All commands accept --journal to use a journal file other than the default.
+Settling a bet credits its P&L to the bankroll it was recorded against. If
+that fails, settle the bet again to retry; it is never credited twice.
+`)
+}
@@ -0,0 +1,340 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/codehakase/kelly/internal/cli"
+)
+
+// commands are kelly's subcommands, in the order "kelly help" lists them.
+// They are set in init because completion and help refer back to them.
+var commands []*cli.Command
+
+func init() {
+	commands = []*cli.Command{
+		calcCommand, compareCommand, batchCommand, convertCommand, scanCommand,
+		betCommand, bankrollCommand, simulateCommand, backtestCommand,
+		serveCommand, tuiCommand, configCommand, completionCommand, helpCommand, versionCommand,
+	}
+	for _, c := range commands {
+		helpCommand.Subcommands = append(helpCommand.Subcommands, c.Name)
+	}
+}
+
+// bareCommand is kelly run with flags and no command: kelly calc, with -i
+// to open the TUI instead and --version.
+var bareCommand = &cli.Command{
+	Name:  "kelly",
+	Help:  printUsage,
+	Flags: withConfig(func(fs *flag.FlagSet) func(args []string) { return defineCalc(fs, true) }),
+}
+
+var calcCommand = &cli.Command{
+	Name:    "calc",
+	Usage:   "calc [flags]",
+	Summary: "Calculate stakes for a market",
+	Help:    printCalcUsage,
+	Flags:   withConfig(func(fs *flag.FlagSet) func(args []string) { return defineCalc(fs, false) }),
+}
+
+// defineCalc defines the flags of kelly calc. Bare, without the command
+// name, a run without a market opens the TUI rather than failing.
+func defineCalc(fs *flag.FlagSet, bare bool) func(args []string) {
+	var flags calcFlags
+	flags.register(fs)
+	var interactive, version bool
+	if bare {
+		fs.BoolVar(&interactive, "interactive", false, "Force interactive TUI mode")
+		fs.BoolVar(&version, "version", false, "Show version information")
+		cli.Alias(fs, "i", "interactive")
+	}
+
+	return func(args []string) {
+		if version {
+			printVersion()
+			return
+		}
+		if err := flags.output.parse(); err != nil {
+			fatal(err)
+		}
+		if interactive || bare && !flags.market.given(fs) {
+			runInteractive(fs, &flags.market, flags.output.display)
+			return
+		}
+		mode := "calc"
+		if bare {
+			mode = "CLI mode"
+		}
+		flags.run(fs, mode)
+	}
+}
+
+// run calculates the market, or fails naming the mode that needed it.
+func (c *calcFlags) run(fs *flag.FlagSet, mode string) {
+	if !c.market.hasOdds() || !c.market.hasTotal() {
+		fmt.Fprintf(os.Stderr, "Error: %s %v\n", mode, errNoMarket)
+		fmt.Fprintln(os.Stderr, "Run with -h for usage information")
+		os.Exit(1)
+	}
+	input, err := c.market.input(fs)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "✗ Error: %v\n", err)
+		os.Exit(1)
+	}
+	runCLI(input, c)
+}
+
+var compareCommand = &cli.Command{
+	Name:    "compare",
+	Usage:   "compare [flags]",
+	Summary: "Compare the calculation methods on a market",
+	Help:    printCompareUsage,
+	Flags:   withConfig(compareFlags),
+}
+
+// compareFlags defines the flags of "kelly compare", which prices one
+// market with every general method side by side.
+func compareFlags(fs *flag.FlagSet) func(args []string) {
+	flags := calcFlags{compare: true}
+	flags.market.register(fs)
+	flags.output.register(fs)
+
+	return func(args []string) {
+		if err := flags.output.parse(); err != nil {
+			fatal(err)
+		}
+		flags.run(fs, "compare")
+	}
+}
+
+var tuiCommand = &cli.Command{
+	Name:    "tui",
+	Usage:   "tui [flags]",
+	Summary: "Launch the interactive TUI",
+	Help:    printTUIUsage,
+	Flags:   withConfig(tuiFlags),
+}
+
+// tuiFields are the market flags that fill the TUI's fields.
+var tuiFields = []string{
+	"total", "method", "currency", "kelly-fraction", "max-stake-pct", "min-edge",
+	"commission", "bet-type", "round-to", "hedge-target", "bankroll",
+}
+
+// tuiFlags defines the flags of "kelly tui": the market flags the TUI has
+// fields for, which fill them in.
+func tuiFlags(fs *flag.FlagSet) func(args []string) {
+	var market marketFlags
+	all := flag.NewFlagSet("", flag.ContinueOnError)
+	market.register(all)
+	for _, name := range tuiFields {
+		f := all.Lookup(name)
+		fs.Var(f.Value, name, f.Usage)
+	}
+	cli.Alias(fs, "t", "total")
+	cli.Alias(fs, "m", "method")
+	cli.Alias(fs, "c", "currency")
+	displayOdds := fs.String("display-odds", "", "Show odds in this format: decimal, percentage, fractional, american, hk, malay or indo")
+
+	return func(args []string) {
+		out := outputFlags{displayOdds: *displayOdds}
+		if err := out.parse(); err != nil {
+			fatal(err)
+		}
+		runInteractive(fs, &market, out.display)
+	}
+}
+
+var completionCommand = &cli.Command{
+	Name:        "completion",
+	Usage:       "completion <shell>",
+	Summary:     "Print a shell completion script (bash, zsh, fish)",
+	Help:        printCompletionUsage,
+	Flags:       completionFlags,
+	Subcommands: cli.Shells,
+}
+
+func completionFlags(fs *flag.FlagSet) func(args []string) {
+	return func(args []string) {
+		if len(args) != 1 {
+			fs.Usage()
+			os.Exit(1)
+		}
+		script, err := cli.Completion(args[0], "kelly", commands)
+		if err != nil {
+			fatal(err)
+		}
+		fmt.Print(script)
+	}
+}
+
+var helpCommand = &cli.Command{
+	Name:    "help",
+	Usage:   "help [command]",
+	Summary: "Show help for kelly or one of its commands",
+	Flags:   helpFlags,
+}
+
+func helpFlags(fs *flag.FlagSet) func(args []string) {
+	return func(args []string) {
+		if len(args) == 0 {
+			printUsage(os.Stdout)
+			return
+		}
+		cmd := cli.Find(commands, args[0])
+		if cmd == nil {
+			fatal(fmt.Errorf("unknown command '%s'", args[0]))
+		}
+		cmdFlags := cmd.FlagSet()
+		cmdFlags.SetOutput(os.Stdout)
+		cmd.PrintHelp(cmdFlags)
+	}
+}
+
+var versionCommand = &cli.Command{
+	Name:    "version",
+	Usage:   "version",
+	Summary: "Show version information",
+	Flags:   func(*flag.FlagSet) func(args []string) { return func([]string) { printVersion() } },
+}
+
+func printVersion() {
+	fmt.Printf("Kelly Calculator %s (built %s)\n", Version, BuildTime)
+}
+
+func printUsage(w io.Writer) {
+	fmt.Fprint(w, `Kelly - Optimal Betting Stake Calculator
+
+USAGE:
+  kelly                       Launch the interactive TUI
+  kelly <command> [flags]     Run a command
+  kelly [flags]               The same as kelly calc [flags]
+
+COMMANDS:
+`)
+	for _, c := range commands {
+		fmt.Fprintf(w, "  %-12s %s\n", c.Name, c.Summary)
+	}
+	fmt.Fprint(w, `
+EXAMPLES:
+  kelly calc -a 2.56 -b 3.85 -t 10000
+  kelly -a 2.56 -b 3.85 -t 10000
+  kelly compare -a 2.1 -b 3.5 -t 1000 --prob-a 0.55 --prob-b 0.40
+  kelly convert 5/2 -150 hk:0.95
+  kelly help calc
+
+Run 'kelly help <command>' or 'kelly <command> -h' for a command's flags
+and examples. For more information, visit: https://github.com/codehakase/kelly
+
+`)
+}
+
+func printCalcUsage(w io.Writer) {
+	fmt.Fprintf(w, `Kelly - Stake Calculator
+
+USAGE:
+  kelly calc [flags]
+  kelly [flags]
+
+EXAMPLES:
+  kelly calc -a 2.56 -b 3.85 -t 10000
+  kelly calc --odds-a 39%% --odds-b 26%% --total 10000
+  kelly calc -a my:-0.80 -b hk:0.95 -t 1000
+  kelly calc -a -1.25 -b 1.40 -t 1000 --odds-format indo
+  kelly calc -a 2.56 -b 3.85 -t 10000 --display-odds american
+  kelly calc -a 2.56 -b 3.85 -t 10000 --name-a "Davido" --name-b "Tyla" --currency "₦"
+  kelly calc -a 2.1 -b 3.5 -t 1000 --method kelly --prob-a 0.55 --prob-b 0.40
+  kelly calc --odds 2.9,3.6,4.2 --names Home,Draw,Away -t 10000
+  kelly calc --odds 2.1,3.4,3.6 --probs 0.5,0.27,0.23 -t 1000 --method kelly
+  kelly calc --odds 2.1,3.4,3.6 --probs 0.5,0.27,0.23 -t 1000 --method kelly-simultaneous
+  kelly calc -a 2.1 -b 3.5 -t 1000 --method kelly --prob-a 0.55 --prob-b 0.40 --kelly-fraction 0.25 --max-stake-pct 5
+  kelly calc -a 2.2 -b 1.9 -t 1000 --method kelly --fair-from shin --sharp-a 2.05 --sharp-b 1.85
+  kelly calc -a 3.2 -b 3.1 -t 1000 --side back,lay --commission 0,0.02
+  kelly calc -a 4.0 -b 4.2 -t 25 --method matched --commission 0.02 --bet-type free-snr
+  kelly calc -a 5.0 -b 3.0 -t 100 --method hedge --side back,lay --commission 0,0.02
+  kelly calc -a 3.0 -b 2.0 -t 100 --method hedge --hedge-target free-bet
+  kelly calc -a 9.0 -t 20 --method each-way --place-terms 1/5 --places 4
+  kelly calc --odds 9.0,9.4,2.6 -t 20 --method each-way --place-terms 1/5 --places 4 --commission 0,0.02,0.02
+  kelly calc --odds 1.8,2.1,1.9 --probs 0.6,0.5,0.55 -t 10 --method parlay
+  kelly calc --odds 1.8,2.1,1.9,2.5 -t 11 --method parlay --system yankee
+  kelly calc -a 2.10 -b 1.95 -t 1000 --asian handicap --lines -0.25 -v
+  kelly calc -a 2.05 -b 1.85 -t 1000 -m kelly --asian total --lines 2.25 -pa 0.45 -pb 0.35
+  kelly calc -a 2.56 -b 3.85 -t 10000 --round-to 5 --round-mode stealth -v
+  kelly calc -a 2.56 -b 3.85 -t 10000 --max-stake 2000, -v
+  kelly calc -a 2.60 -b 2.55 -t 10000 --tax 0.2 -v
+  kelly calc -a 2.10 -b 2.15 -t 100000 --currencies NGN,USD --rates rates.json -v
+  kelly calc -a 2.10 -b 2.15 -t 100000 --currencies NGN,USD --rates rates.json --max-stake ,20 --round-to 1
+  kelly calc -a 2.56 -b 3.85 -t 10000 -f json
+  kelly calc -a 2.56 -b 3.85 -t 10000 --record
+  kelly calc -a 2.1 -b 3.5 --method kelly --prob-a 0.55 --prob-b 0.40 --bankroll main
+
+ODDS FORMATS:
+  Decimal:     2.5, 3.85
+  Percentage:  39%%, 26%%
+  Fractional:  3/2, 5/2
+  American:    +250, -150
+  Hong Kong:   hk:1.50
+  Malay:       my:-0.80, my:0.75
+  Indonesian:  id:-1.25, id:+1.50
+
+CALCULATION METHODS:
+  arbitrage     Guarantees profit regardless of outcome (default)
+  kelly         Maximizes growth based on probability estimates
+  kelly-simultaneous
+                Maximizes expected log-wealth over all outcomes jointly
+  matched       Lay stake for a bookmaker back bet (-a back odds, -b lay odds,
+                -t back stake, --commission, --bet-type)
+  hedge         Stake that locks in a result against an open back bet
+  each-way      Win and place stakes of each-way bets (--place-terms, --places)
+  parlay        Accumulator or system bet over the options (--system)
+
+The proportional method is retired: its stakes, in proportion to 1/odds,
+are the arbitrage stakes, and --method proportional runs arbitrage.
+
+`)
+}
+
+func printCompareUsage(w io.Writer) {
+	fmt.Fprint(w, `Kelly - Method Comparison
+
+USAGE:
+  kelly compare [flags]
+
+Prices the market with arbitrage, Kelly and simultaneous Kelly staking one
+after another. Methods that need probabilities are skipped without them.
+
+EXAMPLES:
+  kelly compare -a 2.56 -b 3.85 -t 10000
+  kelly compare -a 2.1 -b 3.5 -t 1000 --prob-a 0.55 --prob-b 0.40
+
+`)
+}
+
+func printTUIUsage(w io.Writer) {
+	fmt.Fprint(w, `Kelly - Interactive Calculator
+
+USAGE:
+  kelly tui [flags]
+  kelly
+
+`)
+}
+
+func printCompletionUsage(w io.Writer) {
+	fmt.Fprint(w, `Kelly - Shell Completion
+
+USAGE:
+  kelly completion bash|zsh|fish
+
+Prints a script completing kelly's commands and their flags.
+
+EXAMPLES:
+  source <(kelly completion bash)
+  kelly completion zsh > "${fpath[1]}/_kelly"
+  kelly completion fish > ~/.config/fish/completions/kelly.fish
+
+`)
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/codehakase/kelly/internal/cli"
+	"github.com/codehakase/kelly/internal/config"
+)
+
+// withConfig adds --profile to a command's flags and fills the flags left
+// off the command line from the environment, the profile and the config
+// file before the command runs.
+func withConfig(define func(fs *flag.FlagSet) func(args []string)) func(fs *flag.FlagSet) func(args []string) {
+	return func(fs *flag.FlagSet) func(args []string) {
+		fs.String("profile", "", "Config profile to use (default $KELLY_PROFILE or the config's profile)")
+		run := define(fs)
+		return func(args []string) {
+			if err := applyConfig(fs); err != nil {
+				fatal(err)
+			}
+			run(args)
+		}
+	}
+}
+
+// parseFlags parses the flags of a bet or bankroll subcommand and applies
+// the config to them.
+func parseFlags(fs *flag.FlagSet, args []string) {
+	fs.Parse(args)
+	if err := applyConfig(fs); err != nil {
+		fatal(err)
+	}
+}
+
+// applyConfig sets the flags of fs not given on the command line from the
+// config, under the profile picked by fs's --profile.
+func applyConfig(fs *flag.FlagSet) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	var profile string
+	if f := fs.Lookup("profile"); f != nil {
+		profile = f.Value.String()
+	}
+	if profile, err = cfg.Profile(profile, os.Getenv); err != nil {
+		return err
+	}
+	return cfg.Apply(fs, profile, os.Getenv)
+}
+
+// loadConfig reads the config file and checks that every setting names a
+// flag of some command.
+func loadConfig() (*config.Config, error) {
+	path, err := config.DefaultPath()
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := config.Load(path)
+	if err != nil {
+		return nil, err
+	}
+	known := make(map[string]bool)
+	for _, s := range settingFlags() {
+		known[s.name] = true
+	}
+	if unknown := cfg.Unknown(known); len(unknown) > 0 {
+		return nil, fmt.Errorf("%s: unknown setting %s", path, strings.Join(unknown, ", "))
+	}
+	return cfg, nil
+}
+
+// settingFlag is a flag the config can set, as the first command to define
+// it has it.
+type settingFlag struct {
+	name string
+	flag *flag.Flag
+}
+
+// settingFlags returns the flags of every command and bet and bankroll
+// subcommand by long name, sorted.
+func settingFlags() []settingFlag {
+	var sets []*flag.FlagSet
+	for _, c := range commands {
+		sets = append(sets, c.FlagSet())
+	}
+	for _, sub := range betSubcommands {
+		fs := flag.NewFlagSet("bet "+sub, flag.ContinueOnError)
+		betFlags(sub, fs)
+		sets = append(sets, fs)
+	}
+	for _, sub := range bankrollSubcommands {
+		fs := flag.NewFlagSet("bankroll "+sub, flag.ContinueOnError)
+		bankrollFlags(sub, fs)
+		sets = append(sets, fs)
+	}
+
+	seen := make(map[string]bool)
+	var flags []settingFlag
+	for _, fs := range sets {
+		for _, names := range cli.FlagGroups(fs) {
+			name := names[len(names)-1]
+			if seen[name] || !config.Settable(name) {
+				continue
+			}
+			seen[name] = true
+			flags = append(flags, settingFlag{name, fs.Lookup(name)})
+		}
+	}
+	sort.Slice(flags, func(i, j int) bool { return flags[i].name < flags[j].name })
+	return flags
+}
+
+var configCommand = &cli.Command{
+	Name:        "config",
+	Usage:       "config show [--profile name]",
+	Summary:     "Show the settings in effect and where they come from",
+	Help:        printConfigUsage,
+	Flags:       configFlags,
+	Subcommands: []string{"show"},
+}
+
+func configFlags(fs *flag.FlagSet) func(args []string) {
+	profileFlag := fs.String("profile", "", "Config profile to show (default $KELLY_PROFILE or the config's profile)")
+
+	return func(args []string) {
+		if len(args) == 0 || args[0] != "show" {
+			fs.Usage()
+			os.Exit(1)
+		}
+		// Flags may follow show, as in config show --profile exchange-uk.
+		fs.Parse(args[1:])
+		if fs.NArg() > 0 {
+			fs.Usage()
+			os.Exit(1)
+		}
+		cfg, err := loadConfig()
+		if err != nil {
+			fatal(err)
+		}
+		profile, err := cfg.Profile(*profileFlag, os.Getenv)
+		if err != nil {
+			fatal(err)
+		}
+
+		fmt.Printf("# config: %s\n", cfg.Path)
+		if profile != "" {
+			fmt.Printf("# profile: %s\n", profile)
+		}
+		for _, s := range settingFlags() {
+			setting, ok := cfg.Lookup(s.name, profile, os.Getenv)
+			if !ok {
+				setting = config.Setting{Value: s.flag.DefValue, Source: "default"}
+			}
+			line := s.name + " = " + tomlValue(s.flag, setting.Value)
+			fmt.Printf("%-48s # %s\n", line, setting.Source)
+		}
+	}
+}
+
+// tomlValue writes a flag value as the config file would hold it: numbers
+// and booleans bare, everything else quoted.
+func tomlValue(f *flag.Flag, value string) string {
+	if typ, _ := flag.UnquoteUsage(f); typ == "float" || typ == "int" || typ == "uint" || typ == "" {
+		if _, err := strconv.ParseFloat(value, 64); err == nil || value == "true" || value == "false" {
+			return value
+		}
+	}
+	return strconv.Quote(value)
+}
+
+func printConfigUsage(w io.Writer) {
+	fmt.Fprint(w, `Kelly - Configuration
+
+USAGE:
+  kelly config show [--profile name]
+
+Settings are read from $KELLY_CONFIG or <config dir>/kelly/config.toml,
+by long flag name. Top-level settings apply to every command with the
+flag; a profile's settings apply over them when it is picked with
+--profile, $KELLY_PROFILE or a top-level profile setting:
+
+  currency = "£"
+  kelly-fraction = 0.25
+  profile = "exchange-uk"
+
+  [profiles.exchange-uk]
+  commission = 0.02
+  round-to = 5
+  kelly-fraction = 0.5
+
+An environment variable named KELLY_ and the flag in upper case, such as
+KELLY_KELLY_FRACTION, overrides both, and a flag on the command line
+overrides everything. The TUI starts from the same settings.
+
+config show prints the value of every setting and where it comes from.
+Defaults are those of the first command with the flag, as listed by
+kelly help.
+
+`)
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/codehakase/kelly/internal/cli"
+	"github.com/codehakase/kelly/internal/formatter"
+	"github.com/codehakase/kelly/internal/parser"
+	"github.com/codehakase/kelly/pkg/types"
+)
+
+var convertCommand = &cli.Command{
+	Name:    "convert",
+	Usage:   "convert [flags] <odds>...",
+	Summary: "Show odds in every format",
+	Help:    printConvertUsage,
+	Flags:   withConfig(convertFlags),
+}
+
+// convertFlags defines the flags of "kelly convert", which prints odds in
+// every format.
+func convertFlags(fs *flag.FlagSet) func(args []string) {
+	oddsFormat := fs.String("odds-format", "", "Format of the odds: decimal, percentage, fractional, american, hk, malay or indo (detected by default)")
+
+	return func(args []string) {
+		if len(args) == 0 {
+			fs.Usage()
+			os.Exit(1)
+		}
+
+		var format types.OddsFormat
+		if *oddsFormat != "" {
+			var err error
+			if format, err = parser.ParseOddsFormat(*oddsFormat); err != nil {
+				fatal(err)
+			}
+		}
+
+		blocks := make([]string, len(args))
+		for i, arg := range args {
+			odds, err := parser.ParseOddsAs(arg, format)
+			if err != nil {
+				fatal(err)
+			}
+			if odds <= 1.0 {
+				fatal(fmt.Errorf("odds must be greater than 1.0, got '%s'", arg))
+			}
+			blocks[i] = formatter.FormatConversions(odds)
+			if len(args) > 1 {
+				blocks[i] = arg + "\n" + blocks[i]
+			}
+		}
+		fmt.Println(strings.TrimRight(strings.Join(blocks, "\n"), "\n"))
+	}
+}
+
+func printConvertUsage(w io.Writer) {
+	fmt.Fprintf(w, `Kelly - Odds Converter
+
+USAGE:
+  kelly convert [flags] <odds>...
+
+Prints each price in every odds format. Fractional odds are the nearest
+price on the ladder bookmakers quote, so 2.45 shows as 6/4. Odds that
+start with a minus sign come after --, as in kelly convert -- -150.
+
+EXAMPLES:
+  kelly convert 5/2
+  kelly convert 3.5 40%% hk:0.95
+  kelly convert --odds-format malay -- -0.80 0.75
+
+`)
+}
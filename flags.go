@@ -0,0 +1,285 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strconv"
+
+	"github.com/codehakase/kelly/internal/cli"
+	"github.com/codehakase/kelly/internal/config"
+	"github.com/codehakase/kelly/internal/fx"
+	"github.com/codehakase/kelly/internal/parser"
+	"github.com/codehakase/kelly/internal/ui"
+	"github.com/codehakase/kelly/pkg/money"
+	"github.com/codehakase/kelly/pkg/types"
+)
+
+// marketFlags describe a market and how to size it. They are shared by the
+// default calculation and the subcommands that run calculations.
+type marketFlags struct {
+	oddsA, oddsB, nameA, nameB     string
+	oddsList, namesList, probsList string
+	probA, probB, total            float64
+	method, currency               string
+	kellyFrac, maxStakePct         float64
+	minEdge                        float64
+	fairFrom                       string
+	sharpA, sharpB, sharpList      string
+	sides, commissions, betType    string
+	bankroll                       string
+	roundTo                        float64
+	roundMode                      string
+	minStakes, maxStakes           string
+	taxes, excises                 string
+	hedgeTarget                    string
+	placeTerms                     string
+	places                         int
+	placeProb                      float64
+	system                         string
+	asian, lines                   string
+	oddsFormat                     string
+	currencies, rates, base        string
+}
+
+func (f *marketFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.oddsA, "odds-a", "", "Odds for Option A")
+	fs.StringVar(&f.oddsB, "odds-b", "", "Odds for Option B")
+	fs.Float64Var(&f.total, "total", 0, "Total amount to allocate")
+	fs.StringVar(&f.method, "method", "arbitrage", "Calculation method (arbitrage, kelly, kelly-simultaneous, matched, hedge, each-way, parlay)")
+	fs.Float64Var(&f.probA, "prob-a", 0, "Probability for Option A (required for Kelly method)")
+	fs.Float64Var(&f.probB, "prob-b", 0, "Probability for Option B (required for Kelly method)")
+	fs.StringVar(&f.nameA, "name-a", "Option A", "Name/label for Option A")
+	fs.StringVar(&f.nameB, "name-b", "Option B", "Name/label for Option B")
+	fs.StringVar(&f.currency, "currency", "₦", "Currency symbol")
+	for short, long := range map[string]string{
+		"a": "odds-a", "b": "odds-b", "t": "total", "m": "method",
+		"pa": "prob-a", "pb": "prob-b", "na": "name-a", "nb": "name-b", "c": "currency",
+	} {
+		cli.Alias(fs, short, long)
+	}
+
+	fs.StringVar(&f.oddsFormat, "odds-format", "", "Format of all odds: decimal, percentage, fractional, american, hk, malay or indo (detected by default)")
+	fs.StringVar(&f.oddsList, "odds", "", "Comma-separated odds for every option (e.g. 2.1,3.4,3.6)")
+	fs.StringVar(&f.namesList, "names", "", "Comma-separated names matching --odds")
+	fs.StringVar(&f.probsList, "probs", "", "Comma-separated probabilities matching --odds (for Kelly)")
+	fs.Float64Var(&f.kellyFrac, "kelly-fraction", 0, "Fraction of Kelly to stake, e.g. 0.25 (default full Kelly)")
+	fs.Float64Var(&f.maxStakePct, "max-stake-pct", 0, "Maximum stake per option as a percentage of the total")
+	fs.Float64Var(&f.minEdge, "min-edge", 0, "Minimum edge (p × odds - 1) required to bet an option")
+	fs.StringVar(&f.fairFrom, "fair-from", "", "Derive missing probabilities by margin removal (multiplicative, additive, power, shin, odds-ratio)")
+	fs.StringVar(&f.sharpA, "sharp-a", "", "Reference (sharp book) odds for Option A, used by --fair-from")
+	fs.StringVar(&f.sharpB, "sharp-b", "", "Reference (sharp book) odds for Option B, used by --fair-from")
+	fs.StringVar(&f.sharpList, "sharp", "", "Comma-separated reference odds matching --odds, used by --fair-from")
+	fs.StringVar(&f.sides, "side", "", "Comma-separated side per option: back or lay (default back)")
+	fs.StringVar(&f.commissions, "commission", "", "Commission on net winnings, one rate for all options or one per option (e.g. 0,0.02)")
+	fs.StringVar(&f.betType, "bet-type", "qualifying", "Matched betting bet type (qualifying, free-snr, free-sr)")
+	fs.StringVar(&f.hedgeTarget, "hedge-target", "equal", "Hedge outcome to lock in (equal, free-bet, break-even)")
+	fs.StringVar(&f.placeTerms, "place-terms", "", "Each-way place terms as a fraction of the odds, e.g. 1/4")
+	fs.IntVar(&f.places, "places", 0, "Number of places paid by an each-way bet")
+	fs.Float64Var(&f.placeProb, "place-prob", 0, "Probability of the each-way selection placing (winning included)")
+	fs.StringVar(&f.system, "system", "", "Parlay system: accumulator (default), a named system such as yankee, or sizes such as 2,3")
+	fs.StringVar(&f.asian, "asian", "", "Price the market on Asian lines: handicap or total (arbitrage and kelly)")
+	fs.StringVar(&f.lines, "lines", "", "Asian lines, one per option or option A's alone (e.g. -0.25 or 2.25)")
+	fs.StringVar(&f.currencies, "currencies", "", "Comma-separated currency code per option (e.g. NGN,USD), converted with --rates")
+	fs.StringVar(&f.rates, "rates", "", "Exchange-rate file (default $KELLY_RATES or <config dir>/kelly/rates.json)")
+	fs.StringVar(&f.base, "base", "", "Currency the total and profits are counted in (default the rates file's base)")
+	fs.StringVar(&f.bankroll, "bankroll", "", "Bankroll to use (default bankroll if unset); supplies --total for Kelly")
+	fs.Float64Var(&f.roundTo, "round-to", 0, "Round back stakes to multiples of this amount, e.g. 5")
+	fs.StringVar(&f.roundMode, "round-mode", "", "Stake rounding: nearest, down or stealth (default nearest with --round-to)")
+	fs.StringVar(&f.minStakes, "min-stake", "", "Bookmaker minimum stake, one for all options or one per option (e.g. 10,)")
+	fs.StringVar(&f.maxStakes, "max-stake", "", "Bookmaker maximum stake, one for all options or one per option (e.g. 500,)")
+	fs.StringVar(&f.taxes, "tax", "", "Tax withheld from net winnings, one rate for all options or one per option (e.g. 0.2)")
+	fs.StringVar(&f.excises, "excise", "", "Excise taken out of each stake, one rate for all options or one per option (e.g. 0.125,0)")
+}
+
+// given reports whether any of the market was given on the command line,
+// so a bare kelly with only some of it is an error rather than the TUI.
+func (f *marketFlags) given(fs *flag.FlagSet) bool {
+	return cli.IsSet(fs, "odds-a") || cli.IsSet(fs, "odds-b") || cli.IsSet(fs, "odds") || cli.IsSet(fs, "total")
+}
+
+// tuiDefaults returns the values of the flags the TUI has fields for, as
+// they would be typed into them. Flags left at their defaults leave the
+// fields empty.
+func (f *marketFlags) tuiDefaults(fs *flag.FlagSet) ui.Defaults {
+	number := func(v float64) string {
+		if v == 0 {
+			return ""
+		}
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	d := ui.Defaults{
+		Method: types.CalculationMethod(f.method), Total: number(f.total),
+		KellyFraction: number(f.kellyFrac), MaxStakePct: number(f.maxStakePct),
+		MinEdge: number(f.minEdge), Commission: f.commissions, RoundTo: number(f.roundTo),
+	}
+	if config.IsSet(fs, "currency") {
+		d.Currency = f.currency
+	}
+	if f.betType != string(types.BetQualifying) {
+		d.BetType = f.betType
+	}
+	if f.hedgeTarget != string(types.HedgeEqual) {
+		d.HedgeTarget = f.hedgeTarget
+	}
+	return d
+}
+
+// hasOdds reports whether a market was given, either as -a/-b or --odds.
+// An each-way bet needs only -a, the selection.
+func (f *marketFlags) hasOdds() bool {
+	if f.oddsA != "" && types.CalculationMethod(f.method) == types.MethodEachWay {
+		return true
+	}
+	return (f.oddsA != "" && f.oddsB != "") || f.oddsList != ""
+}
+
+// hasTotal reports whether the amount to allocate is known: set with
+// --total, or taken from a bankroll for Kelly methods.
+func (f *marketFlags) hasTotal() bool {
+	return f.total > 0 || types.CalculationMethod(f.method).RequiresProbabilities()
+}
+
+// input builds the calculation input described by the flags. When --total
+// is not set the balance of the selected bankroll, less the stakes of its
+// pending bets, is used, along with its currency unless a currency was given
+// or configured.
+func (f *marketFlags) input(fs *flag.FlagSet) (*types.CalculationInput, error) {
+	total, currency := money.FromFloat(f.total), f.currency
+	if total <= 0 {
+		b, err := loadBankroll(f.bankroll)
+		if err != nil {
+			return nil, fmt.Errorf("--total not set and no bankroll to use: %w", err)
+		}
+		pending, err := pendingStakes(b)
+		if err != nil {
+			return nil, err
+		}
+		if b.Balance() <= 0 {
+			return nil, fmt.Errorf("bankroll '%s' is empty", b.Name)
+		}
+		if total = b.Balance() - pending; total <= 0 {
+			return nil, fmt.Errorf("bankroll '%s' has nothing left to stake: %s%s is on pending bets", b.Name, b.Currency, pending)
+		}
+		if !config.IsSet(fs, "currency") {
+			currency = b.Currency
+		}
+	}
+
+	var format types.OddsFormat
+	if f.oddsFormat != "" {
+		var err error
+		if format, err = parser.ParseOddsFormat(f.oddsFormat); err != nil {
+			return nil, err
+		}
+	}
+	options, err := buildOptions(f.oddsA, f.oddsB, f.nameA, f.nameB, f.probA, f.probB,
+		f.oddsList, f.namesList, f.probsList, format)
+	if err != nil {
+		return nil, err
+	}
+	if err := applySharpOdds(options, f.sharpA, f.sharpB, f.sharpList, format); err != nil {
+		return nil, err
+	}
+	if err := applyExchangeTerms(options, f.sides, f.commissions); err != nil {
+		return nil, err
+	}
+	if err := applyStakeLimits(options, f.minStakes, f.maxStakes); err != nil {
+		return nil, err
+	}
+	if err := applyTaxes(options, f.taxes, f.excises); err != nil {
+		return nil, err
+	}
+	if err := applyAsianLines(options, types.AsianMarket(f.asian), f.lines); err != nil {
+		return nil, err
+	}
+	rates, err := applyCurrencies(options, f.currencies, f.rates, f.base)
+	if err != nil {
+		return nil, err
+	}
+	base := fx.Code(f.base)
+	if rates != nil && !config.IsSet(fs, "currency") {
+		if base == "" {
+			base = rates.Base
+		}
+		currency = types.CurrencySymbol(base)
+	}
+
+	var placeFraction float64
+	if f.placeTerms != "" {
+		placeFraction, err = parser.ParsePlaceTerms(f.placeTerms)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &types.CalculationInput{
+		Method:  types.CalculationMethod(f.method),
+		Options: options, TotalStake: total, Currency: currency,
+		KellyFraction: f.kellyFrac, MaxStakePct: f.maxStakePct, MinEdge: f.minEdge,
+		FairFrom: types.MarginMethod(f.fairFrom), BetType: types.BetType(f.betType),
+		HedgeTarget: types.HedgeTarget(f.hedgeTarget), System: f.system,
+		AsianMarket: types.AsianMarket(f.asian), Rates: rates, BaseCurrency: base,
+		PlaceFraction: placeFraction, Places: f.places, PlaceProbability: f.placeProb,
+		RoundTo: f.roundTo, RoundMode: types.RoundMode(f.roundMode),
+	}, nil
+}
+
+// validMethod returns an error naming the accepted methods when method is
+// not one of them. The retired proportional method is still accepted and
+// runs as arbitrage.
+func validMethod(method types.CalculationMethod) error {
+	switch method {
+	case types.MethodArbitrage, types.MethodKelly, types.MethodKellySimultaneous,
+		types.MethodProportional, types.MethodMatched, types.MethodHedge, types.MethodEachWay,
+		types.MethodParlay:
+		return nil
+	}
+	return fmt.Errorf("invalid method '%s'. Must be: arbitrage, kelly, kelly-simultaneous, matched, hedge, each-way, or parlay", method)
+}
+
+var errNoMarket = errors.New("requires --odds-a and --odds-b (or --odds), and --total (or a bankroll for Kelly)")
+
+// outputFlags control how a calculation result is printed. They are shared
+// by the commands that print results.
+type outputFlags struct {
+	format, displayOdds string
+	verbose, noColor    bool
+
+	// display is --display-odds, read by parse.
+	display types.OddsFormat
+}
+
+func (o *outputFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.format, "format", "table", "Output format (table, json, csv)")
+	fs.StringVar(&o.displayOdds, "display-odds", "", "Show odds in this format: decimal, percentage, fractional, american, hk, malay or indo")
+	fs.BoolVar(&o.verbose, "verbose", false, "Verbose output with explanations")
+	fs.BoolVar(&o.noColor, "no-color", false, "Disable colored output")
+	cli.Alias(fs, "f", "format")
+	cli.Alias(fs, "v", "verbose")
+}
+
+// parse reads the display odds format.
+func (o *outputFlags) parse() error {
+	if o.displayOdds == "" {
+		return nil
+	}
+	var err error
+	o.display, err = parser.ParseOddsFormat(o.displayOdds)
+	return err
+}
+
+// calcFlags are the flags of kelly calc: the market, how to print its
+// result, and what else to do with it.
+type calcFlags struct {
+	market          marketFlags
+	output          outputFlags
+	compare, record bool
+}
+
+func (c *calcFlags) register(fs *flag.FlagSet) {
+	c.market.register(fs)
+	c.output.register(fs)
+	fs.BoolVar(&c.compare, "compare", false, "Compare all calculation methods, as kelly compare does")
+	fs.BoolVar(&c.record, "record", false, "Record the result as a pending bet in the journal")
+}
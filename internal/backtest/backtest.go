@@ -0,0 +1,291 @@
+package backtest
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/codehakase/kelly/internal/calculator"
+	"github.com/codehakase/kelly/internal/journal"
+	"github.com/codehakase/kelly/internal/parser"
+	"github.com/codehakase/kelly/internal/validator"
+	"github.com/codehakase/kelly/pkg/money"
+	"github.com/codehakase/kelly/pkg/types"
+)
+
+// Columns maps CSV header names to the fields of a market. Odds and Result
+// are required; Probs and Sharp, when set, list one column per odds column.
+// Names label the options and default to the odds column names.
+type Columns struct {
+	Odds   []string
+	Probs  []string
+	Sharp  []string
+	Names  []string
+	Result string
+	Date   string
+	Event  string
+}
+
+// Config describes the strategy to replay. Every row is sized from the
+// bankroll as it stands after the rows before it.
+type Config struct {
+	Method           types.CalculationMethod
+	StartingBankroll float64
+	Currency         string
+	KellyFraction    float64
+	MaxStakePct      float64
+	MinEdge          float64
+	FairFrom         types.MarginMethod
+	Columns          Columns
+}
+
+// Run replays every row of the CSV in r through cfg.Method. Rows that
+// cannot be priced or have no value are recorded in the ledger as skipped;
+// only a malformed file or column mapping is an error.
+func Run(r io.Reader, cfg Config) (*types.BacktestResult, error) {
+	if cfg.StartingBankroll <= 0 {
+		return nil, errors.New("starting bankroll must be positive")
+	}
+	if len(cfg.Columns.Odds) < 2 {
+		return nil, errors.New("at least two odds columns are required")
+	}
+	if cfg.Columns.Result == "" {
+		return nil, errors.New("a result column is required")
+	}
+
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("reading header: %w", err)
+	}
+	idx, err := columnIndexes(header, cfg.Columns)
+	if err != nil {
+		return nil, err
+	}
+
+	names := cfg.Columns.Names
+	if len(names) == 0 {
+		names = cfg.Columns.Odds
+	}
+	if len(names) != len(cfg.Columns.Odds) {
+		return nil, fmt.Errorf("got %d names for %d odds columns", len(names), len(cfg.Columns.Odds))
+	}
+
+	result := &types.BacktestResult{
+		Method:           cfg.Method,
+		Currency:         cfg.Currency,
+		StartingBankroll: money.FromFloat(cfg.StartingBankroll),
+	}
+	bankroll, peak := result.StartingBankroll, result.StartingBankroll
+	var returns []float64
+
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		result.Rows++
+
+		entry := types.LedgerEntry{Line: line, Date: field(record, idx.date), Event: field(record, idx.event)}
+		if bankroll <= 0 {
+			entry.Skipped = "bankroll exhausted"
+		} else if err := replay(record, idx, names, cfg, bankroll, &entry); err != nil {
+			entry.Skipped = err.Error()
+		}
+		entry.Bankroll = bankroll + entry.PnL
+
+		switch {
+		case entry.Skipped != "":
+			result.Skipped++
+		case entry.Staked > 0:
+			result.Bets++
+			result.TotalStaked += entry.Staked
+			returns = append(returns, entry.PnL.Float64()/bankroll.Float64())
+			if entry.PnL > 0 {
+				result.Winners++
+			}
+		}
+
+		bankroll = entry.Bankroll
+		peak = max(peak, bankroll)
+		result.MaxDrawdown = math.Max(result.MaxDrawdown, 1.0-bankroll.Float64()/peak.Float64())
+		result.Ledger = append(result.Ledger, entry)
+	}
+
+	result.FinalBankroll = bankroll
+	result.Profit = bankroll - result.StartingBankroll
+	result.ROI = result.Profit.Float64() / result.StartingBankroll.Float64()
+	if result.TotalStaked > 0 {
+		result.Yield = result.Profit.Float64() / result.TotalStaked.Float64()
+	}
+	result.Sharpe, result.Sortino = ratios(returns)
+	return result, nil
+}
+
+// replay prices one row, sizes it from bankroll and settles it.
+func replay(record []string, idx indexes, names []string, cfg Config, bankroll money.Amount, entry *types.LedgerEntry) error {
+	options := make([]types.Option, len(idx.odds))
+	for i, col := range idx.odds {
+		odds, err := parser.ParseOdds(field(record, col))
+		if err != nil {
+			return fmt.Errorf("%s: %v", names[i], err)
+		}
+		options[i] = types.Option{Name: names[i], Odds: odds}
+
+		if idx.probs != nil {
+			prob, err := strconv.ParseFloat(field(record, idx.probs[i]), 64)
+			if err != nil {
+				return fmt.Errorf("%s probability: %v", names[i], err)
+			}
+			options[i].Probability = prob
+		}
+		if idx.sharp != nil {
+			sharp, err := parser.ParseOdds(field(record, idx.sharp[i]))
+			if err != nil {
+				return fmt.Errorf("%s sharp odds: %v", names[i], err)
+			}
+			options[i].SharpOdds = sharp
+		}
+	}
+
+	winner, label, err := journal.ResolveWinner(options, field(record, idx.result))
+	if err != nil {
+		return err
+	}
+	if winner >= 0 {
+		label = options[winner].Name
+	}
+
+	input := &types.CalculationInput{
+		Method: cfg.Method, Options: options, TotalStake: bankroll, Currency: cfg.Currency,
+		KellyFraction: cfg.KellyFraction, MaxStakePct: cfg.MaxStakePct, MinEdge: cfg.MinEdge,
+		FairFrom: cfg.FairFrom,
+	}
+	if err := validator.ValidateCalculationInput(input); err != nil {
+		return err
+	}
+	calc, err := calculator.NewCalculator(cfg.Method).Calculate(input)
+	if err != nil {
+		return err
+	}
+
+	entry.Stakes = make([]money.Amount, len(calc.Options))
+	for i, opt := range calc.Options {
+		entry.Stakes[i] = opt.Stake
+		if opt.Side == types.SideLay {
+			entry.Stakes[i] = opt.Liability
+		}
+		entry.Staked += entry.Stakes[i]
+	}
+	entry.Winner = label
+	entry.PnL = journal.SettlementPnL(calc, winner)
+	return nil
+}
+
+type indexes struct {
+	odds, probs, sharp  []int
+	result, date, event int
+}
+
+func columnIndexes(header []string, cols Columns) (indexes, error) {
+	pos := make(map[string]int, len(header))
+	for i, name := range header {
+		pos[strings.TrimSpace(name)] = i
+	}
+
+	lookup := func(name string) (int, error) {
+		if name == "" {
+			return -1, nil
+		}
+		i, ok := pos[name]
+		if !ok {
+			return 0, fmt.Errorf("column '%s' not found in header", name)
+		}
+		return i, nil
+	}
+	lookupAll := func(flag string, names []string) ([]int, error) {
+		if len(names) == 0 {
+			return nil, nil
+		}
+		if len(names) != len(cols.Odds) {
+			return nil, fmt.Errorf("got %d %s columns for %d odds columns", len(names), flag, len(cols.Odds))
+		}
+		out := make([]int, len(names))
+		for i, name := range names {
+			var err error
+			if out[i], err = lookup(name); err != nil {
+				return nil, err
+			}
+		}
+		return out, nil
+	}
+
+	var idx indexes
+	var err error
+	if idx.odds, err = lookupAll("odds", cols.Odds); err != nil {
+		return idx, err
+	}
+	if idx.probs, err = lookupAll("probability", cols.Probs); err != nil {
+		return idx, err
+	}
+	if idx.sharp, err = lookupAll("sharp odds", cols.Sharp); err != nil {
+		return idx, err
+	}
+	if idx.result, err = lookup(cols.Result); err != nil {
+		return idx, err
+	}
+	if idx.date, err = lookup(cols.Date); err != nil {
+		return idx, err
+	}
+	if idx.event, err = lookup(cols.Event); err != nil {
+		return idx, err
+	}
+	return idx, nil
+}
+
+func field(record []string, i int) string {
+	if i < 0 || i >= len(record) {
+		return ""
+	}
+	return strings.TrimSpace(record[i])
+}
+
+// ratios returns the Sharpe ratio (mean over standard deviation) and the
+// Sortino ratio (mean over downside deviation) of per-bet returns.
+func ratios(returns []float64) (sharpe, sortino float64) {
+	if len(returns) < 2 {
+		return 0, 0
+	}
+
+	var mean float64
+	for _, r := range returns {
+		mean += r
+	}
+	mean /= float64(len(returns))
+
+	var variance, downside float64
+	for _, r := range returns {
+		variance += (r - mean) * (r - mean)
+		if r < 0 {
+			downside += r * r
+		}
+	}
+	variance /= float64(len(returns) - 1)
+	downside /= float64(len(returns))
+
+	if variance > 0 {
+		sharpe = mean / math.Sqrt(variance)
+	}
+	if downside > 0 {
+		sortino = mean / math.Sqrt(downside)
+	}
+	return sharpe, sortino
+}
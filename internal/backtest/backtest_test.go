@@ -0,0 +1,145 @@
+package backtest
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/codehakase/kelly/pkg/money"
+	"github.com/codehakase/kelly/pkg/types"
+)
+
+const history = `date,match,home,away,p_home,p_away,winner
+2024-01-06,Arsenal v Spurs,2.0,2.0,0.6,0.4,home
+2024-01-13,Chelsea v Fulham,2.0,2.0,0.6,0.4,away
+2024-01-20,Leeds v Hull,bad,2.0,0.6,0.4,home
+2024-01-27,Derby v Stoke,2.0,2.0,0.5,0.5,A
+`
+
+func kellyConfig() Config {
+	return Config{
+		Method:           types.MethodKelly,
+		StartingBankroll: 1000,
+		Currency:         "$",
+		Columns: Columns{
+			Odds:   []string{"home", "away"},
+			Probs:  []string{"p_home", "p_away"},
+			Result: "winner",
+			Date:   "date",
+			Event:  "match",
+		},
+	}
+}
+
+func TestRun_Kelly(t *testing.T) {
+	result, err := Run(strings.NewReader(history), kellyConfig())
+	if err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+
+	if result.Rows != 4 || result.Bets != 2 || result.Skipped != 1 || result.Winners != 1 {
+		t.Errorf("rows/bets/skipped/winners = %d/%d/%d/%d, want 4/2/1/1",
+			result.Rows, result.Bets, result.Skipped, result.Winners)
+	}
+
+	// f = 0.2: win 200 on 1000, then lose 240 of 1200. No edge on the last row.
+	if result.FinalBankroll != money.FromFloat(960) {
+		t.Errorf("FinalBankroll = %.2f, want 960", result.FinalBankroll.Float64())
+	}
+	if result.TotalStaked != money.FromFloat(440) {
+		t.Errorf("TotalStaked = %.2f, want 440", result.TotalStaked.Float64())
+	}
+	if math.Abs(result.ROI-(-0.04)) > 1e-9 || math.Abs(result.Yield-(-40.0/440)) > 1e-9 {
+		t.Errorf("ROI = %.4f, Yield = %.4f; want -0.04, %.4f", result.ROI, result.Yield, -40.0/440)
+	}
+	if math.Abs(result.MaxDrawdown-0.2) > 1e-9 {
+		t.Errorf("MaxDrawdown = %.4f, want 0.2", result.MaxDrawdown)
+	}
+
+	ledger := result.Ledger
+	if len(ledger) != 4 {
+		t.Fatalf("ledger has %d entries, want 4", len(ledger))
+	}
+	if ledger[0].Event != "Arsenal v Spurs" || ledger[0].Winner != "home" || ledger[0].PnL != money.FromFloat(200) || ledger[0].Bankroll != money.FromFloat(1200) {
+		t.Errorf("ledger[0] = %+v", ledger[0])
+	}
+	if ledger[2].Skipped == "" || ledger[2].Line != 4 {
+		t.Errorf("ledger[2] should be skipped at line 4, got %+v", ledger[2])
+	}
+	if ledger[3].Staked != 0 || ledger[3].Skipped != "" {
+		t.Errorf("ledger[3] should place no bet without an edge, got %+v", ledger[3])
+	}
+}
+
+func TestRun_FairFrom(t *testing.T) {
+	data := `home,away,sharp_home,sharp_away,result
+2.2,1.9,2.05,1.85,home
+`
+	cfg := Config{
+		Method: types.MethodKelly, StartingBankroll: 1000, FairFrom: types.MarginMultiplicative,
+		Columns: Columns{Odds: []string{"home", "away"}, Sharp: []string{"sharp_home", "sharp_away"}, Result: "result"},
+	}
+	result, err := Run(strings.NewReader(data), cfg)
+	if err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	if result.Bets != 1 || result.Profit <= 0 {
+		t.Errorf("Bets = %d, Profit = %.2f; want one winning bet", result.Bets, result.Profit.Float64())
+	}
+}
+
+func TestRun_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*Config)
+	}{
+		{"missing column", func(c *Config) { c.Columns.Odds = []string{"home", "draw"} }},
+		{"one odds column", func(c *Config) { c.Columns.Odds = []string{"home"} }},
+		{"no result column", func(c *Config) { c.Columns.Result = "" }},
+		{"probability count", func(c *Config) { c.Columns.Probs = []string{"p_home"} }},
+		{"no bankroll", func(c *Config) { c.StartingBankroll = 0 }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := kellyConfig()
+			tt.modify(&cfg)
+			if _, err := Run(strings.NewReader(history), cfg); err == nil {
+				t.Error("Run() expected error")
+			}
+		})
+	}
+}
+
+func TestRatios(t *testing.T) {
+	sharpe, sortino := ratios([]float64{0.1, -0.1, 0.1, -0.1})
+	if sharpe != 0 || sortino != 0 {
+		t.Errorf("ratios() of zero-mean returns = %.4f, %.4f; want 0, 0", sharpe, sortino)
+	}
+
+	sharpe, sortino = ratios([]float64{0.2, -0.1})
+	// mean 0.05, sample sd 0.2121, downside sqrt(0.01/2)
+	if math.Abs(sharpe-0.05/math.Sqrt(0.045)) > 1e-9 || math.Abs(sortino-0.05/math.Sqrt(0.005)) > 1e-9 {
+		t.Errorf("ratios() = %.4f, %.4f", sharpe, sortino)
+	}
+}
+
+func TestRun_NamedResults(t *testing.T) {
+	data := `H,D,A,pH,pD,pA,FTR
+2.0,3.5,4.0,0.3,0.2,0.5,A
+`
+	cfg := Config{
+		Method: types.MethodKelly, StartingBankroll: 1000,
+		Columns: Columns{
+			Odds: []string{"H", "D", "A"}, Probs: []string{"pH", "pD", "pA"}, Result: "FTR",
+		},
+	}
+	result, err := Run(strings.NewReader(data), cfg)
+	if err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	// Only the away side has an edge, and "A" names it rather than the first option.
+	if result.Ledger[0].Winner != "A" || result.Profit <= 0 {
+		t.Errorf("Winner = %s, Profit = %.2f; want the away bet to win", result.Ledger[0].Winner, result.Profit.Float64())
+	}
+}
@@ -0,0 +1,214 @@
+package bankroll
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/codehakase/kelly/pkg/money"
+	"github.com/codehakase/kelly/pkg/types"
+)
+
+// Book is the on-disk set of bankrolls and the name of the default one.
+type Book struct {
+	Default   string           `json:"default"`
+	Bankrolls []types.Bankroll `json:"bankrolls"`
+}
+
+// Store keeps a Book in a JSON file.
+type Store struct {
+	path string
+}
+
+// DefaultPath returns $KELLY_BANKROLLS, or bankrolls.json in the kelly
+// directory under the user's config dir.
+func DefaultPath() (string, error) {
+	if path := os.Getenv("KELLY_BANKROLLS"); path != "" {
+		return path, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("locating config dir: %w", err)
+	}
+	return filepath.Join(dir, "kelly", "bankrolls.json"), nil
+}
+
+func Open(path string) *Store {
+	return &Store{path: path}
+}
+
+func (s *Store) Path() string { return s.path }
+
+// Load reads the book. A missing file is treated as an empty book.
+func (s *Store) Load() (*Book, error) {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &Book{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var book Book
+	if err := json.Unmarshal(data, &book); err != nil {
+		return nil, fmt.Errorf("%s: %w", s.path, err)
+	}
+	return &book, nil
+}
+
+// List returns every bankroll sorted by name.
+func (s *Store) List() ([]types.Bankroll, string, error) {
+	book, err := s.Load()
+	if err != nil {
+		return nil, "", err
+	}
+	sort.Slice(book.Bankrolls, func(i, j int) bool {
+		return book.Bankrolls[i].Name < book.Bankrolls[j].Name
+	})
+	return book.Bankrolls, book.Default, nil
+}
+
+// Get returns the named bankroll, or the default one when name is empty.
+func (s *Store) Get(name string) (types.Bankroll, error) {
+	book, err := s.Load()
+	if err != nil {
+		return types.Bankroll{}, err
+	}
+	b, err := book.find(name)
+	if err != nil {
+		return types.Bankroll{}, err
+	}
+	return *b, nil
+}
+
+// Create adds an empty bankroll. The first bankroll becomes the default.
+func (s *Store) Create(name, currency string) (types.Bankroll, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return types.Bankroll{}, errors.New("bankroll name is required")
+	}
+
+	var created types.Bankroll
+	err := s.update(func(book *Book) error {
+		for _, b := range book.Bankrolls {
+			if strings.EqualFold(b.Name, name) {
+				return fmt.Errorf("bankroll '%s' already exists", name)
+			}
+		}
+		created = types.Bankroll{Name: name, Currency: currency}
+		book.Bankrolls = append(book.Bankrolls, created)
+		if book.Default == "" {
+			book.Default = name
+		}
+		return nil
+	})
+	return created, err
+}
+
+// SetDefault makes the named bankroll the default.
+func (s *Store) SetDefault(name string) error {
+	return s.update(func(book *Book) error {
+		b, err := book.find(name)
+		if err != nil {
+			return err
+		}
+		book.Default = b.Name
+		return nil
+	})
+}
+
+// Deposit adds money to a bankroll.
+func (s *Store) Deposit(name string, amount float64, note string) (types.Bankroll, error) {
+	if amount <= 0 {
+		return types.Bankroll{}, errors.New("deposit must be positive")
+	}
+	return s.record(name, types.Transaction{Kind: types.TransactionDeposit, Amount: money.FromFloat(amount), Note: note})
+}
+
+// Withdraw takes money out of a bankroll; it cannot go below zero.
+func (s *Store) Withdraw(name string, amount float64, note string) (types.Bankroll, error) {
+	if amount <= 0 {
+		return types.Bankroll{}, errors.New("withdrawal must be positive")
+	}
+	return s.record(name, types.Transaction{Kind: types.TransactionWithdrawal, Amount: money.FromFloat(-amount), Note: note})
+}
+
+// Settle records the realized P&L of a settled bet against its bankroll.
+// Each bet is recorded once, so settling a bet again leaves the bankroll as
+// it is; a settlement that failed part way can simply be retried.
+func (s *Store) Settle(bet types.Bet) (types.Bankroll, error) {
+	if bet.Status != types.BetSettled {
+		return types.Bankroll{}, fmt.Errorf("bet %d is %s, not settled", bet.ID, bet.Status)
+	}
+	return s.record(bet.Bankroll, types.Transaction{Kind: types.TransactionBet, Amount: bet.PnL, BetID: bet.ID})
+}
+
+func (s *Store) record(name string, tx types.Transaction) (types.Bankroll, error) {
+	var updated types.Bankroll
+	err := s.update(func(book *Book) error {
+		b, err := book.find(name)
+		if err != nil {
+			return err
+		}
+		if tx.Kind == types.TransactionWithdrawal && b.Balance()+tx.Amount < 0 {
+			return fmt.Errorf("cannot withdraw %s from '%s': balance is %s", -tx.Amount, b.Name, b.Balance())
+		}
+		if tx.Kind == types.TransactionBet {
+			for _, prev := range b.Transactions {
+				if prev.Kind == types.TransactionBet && prev.BetID == tx.BetID {
+					updated = *b
+					return nil
+				}
+			}
+		}
+		tx.At = time.Now().UTC()
+		b.Transactions = append(b.Transactions, tx)
+		updated = *b
+		return nil
+	})
+	return updated, err
+}
+
+// update loads the book, applies fn and writes the book back through a
+// temporary file.
+func (s *Store) update(fn func(book *Book) error) error {
+	book, err := s.Load()
+	if err != nil {
+		return err
+	}
+	if err := fn(book); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(book, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
+
+func (book *Book) find(name string) (*types.Bankroll, error) {
+	if name == "" {
+		name = book.Default
+	}
+	if name == "" {
+		return nil, errors.New("no bankroll exists; create one with 'kelly bankroll create <name>'")
+	}
+	for i := range book.Bankrolls {
+		if strings.EqualFold(book.Bankrolls[i].Name, name) {
+			return &book.Bankrolls[i], nil
+		}
+	}
+	return nil, fmt.Errorf("bankroll '%s' not found", name)
+}
@@ -0,0 +1,74 @@
+package bankroll
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/codehakase/kelly/pkg/money"
+	"github.com/codehakase/kelly/pkg/types"
+)
+
+func TestStore_Workflow(t *testing.T) {
+	s := Open(filepath.Join(t.TempDir(), "kelly", "bankrolls.json"))
+
+	if _, err := s.Get(""); err == nil {
+		t.Error("Get() should fail when no bankroll exists")
+	}
+
+	if _, err := s.Create("main", "$"); err != nil {
+		t.Fatalf("Create() error: %v", err)
+	}
+	if _, err := s.Create("exchange", "£"); err != nil {
+		t.Fatalf("Create() error: %v", err)
+	}
+	if _, err := s.Create("Main", "$"); err == nil {
+		t.Error("Create() should reject a duplicate name")
+	}
+
+	if _, err := s.Deposit("", 1000, "initial"); err != nil {
+		t.Fatalf("Deposit() error: %v", err)
+	}
+	if _, err := s.Withdraw("main", 250, ""); err != nil {
+		t.Fatalf("Withdraw() error: %v", err)
+	}
+	if _, err := s.Withdraw("main", 5000, ""); err == nil {
+		t.Error("Withdraw() should not take the balance below zero")
+	}
+	if _, err := s.Deposit("main", -5, ""); err == nil {
+		t.Error("Deposit() should reject a non-positive amount")
+	}
+
+	bet := types.Bet{ID: 7, Status: types.BetSettled, Bankroll: "main", PnL: money.FromFloat(-120.456)}
+	b, err := s.Settle(bet)
+	if err != nil {
+		t.Fatalf("Settle() error: %v", err)
+	}
+	if b.Balance() != money.FromFloat(629.54) {
+		t.Errorf("Balance() = %.2f, want 629.54", b.Balance().Float64())
+	}
+	if b, err = s.Settle(bet); err != nil || b.Balance() != money.FromFloat(629.54) || len(b.Transactions) != 3 {
+		t.Errorf("Settle() again = %.2f over %d transactions, %v; want the bet recorded once",
+			b.Balance().Float64(), len(b.Transactions), err)
+	}
+	if _, err := s.Settle(types.Bet{ID: 8, Status: types.BetVoid}); err == nil {
+		t.Error("Settle() should reject a bet that is not settled")
+	}
+
+	def, err := s.Get("")
+	if err != nil || def.Name != "main" {
+		t.Fatalf("Get(\"\") = %s, %v; want the first bankroll as default", def.Name, err)
+	}
+	if err := s.SetDefault("EXCHANGE"); err != nil {
+		t.Fatalf("SetDefault() error: %v", err)
+	}
+	bankrolls, defaultName, err := s.List()
+	if err != nil {
+		t.Fatalf("List() error: %v", err)
+	}
+	if defaultName != "exchange" || len(bankrolls) != 2 || bankrolls[0].Name != "exchange" {
+		t.Errorf("List() = %d bankrolls, default %q; want 2 sorted, default exchange", len(bankrolls), defaultName)
+	}
+	if _, err := s.Get("missing"); err == nil {
+		t.Error("Get() should fail for an unknown bankroll")
+	}
+}
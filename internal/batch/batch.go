@@ -0,0 +1,191 @@
+// Package batch reads the markets of a batch run, one per CSV row or JSON
+// line, as values keyed by kelly calc's flag names.
+package batch
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Format is the format of a batch input.
+type Format string
+
+const (
+	FormatCSV   Format = "csv"
+	FormatJSONL Format = "jsonl"
+)
+
+// Row is one market: its values keyed by long flag name, such as "odds-a"
+// or "total", and the line it was read from. Err is set instead when the
+// row could not be read.
+type Row struct {
+	Line   int
+	Values map[string]string
+	Err    error
+}
+
+// Key normalizes a column or field name to a flag name: lower case, with
+// underscores as dashes, so odds_a and Odds-A both name --odds-a.
+func Key(name string) string {
+	return strings.ReplaceAll(strings.ToLower(strings.TrimSpace(name)), "_", "-")
+}
+
+// Read calls fn with each row of r, in order. CSV input has a header row
+// naming its columns; JSONL input has one object per line. An empty format
+// is detected: input starting with { is JSONL. Read fails only when the
+// input as a whole cannot be read; errors in single rows go to fn.
+func Read(r io.Reader, format Format, fn func(Row)) error {
+	br := bufio.NewReader(r)
+	if format == "" {
+		format = detect(br)
+	}
+	switch format {
+	case FormatCSV:
+		return readCSV(br, fn)
+	case FormatJSONL:
+		return readJSONL(br, fn)
+	}
+	return fmt.Errorf("unknown input format '%s' (use csv or jsonl)", format)
+}
+
+// detect peeks past leading whitespace for the { that starts JSONL.
+func detect(br *bufio.Reader) Format {
+	for n := 1; ; n++ {
+		peek, err := br.Peek(n)
+		if len(peek) < n {
+			return FormatCSV
+		}
+		switch c := peek[n-1]; {
+		case c == '{':
+			return FormatJSONL
+		case c != ' ' && c != '\t' && c != '\r' && c != '\n':
+			return FormatCSV
+		}
+		if err != nil {
+			return FormatCSV
+		}
+	}
+}
+
+func readCSV(r io.Reader, fn func(Row)) error {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if errors.Is(err, io.EOF) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("reading CSV header: %w", err)
+	}
+	keys := make([]string, len(header))
+	for i, name := range header {
+		keys[i] = Key(name)
+	}
+
+	for {
+		record, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			fn(Row{Line: parseErr.StartLine, Err: parseErr.Err})
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		line, _ := cr.FieldPos(0)
+		if len(record) != len(keys) {
+			fn(Row{Line: line, Err: fmt.Errorf("row has %d fields, header has %d", len(record), len(keys))})
+			continue
+		}
+
+		values := make(map[string]string, len(keys))
+		for i, key := range keys {
+			if v := strings.TrimSpace(record[i]); v != "" {
+				values[key] = v
+			}
+		}
+		fn(Row{Line: line, Values: values})
+	}
+}
+
+func readJSONL(r io.Reader, fn func(Row)) error {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 1<<20)
+	for line := 1; sc.Scan(); line++ {
+		text := bytes.TrimSpace(sc.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+		values, err := parseObject(text)
+		if err != nil {
+			fn(Row{Line: line, Err: err})
+			continue
+		}
+		fn(Row{Line: line, Values: values})
+	}
+	return sc.Err()
+}
+
+// parseObject reads a JSON object of scalar values, or arrays of them,
+// which are joined with commas as list flags expect.
+func parseObject(data []byte) (map[string]string, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var fields map[string]any
+	if err := dec.Decode(&fields); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+	if dec.More() {
+		return nil, errors.New("invalid JSON: more than one value on the line")
+	}
+
+	values := make(map[string]string, len(fields))
+	for name, v := range fields {
+		text, err := jsonText(v)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		if text != "" {
+			values[Key(name)] = text
+		}
+	}
+	return values, nil
+}
+
+func jsonText(v any) (string, error) {
+	switch v := v.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case json.Number:
+		return v.String(), nil
+	case bool:
+		return fmt.Sprint(v), nil
+	case []any:
+		parts := make([]string, len(v))
+		for i, item := range v {
+			if _, ok := item.([]any); ok {
+				return "", errors.New("nested arrays are not supported")
+			}
+			text, err := jsonText(item)
+			if err != nil {
+				return "", err
+			}
+			parts[i] = text
+		}
+		return strings.Join(parts, ","), nil
+	}
+	return "", errors.New("objects are not supported")
+}
@@ -0,0 +1,74 @@
+package batch
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func readAll(t *testing.T, input string, format Format) []Row {
+	t.Helper()
+	var rows []Row
+	if err := Read(strings.NewReader(input), format, func(row Row) { rows = append(rows, row) }); err != nil {
+		t.Fatalf("Read() error: %v", err)
+	}
+	return rows
+}
+
+func TestRead_CSV(t *testing.T) {
+	input := "Odds_A, odds-b,total,names\n" +
+		"2.10,3.50,1000,\"Home,Away\"\n" +
+		"2.2\n" +
+		"\n" +
+		"1.9,4.2,,\n"
+	rows := readAll(t, input, "")
+
+	if len(rows) != 3 {
+		t.Fatalf("got %d rows, want 3: %+v", len(rows), rows)
+	}
+	want := map[string]string{"odds-a": "2.10", "odds-b": "3.50", "total": "1000", "names": "Home,Away"}
+	if rows[0].Line != 2 || !reflect.DeepEqual(rows[0].Values, want) {
+		t.Errorf("row 1 = %+v, want line 2 with %v", rows[0], want)
+	}
+	if rows[1].Line != 3 || rows[1].Err == nil {
+		t.Errorf("row 2 = %+v, want a field count error on line 3", rows[1])
+	}
+	// Empty cells are left out, so the batch defaults apply.
+	if rows[2].Line != 5 || !reflect.DeepEqual(rows[2].Values, map[string]string{"odds-a": "1.9", "odds-b": "4.2"}) {
+		t.Errorf("row 3 = %+v, want line 5 with only the odds", rows[2])
+	}
+}
+
+func TestRead_JSONL(t *testing.T) {
+	input := `  {"odds": [2.1, "5/2", 3.6], "prob_a": 0.5, "method": "kelly", "record": true, "name_b": null}
+
+{"odds_a": 2.1,
+{"odds": [[2.1]]}
+{"total": 100} {"total": 200}
+`
+	rows := readAll(t, input, "")
+
+	if len(rows) != 4 {
+		t.Fatalf("got %d rows, want 4: %+v", len(rows), rows)
+	}
+	want := map[string]string{"odds": "2.1,5/2,3.6", "prob-a": "0.5", "method": "kelly", "record": "true"}
+	if rows[0].Line != 1 || !reflect.DeepEqual(rows[0].Values, want) {
+		t.Errorf("row 1 = %+v, want line 1 with %v", rows[0], want)
+	}
+	for i, line := range []int{3, 4, 5} {
+		if row := rows[i+1]; row.Line != line || row.Err == nil {
+			t.Errorf("row %d = %+v, want an error on line %d", i+2, row, line)
+		}
+	}
+}
+
+func TestRead_Format(t *testing.T) {
+	// Forced to JSONL, CSV rows are errors rather than detected.
+	rows := readAll(t, "odds_a,odds_b\n2.1,3.5\n", FormatJSONL)
+	if len(rows) != 2 || rows[0].Err == nil || rows[1].Err == nil {
+		t.Errorf("JSONL read of CSV = %+v, want two row errors", rows)
+	}
+	if err := Read(strings.NewReader(""), "xml", func(Row) {}); err == nil {
+		t.Error("Read() expected an error for an unknown format")
+	}
+}
@@ -0,0 +1,444 @@
+package calculator
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/codehakase/kelly/pkg/money"
+	"github.com/codehakase/kelly/pkg/types"
+)
+
+// ValidLine reports whether line is an Asian line: a whole, half or quarter
+// goal.
+func ValidLine(line float64) bool {
+	q := line * 4
+	return math.Abs(q-math.Round(q)) < 1e-9
+}
+
+// SettleLine returns how a bet on a side with the given handicap settles
+// when that side wins by margin goals (negative when it loses). A quarter
+// line is settled as two half-bets on the neighbouring lines. An over on a
+// goal total T settles as SettleLine(-T, goals), an under as
+// SettleLine(T, -goals).
+func SettleLine(line float64, margin int) types.LineOutcome {
+	halves := []float64{line, line}
+	if q := math.Mod(math.Abs(line)*4, 2); math.Abs(q-1) < 1e-9 {
+		halves = []float64{line - 0.25, line + 0.25}
+	}
+
+	var score int
+	for _, h := range halves {
+		switch d := float64(margin) + h; {
+		case d > 1e-9:
+			score++
+		case d < -1e-9:
+			score--
+		}
+	}
+	switch score {
+	case 2:
+		return types.LineWin
+	case 1:
+		return types.LineHalfWin
+	case -1:
+		return types.LineHalfLoss
+	case -2:
+		return types.LineLoss
+	default:
+		return types.LinePush
+	}
+}
+
+// LinePayout returns what a bet returns per unit staked at the given odds
+// (net of commission) when it settles with outcome.
+func LinePayout(outcome types.LineOutcome, odds float64) float64 {
+	switch outcome {
+	case types.LineWin:
+		return odds
+	case types.LineHalfWin:
+		return (1.0 + odds) / 2.0
+	case types.LinePush:
+		return 1.0
+	case types.LineHalfLoss:
+		return 0.5
+	default:
+		return 0
+	}
+}
+
+// asianLine is a pair of complementary Asian lines. Either side clears the
+// line outright, or on a whole or quarter line the result can land on it
+// (split), settling the sides as splitA and splitB.
+type asianLine struct {
+	split          bool
+	splitA, splitB types.LineOutcome
+}
+
+// complementaryLines checks that the two options are on complementary lines
+// of the market and returns them from option A's side.
+func complementaryLines(market types.AsianMarket, options []types.Option) (asianLine, error) {
+	if len(options) != 2 {
+		return asianLine{}, fmt.Errorf("Asian lines require exactly 2 options, got %d", len(options))
+	}
+	for i, opt := range options {
+		if opt.Side == types.SideLay {
+			return asianLine{}, errors.New("Asian lines must be back bets")
+		}
+		if !ValidLine(opt.Line) {
+			return asianLine{}, fmt.Errorf("%s: %g is not an Asian line (use whole, half or quarter goals)", types.OptionLabel(i), opt.Line)
+		}
+	}
+
+	a, b := options[0].Line, options[1].Line
+	var handicap float64
+	switch market {
+	case types.AsianHandicap:
+		if math.Abs(a+b) > 1e-9 {
+			return asianLine{}, fmt.Errorf("handicaps %+g and %+g are not complementary", a, b)
+		}
+		handicap = a
+	case types.AsianTotal:
+		if math.Abs(a-b) > 1e-9 {
+			return asianLine{}, fmt.Errorf("totals %g and %g are not the same line", a, b)
+		}
+		if a <= 0 {
+			return asianLine{}, fmt.Errorf("goal total must be positive, got %g", a)
+		}
+		// Over the total is A winning by its goals with a -total handicap.
+		handicap = -a
+	default:
+		return asianLine{}, fmt.Errorf("unknown Asian market '%s' (use handicap or total)", market)
+	}
+
+	// A result lands on the line when A's margin is within a quarter goal
+	// of its handicap, which no margin is on a half line.
+	var line asianLine
+	if m := math.Round(-handicap); math.Abs(m+handicap) <= 0.25+1e-9 {
+		line.split = true
+		line.splitA = SettleLine(handicap, int(m))
+		line.splitB = SettleLine(-handicap, -int(m))
+	}
+	return line, nil
+}
+
+// linePayout returns what opt pays back per unit staked when it settles
+// with outcome. Commission and winnings tax come out of the winnings, and
+// stake excise out of everything paid back, a push included.
+func linePayout(opt types.Option, outcome types.LineOutcome) float64 {
+	return (1.0 - opt.StakeExcise) * LinePayout(outcome, 1.0+opt.NetWinnings(opt.Odds-1.0))
+}
+
+// payouts returns what the position pays back when A clears the line, when
+// the result lands on it, and when B clears it.
+func (l asianLine) payouts(options []types.Option, stakes []float64) (ifA, ifSplit, ifB float64) {
+	ifA = stakes[0] * linePayout(options[0], types.LineWin)
+	ifB = stakes[1] * linePayout(options[1], types.LineWin)
+	if l.split {
+		ifSplit = stakes[0]*linePayout(options[0], l.splitA) + stakes[1]*linePayout(options[1], l.splitB)
+	}
+	return ifA, ifSplit, ifB
+}
+
+// asianResult builds the result of stakes on the two lines, widening the
+// profit range of newResult to cover a split result. The Asian outcome's
+// profits are net of the stakes placed, which for Kelly are less than the
+// total.
+func asianResult(method types.CalculationMethod, input *types.CalculationInput, options []types.Option,
+	line asianLine, stakes []float64) *types.CalculationResult {
+
+	result := newResult(method, input, options, stakes)
+	for i, opt := range options {
+		result.Options[i].Line = opt.Line
+	}
+
+	retA, retSplit, retB := line.payouts(options, stakes)
+	minReturn, maxReturn := math.Min(retA, retB), math.Max(retA, retB)
+	if line.split {
+		minReturn, maxReturn = math.Min(minReturn, retSplit), math.Max(maxReturn, retSplit)
+	}
+	total := input.TotalStake.Float64()
+	minProfit, maxProfit := minReturn-total, maxReturn-total
+	result.Summary.GuaranteedProfit = minProfit > 0
+	result.Summary.MinProfit = money.FromFloat(minProfit)
+	result.Summary.MaxProfit = money.FromFloat(maxProfit)
+	result.Summary.MinROI = round(minProfit/total, 4)
+	result.Summary.MaxROI = round(maxProfit/total, 4)
+
+	staked := stakes[0] + stakes[1]
+	result.Summary.Asian = &types.AsianOutcome{
+		Market:  input.AsianMarket,
+		Line:    options[0].Line,
+		Split:   line.split,
+		SplitA:  line.splitA,
+		SplitB:  line.splitB,
+		IfAWins: money.FromFloat(retA - staked),
+		IfBWins: money.FromFloat(retB - staked),
+	}
+	if line.split {
+		result.Summary.Asian.IfSplit = money.FromFloat(retSplit - staked)
+	}
+	return result
+}
+
+// asianArbitrage splits the total across complementary Asian lines to
+// maximize the worst result. With a split result there are three profits,
+// each linear in A's stake, so the best split is at an end of the range or
+// where two of them cross; ties go to the split whose next-worst result is
+// best, so a push at zero does not hide the profit on either side.
+func asianArbitrage(input *types.CalculationInput) (*types.CalculationResult, error) {
+	options := input.Outcomes()
+	line, err := complementaryLines(input.AsianMarket, options)
+	if err != nil {
+		return nil, err
+	}
+	net, err := netOptions(options)
+	if err != nil {
+		return nil, err
+	}
+
+	// Profits per unit of total for a share x on A: slope·x + intercept.
+	a, b := net[0].Odds, net[1].Odds
+	lines := [][2]float64{{a, -1}, {-b, b - 1}}
+	if line.split {
+		pa, pb := linePayout(options[0], line.splitA), linePayout(options[1], line.splitB)
+		lines = append(lines, [2]float64{pa - pb, pb - 1})
+	}
+
+	candidates := []float64{0, 1}
+	for i := range lines {
+		for j := i + 1; j < len(lines); j++ {
+			if ds := lines[i][0] - lines[j][0]; math.Abs(ds) > 1e-12 {
+				if x := (lines[j][1] - lines[i][1]) / ds; x > 0 && x < 1 {
+					candidates = append(candidates, x)
+				}
+			}
+		}
+	}
+
+	var best []float64
+	share := 0.0
+	for _, x := range candidates {
+		profits := make([]float64, len(lines))
+		for i, l := range lines {
+			profits[i] = l[0]*x + l[1]
+		}
+		sort.Float64s(profits)
+		if best == nil || betterWorst(profits, best) {
+			best, share = profits, x
+		}
+	}
+
+	stakes := money.Split(input.TotalStake, []float64{share, 1.0 - share})
+	limited, stakes, constraints, err := withLimits(input, options, stakes, true)
+	if err != nil {
+		return nil, err
+	}
+
+	result := asianResult(types.MethodArbitrage, limited, options, line, stakes)
+	// Without probabilities every way the line settles counts the same.
+	outcomes := []float64{result.Summary.Asian.IfAWins.Float64(), result.Summary.Asian.IfBWins.Float64()}
+	if line.split {
+		outcomes = append(outcomes, result.Summary.Asian.IfSplit.Float64())
+	}
+	var sum float64
+	for _, p := range outcomes {
+		sum += p
+	}
+	result.Summary.ExpectedValue = money.FromFloat(sum / float64(len(outcomes)))
+	return markLimits(result, input, limited, constraints), nil
+}
+
+// betterWorst reports whether the ascending profits a beat b: a better
+// worst result, or the same and a better next one.
+func betterWorst(a, b []float64) bool {
+	for i := range a {
+		if math.Abs(a[i]-b[i]) > 1e-9 {
+			return a[i] > b[i]
+		}
+	}
+	return false
+}
+
+// asianKelly sizes the two sides of complementary Asian lines together by
+// the Kelly criterion over the three ways they settle: A clearing the line,
+// the result landing on it, and B clearing it. The sides are staked from
+// one bankroll against one result, so they are not sized as separate bets:
+// usually only the side with the edge is staked, and both only when that
+// grows the bankroll faster.
+func asianKelly(input *types.CalculationInput) (*types.CalculationResult, error) {
+	options, err := probabilityOptions(input)
+	if err != nil {
+		return nil, err
+	}
+	line, err := complementaryLines(input.AsianMarket, options)
+	if err != nil {
+		return nil, err
+	}
+	net, err := netOptions(options)
+	if err != nil {
+		return nil, err
+	}
+
+	pA, pB := net[0].Probability, net[1].Probability
+	pSplit := 1.0 - pA - pB
+	if pSplit < -1e-9 {
+		return nil, fmt.Errorf("probabilities of the two sides sum to %.4f, more than 1", pA+pB)
+	}
+	if !line.split && pSplit > 1e-9 {
+		return nil, fmt.Errorf("a half-goal line cannot push, so its probabilities must sum to 1 (got %.4f)", pA+pB)
+	}
+	pSplit = math.Max(0, pSplit)
+
+	// Net return per unit staked on each side, and the probability, for
+	// each way the line settles.
+	a, b := net[0].Odds-1.0, net[1].Odds-1.0
+	r := [][]float64{{a, -1.0}, {-1.0, b}}
+	p := []float64{pA, pB}
+	if line.split {
+		r = append(r, []float64{linePayout(options[0], line.splitA) - 1.0, linePayout(options[1], line.splitB) - 1.0})
+		p = append(p, pSplit)
+	}
+	edges := make([]float64, 2)
+	for k := range r {
+		for i := range edges {
+			edges[i] += p[k] * r[k][i]
+		}
+	}
+	fractions := jointKelly(r, p)
+	constraints := applyKellyLimits(input, edges, fractions)
+
+	stakes := make([]float64, 2)
+	for i, f := range fractions {
+		stakes[i] = money.Round(input.TotalStake.Float64() * f)
+	}
+	limited, stakes, limits, err := withLimits(input, options, stakes, false)
+	if err != nil {
+		return nil, err
+	}
+
+	result := asianResult(types.MethodKelly, limited, options, line, stakes)
+	result.Summary.Asian.SplitProbability = round(pSplit, 6)
+	for i, opt := range net {
+		result.Options[i].Probability = opt.Probability
+		result.Options[i].BindingConstraint = constraints[i]
+	}
+	retA, retSplit, retB := line.payouts(options, stakes)
+	expected := pA*retA + pB*retB + pSplit*retSplit - limited.TotalStake.Float64()
+	result.Summary.ExpectedValue = money.FromFloat(expected)
+	if missingProbability(input.Outcomes()) {
+		result.Summary.FairFrom = input.FairFrom
+	}
+	return markLimits(result, input, limited, limits), nil
+}
+
+// jointKelly returns the fractions of a bankroll to stake on bets settled
+// by one result that together maximize expected log growth, where r[k][i]
+// is what bet i returns per unit, net of its stake, in result k with
+// probability p[k]. The stakes never add up to more than the bankroll.
+//
+// The growth rate is concave in the fractions, so it is climbed one
+// direction at a time: along each bet, and along moving stake from one bet
+// to another, which keeps the total when all of the bankroll is staked.
+// Each step goes to the best point on its line, until none moves.
+func jointKelly(r [][]float64, p []float64) []float64 {
+	n := len(r[0])
+	var directions [][]float64
+	for i := 0; i < n; i++ {
+		d := make([]float64, n)
+		d[i] = 1
+		directions = append(directions, d)
+		for j := i + 1; j < n; j++ {
+			d := make([]float64, n)
+			d[i], d[j] = 1, -1
+			directions = append(directions, d)
+		}
+	}
+
+	f := make([]float64, n)
+	for pass := 0; pass < 1000; pass++ {
+		var moved float64
+		for _, d := range directions {
+			x := kellyStep(f, d, r, p)
+			for i := range f {
+				f[i] = math.Max(0, f[i]+x*d[i])
+			}
+			moved += math.Abs(x)
+		}
+		if moved < 1e-12 {
+			break
+		}
+	}
+	return f
+}
+
+// kellyStep returns how far along d from f the growth rate peaks, keeping
+// the fractions non-negative, their sum at most 1 and wealth positive in
+// every result. The growth rate is concave along d, so the root of its
+// derivative is bisected.
+func kellyStep(f, d []float64, r [][]float64, p []float64) float64 {
+	lo, hi := math.Inf(-1), math.Inf(1)
+	var sum, dsum float64
+	for i := range f {
+		sum += f[i]
+		dsum += d[i]
+		switch {
+		case d[i] > 0:
+			lo = math.Max(lo, -f[i]/d[i])
+		case d[i] < 0:
+			hi = math.Min(hi, -f[i]/d[i])
+		}
+	}
+	if dsum > 0 {
+		hi = math.Min(hi, (1.0-sum)/dsum)
+	}
+
+	wealth := make([]float64, len(r))
+	change := make([]float64, len(r))
+	for k := range r {
+		wealth[k] = 1.0
+		for i := range f {
+			wealth[k] += f[i] * r[k][i]
+			change[k] += d[i] * r[k][i]
+		}
+		switch {
+		case change[k] < 0:
+			hi = math.Min(hi, -wealth[k]/change[k])
+		case change[k] > 0:
+			lo = math.Max(lo, -wealth[k]/change[k])
+		}
+	}
+
+	slope := func(x float64) float64 {
+		var s float64
+		for k := range r {
+			if p[k] > 0 {
+				s += p[k] * change[k] / (wealth[k] + x*change[k])
+			}
+		}
+		return s
+	}
+	var a, b float64
+	switch s := slope(0); {
+	case s > 0 && hi > 0:
+		a, b = 0, hi
+	case s < 0 && lo < 0:
+		a, b = lo, 0
+	default:
+		return 0
+	}
+	for step := 0; step < 100; step++ {
+		mid := (a + b) / 2
+		if slope(mid) > 0 {
+			a = mid
+		} else {
+			b = mid
+		}
+	}
+	// Stay on the side of the peak that keeps every bound.
+	if a < 0 {
+		return b
+	}
+	return a
+}
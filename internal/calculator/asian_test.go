@@ -0,0 +1,325 @@
+package calculator
+
+import (
+	"math"
+	"testing"
+
+	"github.com/codehakase/kelly/pkg/money"
+	"github.com/codehakase/kelly/pkg/types"
+)
+
+func TestSettleLine(t *testing.T) {
+	tests := []struct {
+		line   float64
+		margin int
+		want   types.LineOutcome
+	}{
+		{-0.25, 1, types.LineWin},
+		{-0.25, 0, types.LineHalfLoss},
+		{-0.25, -1, types.LineLoss},
+		{0.25, 0, types.LineHalfWin},
+		{0, 0, types.LinePush},
+		{0, 1, types.LineWin},
+		{-0.5, 0, types.LineLoss},
+		{-0.5, 1, types.LineWin},
+		{-0.75, 1, types.LineHalfWin},
+		{-0.75, 2, types.LineWin},
+		{0.75, -1, types.LineHalfLoss},
+		{-1, 1, types.LinePush},
+		{-1.25, 1, types.LineHalfLoss},
+		{1.5, -1, types.LineWin},
+	}
+
+	for _, tt := range tests {
+		if got := SettleLine(tt.line, tt.margin); got != tt.want {
+			t.Errorf("SettleLine(%+g, %d) = %s, want %s", tt.line, tt.margin, got, tt.want)
+		}
+	}
+}
+
+func TestLinePayout(t *testing.T) {
+	tests := []struct {
+		outcome types.LineOutcome
+		want    float64
+	}{
+		{types.LineWin, 2.0},
+		{types.LineHalfWin, 1.5},
+		{types.LinePush, 1.0},
+		{types.LineHalfLoss, 0.5},
+		{types.LineLoss, 0},
+	}
+
+	for _, tt := range tests {
+		if got := LinePayout(tt.outcome, 2.0); got != tt.want {
+			t.Errorf("LinePayout(%s, 2.0) = %.2f, want %.2f", tt.outcome, got, tt.want)
+		}
+	}
+}
+
+func TestArbitrageCalculator_AsianLines(t *testing.T) {
+	tests := []struct {
+		name          string
+		market        types.AsianMarket
+		options       []types.Option
+		wantStakes    [2]float64
+		wantProfits   [3]float64 // A clears, split, B clears
+		wantSplit     bool
+		wantGuarantee bool
+	}{
+		{
+			// No push on a half line: equal returns, 1000 × 2.05 / 4.15 on A.
+			name:   "half-goal handicap",
+			market: types.AsianHandicap,
+			options: []types.Option{
+				{Odds: 2.10, Line: -0.5},
+				{Odds: 2.05, Line: 0.5},
+			},
+			wantStakes:    [2]float64{493.98, 506.02},
+			wantProfits:   [3]float64{37.36, 0, 37.34},
+			wantGuarantee: true,
+		},
+		{
+			// A draw loses half of A's stake and wins half of B's, so A is
+			// staked to break the tie between its win and the draw.
+			name:   "quarter-goal handicap",
+			market: types.AsianHandicap,
+			options: []types.Option{
+				{Odds: 2.10, Line: -0.25},
+				{Odds: 1.95, Line: 0.25},
+			},
+			wantStakes:    [2]float64{479.67, 520.33},
+			wantProfits:   [3]float64{7.31, 7.32, 14.64},
+			wantSplit:     true,
+			wantGuarantee: true,
+		},
+		{
+			// Draw no bet: a draw returns both stakes, so the best is a
+			// profit either way and nothing lost on the draw.
+			name:   "level handicap pushes",
+			market: types.AsianHandicap,
+			options: []types.Option{
+				{Odds: 2.10, Line: 0},
+				{Odds: 2.00, Line: 0},
+			},
+			wantStakes:  [2]float64{487.80, 512.20},
+			wantProfits: [3]float64{24.38, 0, 24.40},
+			wantSplit:   true,
+		},
+		{
+			name:   "goal total",
+			market: types.AsianTotal,
+			options: []types.Option{
+				{Odds: 2.05, Line: 2.5},
+				{Odds: 2.02, Line: 2.5},
+			},
+			wantStakes:    [2]float64{496.31, 503.69},
+			wantProfits:   [3]float64{17.44, 0, 17.45},
+			wantGuarantee: true,
+		},
+	}
+
+	calc := &ArbitrageCalculator{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := calc.Calculate(&types.CalculationInput{
+				Method: types.MethodArbitrage, Options: tt.options, TotalStake: money.FromFloat(1000),
+				AsianMarket: tt.market,
+			})
+			if err != nil {
+				t.Fatalf("Calculate() error: %v", err)
+			}
+
+			for i, want := range tt.wantStakes {
+				if !floatAlmostEqual(result.Options[i].Stake.Float64(), want, 0.01) {
+					t.Errorf("%s stake = %.2f, want %.2f", types.OptionLabel(i), result.Options[i].Stake.Float64(), want)
+				}
+				if result.Options[i].Line != tt.options[i].Line {
+					t.Errorf("%s line = %g, want %g", types.OptionLabel(i), result.Options[i].Line, tt.options[i].Line)
+				}
+			}
+
+			asian := result.Summary.Asian
+			if asian == nil {
+				t.Fatal("Summary.Asian should be set")
+			}
+			for i, got := range []float64{asian.IfAWins.Float64(), asian.IfSplit.Float64(), asian.IfBWins.Float64()} {
+				if !floatAlmostEqual(got, tt.wantProfits[i], 0.02) {
+					t.Errorf("profits = %.2f/%.2f/%.2f, want %v", asian.IfAWins.Float64(), asian.IfSplit.Float64(), asian.IfBWins.Float64(), tt.wantProfits)
+					break
+				}
+			}
+			if asian.Split != tt.wantSplit || asian.Market != tt.market {
+				t.Errorf("Asian = %+v, want split %v on a %s market", asian, tt.wantSplit, tt.market)
+			}
+			if result.Summary.GuaranteedProfit != tt.wantGuarantee {
+				t.Errorf("GuaranteedProfit = %v, want %v", result.Summary.GuaranteedProfit, tt.wantGuarantee)
+			}
+		})
+	}
+}
+
+func TestArbitrageCalculator_AsianExcise(t *testing.T) {
+	// Excise is kept on a push: of 500 staked on each side at level
+	// handicap, only 450 is placed and paid back.
+	result, err := (&ArbitrageCalculator{}).Calculate(&types.CalculationInput{
+		Method:      types.MethodArbitrage,
+		AsianMarket: types.AsianHandicap,
+		Options: []types.Option{
+			{Odds: 2.0, Line: 0, StakeExcise: 0.1},
+			{Odds: 2.0, Line: 0, StakeExcise: 0.1},
+		},
+		TotalStake: money.FromFloat(1000),
+	})
+	if err != nil {
+		t.Fatalf("Calculate() error: %v", err)
+	}
+	if a := result.Summary.Asian; a.IfSplit != money.FromFloat(-100) || a.IfAWins != money.FromFloat(-100) {
+		t.Errorf("profits = %.2f on a push, %.2f on a win, want -100 each", a.IfSplit.Float64(), a.IfAWins.Float64())
+	}
+}
+
+func TestKellyCalculator_AsianLines(t *testing.T) {
+	t.Run("quarter line", func(t *testing.T) {
+		// A wins outright half the time and draws a fifth of the time,
+		// losing half its stake: growth peaks at 11.90% of the bankroll.
+		result, err := (&KellyCalculator{}).Calculate(&types.CalculationInput{
+			Method: types.MethodKelly,
+			Options: []types.Option{
+				{Odds: 2.0, Line: -0.25, Probability: 0.5},
+				{Odds: 1.9, Line: 0.25, Probability: 0.3},
+			},
+			TotalStake:  money.FromFloat(1000),
+			AsianMarket: types.AsianHandicap,
+		})
+		if err != nil {
+			t.Fatalf("Calculate() error: %v", err)
+		}
+		if !floatAlmostEqual(result.Options[0].Stake.Float64(), 118.98, 0.01) || result.Options[1].Stake != 0 {
+			t.Errorf("stakes = %.2f/%.2f, want 118.98/0", result.Options[0].Stake.Float64(), result.Options[1].Stake.Float64())
+		}
+		asian := result.Summary.Asian
+		if asian.SplitA != types.LineHalfLoss || asian.SplitB != types.LineHalfWin || asian.SplitProbability != 0.2 {
+			t.Errorf("Asian = %+v, want a half loss and half win with probability 0.2", asian)
+		}
+		if !floatAlmostEqual(result.Summary.ExpectedValue.Float64(), -869.12, 0.01) {
+			t.Errorf("ExpectedValue = %.2f, want -869.12", result.Summary.ExpectedValue.Float64())
+		}
+	})
+
+	t.Run("half line matches the binary model", func(t *testing.T) {
+		options := []types.Option{
+			{Odds: 2.0, Line: -0.5, Probability: 0.55},
+			{Odds: 2.0, Line: 0.5, Probability: 0.45},
+		}
+		asian, err := (&KellyCalculator{}).Calculate(&types.CalculationInput{
+			Method: types.MethodKelly, Options: options, TotalStake: money.FromFloat(1000), AsianMarket: types.AsianHandicap,
+		})
+		if err != nil {
+			t.Fatalf("Calculate() error: %v", err)
+		}
+		binary, err := (&KellyCalculator{}).Calculate(&types.CalculationInput{
+			Method: types.MethodKelly, Options: options, TotalStake: money.FromFloat(1000),
+		})
+		if err != nil {
+			t.Fatalf("Calculate() error: %v", err)
+		}
+		if !floatAlmostEqual(asian.Options[0].Stake.Float64(), binary.Options[0].Stake.Float64(), 0.01) {
+			t.Errorf("stake = %.2f, want %.2f as without lines", asian.Options[0].Stake.Float64(), binary.Options[0].Stake.Float64())
+		}
+	})
+
+	t.Run("sides sized together", func(t *testing.T) {
+		// Both sides have an edge on their own, but they are staked against
+		// one result: A wins at 2.3, B wins at 2.3, or a split loses half
+		// of A and wins half of B. The stakes must maximize the growth of
+		// the position as a whole, not of each side alone.
+		result, err := (&KellyCalculator{}).Calculate(&types.CalculationInput{
+			Method: types.MethodKelly,
+			Options: []types.Option{
+				{Odds: 2.3, Line: -0.25, Probability: 0.47},
+				{Odds: 2.3, Line: 0.25, Probability: 0.36},
+			},
+			TotalStake:  money.FromFloat(1000),
+			AsianMarket: types.AsianHandicap,
+		})
+		if err != nil {
+			t.Fatalf("Calculate() error: %v", err)
+		}
+
+		growth := func(fA, fB float64) float64 {
+			return 0.47*math.Log(1+1.3*fA-fB) + 0.36*math.Log(1-fA+1.3*fB) + 0.17*math.Log(1-0.5*fA+0.65*fB)
+		}
+		fA, fB := result.Options[0].Stake.Float64()/1000, result.Options[1].Stake.Float64()/1000
+		if fA+fB > 1 {
+			t.Fatalf("stakes %.2f/%.2f exceed the bankroll", result.Options[0].Stake.Float64(), result.Options[1].Stake.Float64())
+		}
+		got := growth(fA, fB)
+		for x := 0.0; x <= 1; x += 0.005 {
+			for y := 0.0; x+y <= 1; y += 0.005 {
+				if g := growth(x, y); g > got+1e-6 {
+					t.Fatalf("stakes %.2f/%.2f grow %.6f, but %.3f/%.3f grows %.6f",
+						result.Options[0].Stake.Float64(), result.Options[1].Stake.Float64(), got, x, y, g)
+				}
+			}
+		}
+		// Sized alone each side takes its single-bet Kelly stake, which
+		// together grow the bankroll less.
+		if alone := growth(0.1277, 0.0835); got <= alone {
+			t.Errorf("growth %.6f should beat %.6f of the sides sized alone", got, alone)
+		}
+	})
+
+	t.Run("kelly fraction applies", func(t *testing.T) {
+		result, err := (&KellyCalculator{}).Calculate(&types.CalculationInput{
+			Method: types.MethodKelly,
+			Options: []types.Option{
+				{Odds: 2.0, Line: -0.5, Probability: 0.55},
+				{Odds: 2.0, Line: 0.5, Probability: 0.45},
+			},
+			TotalStake: money.FromFloat(1000), KellyFraction: 0.5, AsianMarket: types.AsianHandicap,
+		})
+		if err != nil {
+			t.Fatalf("Calculate() error: %v", err)
+		}
+		if opt := result.Options[0]; !floatAlmostEqual(opt.Stake.Float64(), 50, 0.01) || opt.BindingConstraint != types.ConstraintKellyFraction {
+			t.Errorf("stake = %.2f (%q), want 50 at half Kelly", opt.Stake.Float64(), opt.BindingConstraint)
+		}
+	})
+}
+
+func TestAsianLines_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		method  types.CalculationMethod
+		market  types.AsianMarket
+		options []types.Option
+	}{
+		{"not complementary", types.MethodArbitrage, types.AsianHandicap,
+			[]types.Option{{Odds: 2.0, Line: -0.25}, {Odds: 2.0, Line: 0.5}}},
+		{"not a quarter goal", types.MethodArbitrage, types.AsianHandicap,
+			[]types.Option{{Odds: 2.0, Line: -0.3}, {Odds: 2.0, Line: 0.3}}},
+		{"different totals", types.MethodArbitrage, types.AsianTotal,
+			[]types.Option{{Odds: 2.0, Line: 2.5}, {Odds: 2.0, Line: 2.25}}},
+		{"three options", types.MethodArbitrage, types.AsianHandicap,
+			[]types.Option{{Odds: 2.0}, {Odds: 3.0}, {Odds: 4.0}}},
+		{"lay side", types.MethodArbitrage, types.AsianHandicap,
+			[]types.Option{{Odds: 2.0}, {Odds: 2.0, Side: types.SideLay}}},
+		{"unknown market", types.MethodArbitrage, "corners",
+			[]types.Option{{Odds: 2.0}, {Odds: 2.0}}},
+		{"half line that pushes", types.MethodKelly, types.AsianHandicap,
+			[]types.Option{{Odds: 2.0, Line: -0.5, Probability: 0.5}, {Odds: 2.0, Line: 0.5, Probability: 0.4}}},
+		{"probabilities above one", types.MethodKelly, types.AsianHandicap,
+			[]types.Option{{Odds: 2.0, Line: -0.25, Probability: 0.6}, {Odds: 2.0, Line: 0.25, Probability: 0.5}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewCalculator(tt.method).Calculate(&types.CalculationInput{
+				Method: tt.method, Options: tt.options, TotalStake: money.FromFloat(1000), AsianMarket: tt.market,
+			})
+			if err == nil {
+				t.Error("Calculate() expected an error")
+			}
+		})
+	}
+}
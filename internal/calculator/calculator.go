@@ -2,8 +2,11 @@ package calculator
 
 import (
 	"errors"
+	"fmt"
 	"math"
 
+	"github.com/codehakase/kelly/internal/margin"
+	"github.com/codehakase/kelly/pkg/money"
 	"github.com/codehakase/kelly/pkg/types"
 )
 
@@ -15,8 +18,19 @@ func NewCalculator(method types.CalculationMethod) Calculator {
 	switch method {
 	case types.MethodKelly:
 		return &KellyCalculator{}
+	case types.MethodKellySimultaneous:
+		return &SimultaneousKellyCalculator{}
 	case types.MethodProportional:
-		return &ProportionalCalculator{}
+		// Retired: its 1/odds split is the arbitrage split.
+		return &ArbitrageCalculator{}
+	case types.MethodMatched:
+		return &MatchedCalculator{}
+	case types.MethodHedge:
+		return &HedgeCalculator{}
+	case types.MethodEachWay:
+		return &EachWayCalculator{}
+	case types.MethodParlay:
+		return &ParlayCalculator{}
 	default:
 		return &ArbitrageCalculator{}
 	}
@@ -34,180 +48,337 @@ func impliedProbability(odds float64) float64 {
 	return 1.0 / odds
 }
 
-func marketEfficiency(oddsA, oddsB float64) float64 {
-	return impliedProbability(oddsA) + impliedProbability(oddsB)
+func marketEfficiency(options []types.Option) float64 {
+	var sum float64
+	for _, opt := range options {
+		sum += impliedProbability(opt.EffectiveOdds())
+	}
+	return sum
+}
+
+// netOptions returns copies of back options with their odds replaced by the
+// effective odds after commission, winnings tax and stake excise, for use in
+// the sizing formulas.
+func netOptions(options []types.Option) ([]types.Option, error) {
+	net := make([]types.Option, len(options))
+	for i, opt := range options {
+		if opt.Side == types.SideLay {
+			return nil, fmt.Errorf("%s: lay bets are only supported by the arbitrage method", types.OptionLabel(i))
+		}
+		net[i] = opt.Untaxed()
+		net[i].Odds = opt.EffectiveOdds()
+		net[i].Commission = 0
+		if opt.Taxed() && net[i].Odds <= 1.0 {
+			return nil, fmt.Errorf("%s: odds of %.2f return less than the stake after tax and excise", types.OptionLabel(i), opt.Odds)
+		}
+	}
+	return net, nil
+}
+
+// grossReturn returns what capital on opt would return without winnings tax
+// and stake excise, or zero when its book charges neither.
+func grossReturn(opt types.Option, capital float64) money.Amount {
+	if !opt.Taxed() {
+		return 0
+	}
+	return money.FromFloat(capital * opt.Untaxed().EffectiveOdds())
+}
+
+// newResult fills in returns, profits and the summary for the given stakes.
+// For lay options the stake passed in is the liability. Callers set
+// Summary.ExpectedValue, which depends on the method.
+func newResult(method types.CalculationMethod, input *types.CalculationInput,
+	options []types.Option, stakes []float64) *types.CalculationResult {
+
+	result := &types.CalculationResult{
+		Method:     method,
+		TotalStake: input.TotalStake,
+		Currency:   input.Currency,
+		Options:    make([]types.Option, len(options)),
+	}
+
+	total := input.TotalStake.Float64()
+	minProfit, maxProfit := math.Inf(1), math.Inf(-1)
+	for i, opt := range options {
+		ret := stakes[i] * opt.EffectiveOdds()
+		profit := ret - total
+		minProfit = math.Min(minProfit, profit)
+		maxProfit = math.Max(maxProfit, profit)
+
+		result.Options[i] = types.Option{
+			Name:               opt.Name,
+			Bookmaker:          opt.Bookmaker,
+			Odds:               opt.Odds,
+			ImpliedProbability: impliedProbability(opt.Odds),
+			SharpOdds:          opt.SharpOdds,
+			Side:               opt.Side,
+			Commission:         opt.Commission,
+			TaxOnWinnings:      opt.TaxOnWinnings,
+			StakeExcise:        opt.StakeExcise,
+			MinStake:           opt.MinStake,
+			MaxStake:           opt.MaxStake,
+			Stake:              money.FromFloat(stakes[i]),
+			ReturnIfWins:       money.FromFloat(ret),
+			GrossReturn:        grossReturn(opt, stakes[i]),
+			ProfitIfWins:       money.FromFloat(profit),
+			ROI:                round(profit/total, 4),
+		}
+		if opt.Side == types.SideLay {
+			result.Options[i].Stake = money.FromFloat(stakes[i] / (opt.Odds - 1.0))
+			result.Options[i].Liability = money.FromFloat(stakes[i])
+		}
+	}
+
+	marketEff := marketEfficiency(options)
+	result.Summary = types.Summary{
+		GuaranteedProfit: marketEff < 1.0,
+		MinProfit:        money.FromFloat(minProfit),
+		MaxProfit:        money.FromFloat(maxProfit),
+		MinROI:           round(minProfit/total, 4),
+		MaxROI:           round(maxProfit/total, 4),
+		MarketEfficiency: round(marketEff, 4),
+	}
+	return result
+}
+
+// meanProfit averages the profit over all outcomes, treating them as equally
+// likely.
+func meanProfit(options []types.Option, stakes []float64, total money.Amount) float64 {
+	var sum float64
+	for i, opt := range options {
+		sum += stakes[i]*opt.EffectiveOdds() - total.Float64()
+	}
+	return sum / float64(len(options))
 }
 
 // ArbitrageCalculator implements guaranteed profit allocation.
 type ArbitrageCalculator struct{}
 
 func (c *ArbitrageCalculator) Calculate(input *types.CalculationInput) (*types.CalculationResult, error) {
-	denominator := input.OddsA + input.OddsB - 2.0
-	stakeA := round(input.TotalStake*(input.OddsB-1.0)/denominator, 2)
-	stakeB := round(input.TotalStake*(input.OddsA-1.0)/denominator, 2)
+	if input.AsianMarket != "" {
+		return asianArbitrage(input)
+	}
+	if input.Rates != nil {
+		return fxArbitrage(input)
+	}
+	options := input.Outcomes()
+	if hasLay(options) {
+		return c.hedge(input, options)
+	}
+
+	net, err := netOptions(options)
+	if err != nil {
+		return nil, err
+	}
 
-	returnA := stakeA * input.OddsA
-	returnB := stakeB * input.OddsB
-	profitA := returnA - input.TotalStake
-	profitB := returnB - input.TotalStake
+	// Stake_i is proportional to 1/Odds_i, the effective odds after
+	// commission, tax and excise, so every outcome returns the same. For
+	// two options that is Total × Odds_B / (Odds_A + Odds_B). The total is
+	// split in whole cents so the stakes add up to it exactly.
+	weights := make([]float64, len(options))
+	for i, opt := range net {
+		weights[i] = 1.0 / opt.Odds
+	}
+	stakes := money.Split(input.TotalStake, weights)
 
-	marketEff := marketEfficiency(input.OddsA, input.OddsB)
+	limited, stakes, constraints, err := withLimits(input, options, stakes, true)
+	if err != nil {
+		return nil, err
+	}
+	result := withRounding(limited, options, stakes, true, arbitrageResult(options))
+	return markLimits(result, input, limited, constraints), nil
+}
 
-	return &types.CalculationResult{
-		Method:     types.MethodArbitrage,
-		TotalStake: input.TotalStake,
-		Currency:   input.Currency,
-		OptionA: types.Option{
-			Name:               input.NameA,
-			Odds:               input.OddsA,
-			ImpliedProbability: impliedProbability(input.OddsA),
-			Stake:              stakeA,
-			ReturnIfWins:       round(returnA, 2),
-			ProfitIfWins:       round(profitA, 2),
-			ROI:                round(profitA/input.TotalStake, 4),
-		},
-		OptionB: types.Option{
-			Name:               input.NameB,
-			Odds:               input.OddsB,
-			ImpliedProbability: impliedProbability(input.OddsB),
-			Stake:              stakeB,
-			ReturnIfWins:       round(returnB, 2),
-			ProfitIfWins:       round(profitB, 2),
-			ROI:                round(profitB/input.TotalStake, 4),
-		},
-		Summary: types.Summary{
-			GuaranteedProfit: marketEff < 1.0,
-			MinProfit:        round(math.Min(profitA, profitB), 2),
-			MaxProfit:        round(math.Max(profitA, profitB), 2),
-			ExpectedValue:    round((profitA+profitB)/2.0, 2),
-			MinROI:           round(math.Min(profitA, profitB)/input.TotalStake, 4),
-			MaxROI:           round(math.Max(profitA, profitB)/input.TotalStake, 4),
-			MarketEfficiency: round(marketEff, 4),
-		},
-	}, nil
+func arbitrageResult(options []types.Option) func(*types.CalculationInput, []float64) *types.CalculationResult {
+	return func(input *types.CalculationInput, stakes []float64) *types.CalculationResult {
+		result := newResult(types.MethodArbitrage, input, options, stakes)
+		result.Summary.ExpectedValue = money.FromFloat(meanProfit(options, stakes, input.TotalStake))
+		return result
+	}
+}
+
+// hedge solves a back bet at a bookmaker against a lay of the same selection
+// on an exchange. The total is split between the back stake and the lay
+// liability so that both results return the same amount after commission.
+func (c *ArbitrageCalculator) hedge(input *types.CalculationInput, options []types.Option) (*types.CalculationResult, error) {
+	if len(options) != 2 || options[0].Side == options[1].Side {
+		return nil, errors.New("lay bets require exactly one back and one lay option on the same selection")
+	}
+
+	weights := make([]float64, len(options))
+	for i, opt := range options {
+		weights[i] = 1.0 / opt.EffectiveOdds()
+	}
+	capital := money.Split(input.TotalStake, weights)
+
+	limited, capital, constraints, err := withLimits(input, options, capital, true)
+	if err != nil {
+		return nil, err
+	}
+	result := withRounding(limited, options, capital, true, arbitrageResult(options))
+	return markLimits(result, input, limited, constraints), nil
+}
+
+func hasLay(options []types.Option) bool {
+	for _, opt := range options {
+		if opt.Side == types.SideLay {
+			return true
+		}
+	}
+	return false
 }
 
 // KellyCalculator implements Kelly Criterion allocation.
 type KellyCalculator struct{}
 
 func (c *KellyCalculator) Calculate(input *types.CalculationInput) (*types.CalculationResult, error) {
-	if input.ProbA == 0 || input.ProbB == 0 {
-		return nil, errors.New("kelly method requires probability estimates for both options")
+	if input.AsianMarket != "" {
+		return asianKelly(input)
+	}
+	options, err := probabilityOptions(input)
+	if err != nil {
+		return nil, err
+	}
+	net, err := netOptions(options)
+	if err != nil {
+		return nil, err
 	}
 
-	kellyA := math.Max(0, (input.ProbA*input.OddsA-1.0)/(input.OddsA-1.0))
-	kellyB := math.Max(0, (input.ProbB*input.OddsB-1.0)/(input.OddsB-1.0))
+	fractions := make([]float64, len(options))
+	for i, opt := range net {
+		fractions[i] = math.Max(0, (opt.Probability*opt.Odds-1.0)/(opt.Odds-1.0))
+	}
+	constraints := applyKellyLimits(input, kellyEdges(net), fractions)
 
-	rawStakeA := input.TotalStake * kellyA
-	rawStakeB := input.TotalStake * kellyB
-	totalRaw := rawStakeA + rawStakeB
+	var totalFraction float64
+	for _, f := range fractions {
+		totalFraction += f
+	}
+	if totalFraction > 1.0 {
+		for i := range fractions {
+			if fractions[i] > 0 {
+				fractions[i] /= totalFraction
+				constraints[i] = types.ConstraintBankroll
+			}
+		}
+	}
 
-	var stakeA, stakeB float64
-	if totalRaw > input.TotalStake {
-		scale := input.TotalStake / totalRaw
-		stakeA = round(rawStakeA*scale, 2)
-		stakeB = round(rawStakeB*scale, 2)
-	} else {
-		stakeA = round(rawStakeA, 2)
-		stakeB = round(rawStakeB, 2)
+	stakes := make([]float64, len(options))
+	for i, f := range fractions {
+		stakes[i] = money.Round(input.TotalStake.Float64() * f)
+	}
+	limited, stakes, limits, err := withLimits(input, options, stakes, false)
+	if err != nil {
+		return nil, err
 	}
 
-	returnA := stakeA * input.OddsA
-	returnB := stakeB * input.OddsB
-	profitA := returnA - input.TotalStake
-	profitB := returnB - input.TotalStake
+	build := func(input *types.CalculationInput, stakes []float64) *types.CalculationResult {
+		result := newResult(types.MethodKelly, input, options, stakes)
 
-	expectedValue := (input.ProbA * profitA) + (input.ProbB * profitB)
-	if probSum := input.ProbA + input.ProbB; probSum < 1.0 {
-		expectedValue += (1.0 - probSum) * (-input.TotalStake)
+		total := input.TotalStake.Float64()
+		var expectedValue, probSum float64
+		for i, opt := range net {
+			result.Options[i].Probability = opt.Probability
+			result.Options[i].BindingConstraint = constraints[i]
+			expectedValue += opt.Probability * (stakes[i]*opt.Odds - total)
+			probSum += opt.Probability
+		}
+		if probSum < 1.0 {
+			expectedValue += (1.0 - probSum) * (-total)
+		}
+		result.Summary.ExpectedValue = money.FromFloat(expectedValue)
+		if missingProbability(input.Outcomes()) {
+			result.Summary.FairFrom = input.FairFrom
+		}
+		return result
 	}
 
-	marketEff := marketEfficiency(input.OddsA, input.OddsB)
+	result := withRounding(limited, options, stakes, false, build)
+	return markLimits(result, input, limited, limits), nil
+}
 
-	return &types.CalculationResult{
-		Method:     types.MethodKelly,
-		TotalStake: input.TotalStake,
-		Currency:   input.Currency,
-		OptionA: types.Option{
-			Name:               input.NameA,
-			Odds:               input.OddsA,
-			ImpliedProbability: impliedProbability(input.OddsA),
-			Probability:        input.ProbA,
-			Stake:              stakeA,
-			ReturnIfWins:       round(returnA, 2),
-			ProfitIfWins:       round(profitA, 2),
-			ROI:                round(profitA/input.TotalStake, 4),
-		},
-		OptionB: types.Option{
-			Name:               input.NameB,
-			Odds:               input.OddsB,
-			ImpliedProbability: impliedProbability(input.OddsB),
-			Probability:        input.ProbB,
-			Stake:              stakeB,
-			ReturnIfWins:       round(returnB, 2),
-			ProfitIfWins:       round(profitB, 2),
-			ROI:                round(profitB/input.TotalStake, 4),
-		},
-		Summary: types.Summary{
-			GuaranteedProfit: marketEff < 1.0,
-			MinProfit:        round(math.Min(profitA, profitB), 2),
-			MaxProfit:        round(math.Max(profitA, profitB), 2),
-			ExpectedValue:    round(expectedValue, 2),
-			MinROI:           round(math.Min(profitA, profitB)/input.TotalStake, 4),
-			MaxROI:           round(math.Max(profitA, profitB)/input.TotalStake, 4),
-			MarketEfficiency: round(marketEff, 4),
-		},
-	}, nil
-}
-
-// ProportionalCalculator implements proportional allocation.
-type ProportionalCalculator struct{}
-
-func (c *ProportionalCalculator) Calculate(input *types.CalculationInput) (*types.CalculationResult, error) {
-	weightA := 1.0 / input.OddsA
-	weightB := 1.0 / input.OddsB
-	totalWeight := weightA + weightB
-
-	stakeA := round(input.TotalStake*(weightA/totalWeight), 2)
-	stakeB := round(input.TotalStake*(weightB/totalWeight), 2)
-
-	returnA := stakeA * input.OddsA
-	returnB := stakeB * input.OddsB
-	profitA := returnA - input.TotalStake
-	profitB := returnB - input.TotalStake
-
-	marketEff := marketEfficiency(input.OddsA, input.OddsB)
-
-	return &types.CalculationResult{
-		Method:     types.MethodProportional,
-		TotalStake: input.TotalStake,
-		Currency:   input.Currency,
-		OptionA: types.Option{
-			Name:               input.NameA,
-			Odds:               input.OddsA,
-			ImpliedProbability: impliedProbability(input.OddsA),
-			Stake:              stakeA,
-			ReturnIfWins:       round(returnA, 2),
-			ProfitIfWins:       round(profitA, 2),
-			ROI:                round(profitA/input.TotalStake, 4),
-		},
-		OptionB: types.Option{
-			Name:               input.NameB,
-			Odds:               input.OddsB,
-			ImpliedProbability: impliedProbability(input.OddsB),
-			Stake:              stakeB,
-			ReturnIfWins:       round(returnB, 2),
-			ProfitIfWins:       round(profitB, 2),
-			ROI:                round(profitB/input.TotalStake, 4),
-		},
-		Summary: types.Summary{
-			GuaranteedProfit: marketEff < 1.0,
-			MinProfit:        round(math.Min(profitA, profitB), 2),
-			MaxProfit:        round(math.Max(profitA, profitB), 2),
-			ExpectedValue:    round((profitA+profitB)/2.0, 2),
-			MinROI:           round(math.Min(profitA, profitB)/input.TotalStake, 4),
-			MaxROI:           round(math.Max(profitA, profitB)/input.TotalStake, 4),
-			MarketEfficiency: round(marketEff, 4),
-		},
-	}, nil
+func missingProbability(options []types.Option) bool {
+	for _, opt := range options {
+		if opt.Probability == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// probabilityOptions returns the options of the input, filling in missing
+// probabilities from the de-margined market when FairFrom is set.
+func probabilityOptions(input *types.CalculationInput) ([]types.Option, error) {
+	options := input.Outcomes()
+	if !missingProbability(options) {
+		return options, nil
+	}
+	if input.FairFrom == "" {
+		return nil, fmt.Errorf("%s method requires probability estimates for all %d options", input.Method, len(options))
+	}
+
+	useSharp := true
+	for _, opt := range options {
+		if opt.SharpOdds <= 0 {
+			useSharp = false
+		}
+	}
+	odds := make([]float64, len(options))
+	for i, opt := range options {
+		odds[i] = opt.Odds
+		if useSharp {
+			odds[i] = opt.SharpOdds
+		}
+	}
+
+	fair, err := margin.FairProbabilities(odds, input.FairFrom)
+	if err != nil {
+		return nil, fmt.Errorf("deriving fair probabilities: %w", err)
+	}
+
+	filled := make([]types.Option, len(options))
+	copy(filled, options)
+	for i := range filled {
+		if filled[i].Probability == 0 {
+			filled[i].Probability = fair[i]
+		}
+	}
+	return filled, nil
+}
+
+// kellyEdges returns the edge, p × odds - 1, of each option.
+func kellyEdges(options []types.Option) []float64 {
+	edges := make([]float64, len(options))
+	for i, opt := range options {
+		edges[i] = opt.Probability*opt.Odds - 1.0
+	}
+	return edges
+}
+
+// applyKellyLimits applies the minimum edge, Kelly fraction and per-bet cap
+// of the input to the given bankroll fractions in place, and returns the
+// limit that last reduced each option's fraction.
+func applyKellyLimits(input *types.CalculationInput, edges []float64, fractions []float64) []types.Constraint {
+	constraints := make([]types.Constraint, len(edges))
+	maxFraction := input.MaxStakePct / 100.0
+
+	for i, edge := range edges {
+		if input.MinEdge > 0 && edge < input.MinEdge && (edge > 0 || fractions[i] > 0) {
+			fractions[i] = 0
+			constraints[i] = types.ConstraintMinEdge
+			continue
+		}
+		if fractions[i] <= 0 {
+			continue
+		}
+		if input.KellyFraction > 0 && input.KellyFraction < 1 {
+			fractions[i] *= input.KellyFraction
+			constraints[i] = types.ConstraintKellyFraction
+		}
+		if maxFraction > 0 && fractions[i] > maxFraction {
+			fractions[i] = maxFraction
+			constraints[i] = types.ConstraintMaxStake
+		}
+	}
+	return constraints
 }
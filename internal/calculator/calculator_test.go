@@ -4,6 +4,7 @@ import (
 	"math"
 	"testing"
 
+	"github.com/codehakase/kelly/pkg/money"
 	"github.com/codehakase/kelly/pkg/types"
 )
 
@@ -19,7 +20,7 @@ func TestKellyCalculator_Calculate(t *testing.T) {
 				Method:     types.MethodKelly,
 				OddsA:      2.1,
 				OddsB:      3.5,
-				TotalStake: 1000,
+				TotalStake: money.FromFloat(1000),
 				ProbA:      0.55,
 				ProbB:      0.40,
 				NameA:      "Team A",
@@ -34,7 +35,7 @@ func TestKellyCalculator_Calculate(t *testing.T) {
 				Method:     types.MethodKelly,
 				OddsA:      2.0,
 				OddsB:      2.0,
-				TotalStake: 1000,
+				TotalStake: money.FromFloat(1000),
 				ProbA:      0.5,
 				ProbB:      0.5,
 				NameA:      "Even A",
@@ -49,7 +50,7 @@ func TestKellyCalculator_Calculate(t *testing.T) {
 				Method:     types.MethodKelly,
 				OddsA:      3.0,
 				OddsB:      2.0,
-				TotalStake: 5000,
+				TotalStake: money.FromFloat(5000),
 				ProbA:      0.8,
 				ProbB:      0.15,
 				NameA:      "Favorite",
@@ -64,7 +65,7 @@ func TestKellyCalculator_Calculate(t *testing.T) {
 				Method:     types.MethodKelly,
 				OddsA:      2.0,
 				OddsB:      2.0,
-				TotalStake: 1000,
+				TotalStake: money.FromFloat(1000),
 				ProbA:      0,
 				ProbB:      0,
 				NameA:      "A",
@@ -79,7 +80,7 @@ func TestKellyCalculator_Calculate(t *testing.T) {
 				Method:     types.MethodKelly,
 				OddsA:      2.0,
 				OddsB:      2.0,
-				TotalStake: 1000,
+				TotalStake: money.FromFloat(1000),
 				ProbA:      0.5,
 				ProbB:      0,
 				NameA:      "A",
@@ -113,23 +114,23 @@ func TestKellyCalculator_Calculate(t *testing.T) {
 			}
 
 			// Check that probabilities are set in options
-			if result.OptionA.Probability != tt.input.ProbA {
-				t.Errorf("OptionA.Probability = %.4f, want %.4f", result.OptionA.Probability, tt.input.ProbA)
+			if result.Options[0].Probability != tt.input.ProbA {
+				t.Errorf("OptionA.Probability = %.4f, want %.4f", result.Options[0].Probability, tt.input.ProbA)
 			}
 
-			if result.OptionB.Probability != tt.input.ProbB {
-				t.Errorf("OptionB.Probability = %.4f, want %.4f", result.OptionB.Probability, tt.input.ProbB)
+			if result.Options[1].Probability != tt.input.ProbB {
+				t.Errorf("OptionB.Probability = %.4f, want %.4f", result.Options[1].Probability, tt.input.ProbB)
 			}
 
 			// Check that stakes don't exceed total
-			totalAllocated := result.OptionA.Stake + result.OptionB.Stake
-			if totalAllocated > tt.input.TotalStake*1.01 { // Allow 1% tolerance for rounding
+			totalAllocated := result.Options[0].Stake + result.Options[1].Stake
+			if totalAllocated.Float64() > tt.input.TotalStake.Float64()*1.01 { // Allow 1% tolerance for rounding
 				t.Errorf("Total allocated (%.2f) exceeds total stake (%.2f)",
-					totalAllocated, tt.input.TotalStake)
+					totalAllocated.Float64(), tt.input.TotalStake.Float64())
 			}
 
 			// Check that stakes are non-negative
-			if result.OptionA.Stake < 0 || result.OptionB.Stake < 0 {
+			if result.Options[0].Stake < 0 || result.Options[1].Stake < 0 {
 				t.Error("Stakes should be non-negative")
 			}
 		})
@@ -143,7 +144,7 @@ func TestKellyCalculator_Normalization(t *testing.T) {
 		Method:     types.MethodKelly,
 		OddsA:      2.0,
 		OddsB:      2.0,
-		TotalStake: 1000,
+		TotalStake: money.FromFloat(1000),
 		ProbA:      0.7, // Strong belief in A
 		ProbB:      0.6, // Strong belief in B (sum > 1, which is overconfident)
 		NameA:      "A",
@@ -157,9 +158,9 @@ func TestKellyCalculator_Normalization(t *testing.T) {
 	}
 
 	// Total allocated should be normalized to total stake
-	totalAllocated := result.OptionA.Stake + result.OptionB.Stake
-	if totalAllocated > input.TotalStake*1.01 {
-		t.Errorf("Total allocated (%.2f) exceeds total stake (%.2f)",
+	totalAllocated := result.Options[0].Stake + result.Options[1].Stake
+	if totalAllocated.Float64() > input.TotalStake.Float64()*1.01 {
+		t.Errorf("Total allocated (%s) exceeds total stake (%s)",
 			totalAllocated, input.TotalStake)
 	}
 }
@@ -171,7 +172,7 @@ func TestKellyCalculator_NoEdge(t *testing.T) {
 		Method:     types.MethodKelly,
 		OddsA:      2.0, // Implies 50% probability
 		OddsB:      2.0, // Implies 50% probability
-		TotalStake: 1000,
+		TotalStake: money.FromFloat(1000),
 		ProbA:      0.5, // Matches implied probability
 		ProbB:      0.5, // Matches implied probability
 		NameA:      "Fair A",
@@ -186,9 +187,9 @@ func TestKellyCalculator_NoEdge(t *testing.T) {
 
 	// When there's no edge, Kelly should recommend minimal or zero bet
 	// Kelly% = (p × odds - 1) / (odds - 1) = (0.5 × 2 - 1) / (2 - 1) = 0
-	if result.OptionA.Stake > 10 || result.OptionB.Stake > 10 {
+	if result.Options[0].Stake > money.FromFloat(10) || result.Options[1].Stake > money.FromFloat(10) {
 		t.Errorf("With no edge, stakes should be minimal (A: %.2f, B: %.2f)",
-			result.OptionA.Stake, result.OptionB.Stake)
+			result.Options[0].Stake.Float64(), result.Options[1].Stake.Float64())
 	}
 }
 
@@ -199,7 +200,7 @@ func TestKellyCalculator_ExpectedValue(t *testing.T) {
 		Method:     types.MethodKelly,
 		OddsA:      2.5,
 		OddsB:      3.0,
-		TotalStake: 1000,
+		TotalStake: money.FromFloat(1000),
 		ProbA:      0.6,
 		ProbB:      0.3,
 		NameA:      "A",
@@ -214,13 +215,138 @@ func TestKellyCalculator_ExpectedValue(t *testing.T) {
 
 	// Expected value should be positive if user has an edge
 	// EV = probA × profitA + probB × profitB + (1 - probA - probB) × -totalStake
-	expectedEV := input.ProbA*result.OptionA.ProfitIfWins +
-		input.ProbB*result.OptionB.ProfitIfWins +
-		(1-input.ProbA-input.ProbB)*(-input.TotalStake)
+	expectedEV := input.ProbA*result.Options[0].ProfitIfWins.Float64() +
+		input.ProbB*result.Options[1].ProfitIfWins.Float64() +
+		(1-input.ProbA-input.ProbB)*(-input.TotalStake.Float64())
 
-	if !floatAlmostEqual(result.Summary.ExpectedValue, expectedEV, 1.0) {
+	if !floatAlmostEqual(result.Summary.ExpectedValue.Float64(), expectedEV, 1.0) {
 		t.Errorf("ExpectedValue = %.2f, calculated EV = %.2f",
-			result.Summary.ExpectedValue, expectedEV)
+			result.Summary.ExpectedValue.Float64(), expectedEV)
+	}
+}
+
+func TestKellyCalculator_Limits(t *testing.T) {
+	// Full Kelly for A: (0.55 × 2.1 - 1) / 1.1 ≈ 14.09%; B has edge 0.4 × 3.5 - 1 = 0.4.
+	base := types.CalculationInput{
+		Method:     types.MethodKelly,
+		OddsA:      2.1,
+		OddsB:      3.5,
+		TotalStake: money.FromFloat(1000),
+		ProbA:      0.55,
+		ProbB:      0.40,
+		NameA:      "A",
+		NameB:      "B",
+	}
+
+	tests := []struct {
+		name            string
+		modify          func(in *types.CalculationInput)
+		wantStakeA      float64
+		wantStakeB      float64
+		wantConstraintA types.Constraint
+		wantConstraintB types.Constraint
+	}{
+		{
+			name:       "full Kelly",
+			modify:     func(in *types.CalculationInput) {},
+			wantStakeA: 140.91,
+			wantStakeB: 160,
+		},
+		{
+			name:            "quarter Kelly",
+			modify:          func(in *types.CalculationInput) { in.KellyFraction = 0.25 },
+			wantStakeA:      35.23,
+			wantStakeB:      40,
+			wantConstraintA: types.ConstraintKellyFraction,
+			wantConstraintB: types.ConstraintKellyFraction,
+		},
+		{
+			name:            "max stake cap",
+			modify:          func(in *types.CalculationInput) { in.MaxStakePct = 15 },
+			wantStakeA:      140.91,
+			wantStakeB:      150,
+			wantConstraintB: types.ConstraintMaxStake,
+		},
+		{
+			name:            "min edge",
+			modify:          func(in *types.CalculationInput) { in.MinEdge = 0.2 },
+			wantStakeA:      0,
+			wantStakeB:      160,
+			wantConstraintA: types.ConstraintMinEdge,
+		},
+		{
+			name: "bankroll",
+			modify: func(in *types.CalculationInput) {
+				in.OddsA, in.OddsB, in.ProbA, in.ProbB = 3.0, 3.0, 0.8, 0.6
+			},
+			wantStakeA:      636.36,
+			wantStakeB:      363.64,
+			wantConstraintA: types.ConstraintBankroll,
+			wantConstraintB: types.ConstraintBankroll,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := base
+			tt.modify(&input)
+
+			result, err := (&KellyCalculator{}).Calculate(&input)
+			if err != nil {
+				t.Fatalf("Calculate() error: %v", err)
+			}
+
+			if !floatAlmostEqual(result.Options[0].Stake.Float64(), tt.wantStakeA, 0.01) {
+				t.Errorf("Options[0].Stake = %.2f, want %.2f", result.Options[0].Stake.Float64(), tt.wantStakeA)
+			}
+			if !floatAlmostEqual(result.Options[1].Stake.Float64(), tt.wantStakeB, 0.01) {
+				t.Errorf("Options[1].Stake = %.2f, want %.2f", result.Options[1].Stake.Float64(), tt.wantStakeB)
+			}
+			if result.Options[0].BindingConstraint != tt.wantConstraintA {
+				t.Errorf("Options[0].BindingConstraint = %q, want %q", result.Options[0].BindingConstraint, tt.wantConstraintA)
+			}
+			if result.Options[1].BindingConstraint != tt.wantConstraintB {
+				t.Errorf("Options[1].BindingConstraint = %q, want %q", result.Options[1].BindingConstraint, tt.wantConstraintB)
+			}
+		})
+	}
+}
+
+func TestKellyCalculator_FairFrom(t *testing.T) {
+	// Bet the soft book at 2.2 / 1.9 using the sharp book's de-vigged line.
+	calc := &KellyCalculator{}
+	input := &types.CalculationInput{
+		Method: types.MethodKelly,
+		Options: []types.Option{
+			{Name: "A", Odds: 2.2, SharpOdds: 2.05},
+			{Name: "B", Odds: 1.9, SharpOdds: 1.85},
+		},
+		TotalStake: money.FromFloat(1000),
+		FairFrom:   types.MarginMultiplicative,
+	}
+
+	result, err := calc.Calculate(input)
+	if err != nil {
+		t.Fatalf("Calculate() error: %v", err)
+	}
+
+	booksum := 1/2.05 + 1/1.85
+	wantProbA := (1 / 2.05) / booksum
+	if !floatAlmostEqual(result.Options[0].Probability, wantProbA, 1e-9) {
+		t.Errorf("Options[0].Probability = %.6f, want %.6f", result.Options[0].Probability, wantProbA)
+	}
+	wantStakeA := round(1000*(wantProbA*2.2-1)/1.2, 2)
+	if !floatAlmostEqual(result.Options[0].Stake.Float64(), wantStakeA, 0.01) {
+		t.Errorf("Options[0].Stake = %.2f, want %.2f", result.Options[0].Stake.Float64(), wantStakeA)
+	}
+	if result.Options[1].Stake != 0 {
+		t.Errorf("Options[1].Stake = %.2f, want 0 (no edge)", result.Options[1].Stake.Float64())
+	}
+	if result.Summary.FairFrom != types.MarginMultiplicative {
+		t.Errorf("Summary.FairFrom = %q, want %q", result.Summary.FairFrom, types.MarginMultiplicative)
+	}
+	if input.Options[0].Probability != 0 {
+		t.Error("Calculate() should not modify the input options")
 	}
 }
 
@@ -240,15 +366,15 @@ func TestArbitrageCalculator_Calculate(t *testing.T) {
 				Method:     types.MethodArbitrage,
 				OddsA:      2.564102564102564,  // 39% converted
 				OddsB:      3.8461538461538463, // 26% converted
-				TotalStake: 10000,
+				TotalStake: money.FromFloat(10000),
 				NameA:      "Davido - With You",
 				NameB:      "Tyla - PUSH 2 START",
 				Currency:   "₦",
 			},
-			wantStakeA:  6453.49, // Actual calculation
-			wantStakeB:  3546.51, // Actual calculation
-			wantProfitA: 6545.65, // Actual profit
-			wantProfitB: 3640.42, // Actual profit
+			wantStakeA:  6000,
+			wantStakeB:  4000,
+			wantProfitA: 5384.62, // Equal whichever wins
+			wantProfitB: 5384.62,
 			wantErr:     false,
 		},
 		{
@@ -257,7 +383,7 @@ func TestArbitrageCalculator_Calculate(t *testing.T) {
 				Method:     types.MethodArbitrage,
 				OddsA:      2.0,
 				OddsB:      2.0,
-				TotalStake: 1000,
+				TotalStake: money.FromFloat(1000),
 				NameA:      "Option A",
 				NameB:      "Option B",
 				Currency:   "$",
@@ -274,15 +400,15 @@ func TestArbitrageCalculator_Calculate(t *testing.T) {
 				Method:     types.MethodArbitrage,
 				OddsA:      2.5,
 				OddsB:      3.0,
-				TotalStake: 1000,
+				TotalStake: money.FromFloat(1000),
 				NameA:      "Team A",
 				NameB:      "Team B",
 				Currency:   "$",
 			},
-			wantStakeA:  571.43,
-			wantStakeB:  428.57,
-			wantProfitA: 428.57,
-			wantProfitB: 285.71,
+			wantStakeA:  545.45,
+			wantStakeB:  454.55,
+			wantProfitA: 363.64,
+			wantProfitB: 363.64,
 			wantErr:     false,
 		},
 		{
@@ -291,15 +417,15 @@ func TestArbitrageCalculator_Calculate(t *testing.T) {
 				Method:     types.MethodArbitrage,
 				OddsA:      5.0,
 				OddsB:      10.0,
-				TotalStake: 5000,
+				TotalStake: money.FromFloat(5000),
 				NameA:      "Underdog A",
 				NameB:      "Underdog B",
 				Currency:   "$",
 			},
-			wantStakeA:  3461.54,
-			wantStakeB:  1538.46,
-			wantProfitA: 12307.7, // Actual profit
-			wantProfitB: 10384.6, // Actual profit
+			wantStakeA:  3333.33,
+			wantStakeB:  1666.67,
+			wantProfitA: 11666.67,
+			wantProfitB: 11666.67,
 			wantErr:     false,
 		},
 	}
@@ -322,21 +448,21 @@ func TestArbitrageCalculator_Calculate(t *testing.T) {
 			}
 
 			// Check stakes
-			if !floatAlmostEqual(result.OptionA.Stake, tt.wantStakeA, 1.0) {
-				t.Errorf("OptionA.Stake = %.2f, want %.2f", result.OptionA.Stake, tt.wantStakeA)
+			if !floatAlmostEqual(result.Options[0].Stake.Float64(), tt.wantStakeA, 1.0) {
+				t.Errorf("OptionA.Stake = %.2f, want %.2f", result.Options[0].Stake.Float64(), tt.wantStakeA)
 			}
 
-			if !floatAlmostEqual(result.OptionB.Stake, tt.wantStakeB, 1.0) {
-				t.Errorf("OptionB.Stake = %.2f, want %.2f", result.OptionB.Stake, tt.wantStakeB)
+			if !floatAlmostEqual(result.Options[1].Stake.Float64(), tt.wantStakeB, 1.0) {
+				t.Errorf("OptionB.Stake = %.2f, want %.2f", result.Options[1].Stake.Float64(), tt.wantStakeB)
 			}
 
 			// Check profits
-			if !floatAlmostEqual(result.OptionA.ProfitIfWins, tt.wantProfitA, 10.0) {
-				t.Errorf("OptionA.ProfitIfWins = %.2f, want %.2f", result.OptionA.ProfitIfWins, tt.wantProfitA)
+			if !floatAlmostEqual(result.Options[0].ProfitIfWins.Float64(), tt.wantProfitA, 10.0) {
+				t.Errorf("OptionA.ProfitIfWins = %.2f, want %.2f", result.Options[0].ProfitIfWins.Float64(), tt.wantProfitA)
 			}
 
-			if !floatAlmostEqual(result.OptionB.ProfitIfWins, tt.wantProfitB, 10.0) {
-				t.Errorf("OptionB.ProfitIfWins = %.2f, want %.2f", result.OptionB.ProfitIfWins, tt.wantProfitB)
+			if !floatAlmostEqual(result.Options[1].ProfitIfWins.Float64(), tt.wantProfitB, 10.0) {
+				t.Errorf("OptionB.ProfitIfWins = %.2f, want %.2f", result.Options[1].ProfitIfWins.Float64(), tt.wantProfitB)
 			}
 
 			// Check that method is correct
@@ -346,7 +472,7 @@ func TestArbitrageCalculator_Calculate(t *testing.T) {
 
 			// Check that total stake is correct
 			if result.TotalStake != tt.input.TotalStake {
-				t.Errorf("TotalStake = %.2f, want %.2f", result.TotalStake, tt.input.TotalStake)
+				t.Errorf("TotalStake = %s, want %s", result.TotalStake, tt.input.TotalStake)
 			}
 
 			// Check that currency is correct
@@ -362,7 +488,7 @@ func TestArbitrageCalculator_Calculate(t *testing.T) {
 			// Check that summary values are consistent
 			if result.Summary.MinProfit > result.Summary.MaxProfit {
 				t.Errorf("MinProfit (%.2f) should be <= MaxProfit (%.2f)",
-					result.Summary.MinProfit, result.Summary.MaxProfit)
+					result.Summary.MinProfit.Float64(), result.Summary.MaxProfit.Float64())
 			}
 		})
 	}
@@ -387,7 +513,7 @@ func TestArbitrageCalculator_GuaranteedProfit(t *testing.T) {
 				Method:     types.MethodArbitrage,
 				OddsA:      tt.oddsA,
 				OddsB:      tt.oddsB,
-				TotalStake: 1000,
+				TotalStake: money.FromFloat(1000),
 				NameA:      "A",
 				NameB:      "B",
 				Currency:   "$",
@@ -412,7 +538,7 @@ func TestArbitrageCalculator_EqualProfits(t *testing.T) {
 		Method:     types.MethodArbitrage,
 		OddsA:      2.5,
 		OddsB:      3.0,
-		TotalStake: 1000,
+		TotalStake: money.FromFloat(1000),
 		NameA:      "A",
 		NameB:      "B",
 		Currency:   "$",
@@ -423,202 +549,305 @@ func TestArbitrageCalculator_EqualProfits(t *testing.T) {
 		t.Fatalf("Calculate() error: %v", err)
 	}
 
-	// For arbitrage, profits should be close (within rounding errors)
-	profitDiff := math.Abs(result.OptionA.ProfitIfWins - result.OptionB.ProfitIfWins)
+	// For arbitrage, profits should be equal whichever option wins
+	profitDiff := math.Abs((result.Options[0].ProfitIfWins - result.Options[1].ProfitIfWins).Float64())
 
-	// Allow for some difference due to rounding
-	if profitDiff > 150 {
+	// Stakes are whole cents, so profits may differ by a few cents
+	if profitDiff > 0.05 {
 		t.Errorf("Profit difference too large: %.2f (A: %.2f, B: %.2f)",
-			profitDiff, result.OptionA.ProfitIfWins, result.OptionB.ProfitIfWins)
+			profitDiff, result.Options[0].ProfitIfWins.Float64(), result.Options[1].ProfitIfWins.Float64())
 	}
 }
 
-func TestProportionalCalculator_Calculate(t *testing.T) {
-	tests := []struct {
-		name       string
-		input      *types.CalculationInput
-		wantStakeA float64
-		wantStakeB float64
-		wantErr    bool
-	}{
-		{
-			name: "equal odds",
-			input: &types.CalculationInput{
-				Method:     types.MethodProportional,
-				OddsA:      2.0,
-				OddsB:      2.0,
-				TotalStake: 1000,
-				NameA:      "A",
-				NameB:      "B",
-				Currency:   "$",
-			},
-			wantStakeA: 500,
-			wantStakeB: 500,
-			wantErr:    false,
-		},
-		{
-			name: "different odds",
-			input: &types.CalculationInput{
-				Method:     types.MethodProportional,
-				OddsA:      2.5,
-				OddsB:      3.0,
-				TotalStake: 1000,
-				NameA:      "Team A",
-				NameB:      "Team B",
-				Currency:   "$",
-			},
-			wantStakeA: 545.45,
-			wantStakeB: 454.55,
-			wantErr:    false,
-		},
-		{
-			name: "high vs low odds",
-			input: &types.CalculationInput{
-				Method:     types.MethodProportional,
-				OddsA:      5.0,
-				OddsB:      2.0,
-				TotalStake: 5000,
-				NameA:      "Underdog",
-				NameB:      "Favorite",
-				Currency:   "$",
-			},
-			wantStakeA: 1428.57,
-			wantStakeB: 3571.43,
-			wantErr:    false,
+func TestArbitrageCalculator_BackLayHedge(t *testing.T) {
+	calc := &ArbitrageCalculator{}
+	input := &types.CalculationInput{
+		Method: types.MethodArbitrage,
+		Options: []types.Option{
+			{Name: "Back", Odds: 3.2, Side: types.SideBack},
+			{Name: "Lay", Odds: 3.1, Side: types.SideLay, Commission: 0.02},
 		},
-		{
-			name: "very different odds",
-			input: &types.CalculationInput{
-				Method:     types.MethodProportional,
-				OddsA:      10.0,
-				OddsB:      1.5,
-				TotalStake: 2000,
-				NameA:      "Longshot",
-				NameB:      "Heavy Favorite",
-				Currency:   "$",
-			},
-			wantStakeA: 260.87,
-			wantStakeB: 1739.13,
-			wantErr:    false,
+		TotalStake: money.FromFloat(1000),
+	}
+
+	result, err := calc.Calculate(input)
+	if err != nil {
+		t.Fatalf("Calculate() error: %v", err)
+	}
+
+	back, lay := result.Options[0], result.Options[1]
+	if !floatAlmostEqual((back.Stake + lay.Liability).Float64(), 1000, 0.01) {
+		t.Errorf("back stake + liability = %.2f, want 1000", (back.Stake + lay.Liability).Float64())
+	}
+	if !floatAlmostEqual(lay.Liability.Float64(), lay.Stake.Float64()*(3.1-1), 0.01) {
+		t.Errorf("Liability = %.2f, want (odds-1) × stake = %.2f", lay.Liability.Float64(), lay.Stake.Float64()*2.1)
+	}
+
+	// Selection wins: back profit minus liability. Selection loses: lay
+	// winnings after commission minus the back stake.
+	ifWins := back.Stake.Float64()*2.2 - lay.Liability.Float64()
+	ifLoses := lay.Stake.Float64()*0.98 - back.Stake.Float64()
+	if !floatAlmostEqual(back.ProfitIfWins.Float64(), ifWins, 0.05) {
+		t.Errorf("back ProfitIfWins = %.2f, want %.2f", back.ProfitIfWins.Float64(), ifWins)
+	}
+	if !floatAlmostEqual(lay.ProfitIfWins.Float64(), ifLoses, 0.05) {
+		t.Errorf("lay ProfitIfWins = %.2f, want %.2f", lay.ProfitIfWins.Float64(), ifLoses)
+	}
+	if !floatAlmostEqual(back.ProfitIfWins.Float64(), lay.ProfitIfWins.Float64(), 0.05) {
+		t.Errorf("hedge should equalize profit: %.2f vs %.2f", back.ProfitIfWins.Float64(), lay.ProfitIfWins.Float64())
+	}
+	if !result.Summary.GuaranteedProfit {
+		t.Error("GuaranteedProfit should be true")
+	}
+}
+
+func TestCalculators_Commission(t *testing.T) {
+	// 5% commission on a back bet at 3.0 pays like odds of 2.9.
+	input := &types.CalculationInput{
+		Method: types.MethodArbitrage,
+		Options: []types.Option{
+			{Name: "A", Odds: 3.0, Commission: 0.05},
+			{Name: "B", Odds: 1.8},
 		},
+		TotalStake: money.FromFloat(1000),
 	}
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			calc := &ProportionalCalculator{}
-			result, err := calc.Calculate(tt.input)
+	result, err := (&ArbitrageCalculator{}).Calculate(input)
+	if err != nil {
+		t.Fatalf("Calculate() error: %v", err)
+	}
+	wantReturn := round(result.Options[0].Stake.Float64()*2.9, 2)
+	if !floatAlmostEqual(result.Options[0].ReturnIfWins.Float64(), wantReturn, 0.01) {
+		t.Errorf("ReturnIfWins = %.2f, want %.2f", result.Options[0].ReturnIfWins.Float64(), wantReturn)
+	}
 
-			if tt.wantErr {
-				if err == nil {
-					t.Errorf("ProportionalCalculator.Calculate() expected error, got nil")
-				}
-				return
-			}
+	input.Options[1].Side = types.SideLay
+	if _, err := (&KellyCalculator{}).Calculate(input); err == nil {
+		t.Error("KellyCalculator should reject lay bets")
+	}
+}
 
-			if err != nil {
-				t.Errorf("ProportionalCalculator.Calculate() unexpected error: %v", err)
-				return
-			}
+func TestCalculators_TaxAndExcise(t *testing.T) {
+	// 20% tax on the winnings at 2.6 pays like odds of 2.28; 12.5% excise
+	// on the stake then leaves 0.875 × 2.28 = 1.995.
+	input := &types.CalculationInput{
+		Method: types.MethodArbitrage,
+		Options: []types.Option{
+			{Name: "A", Odds: 2.6, TaxOnWinnings: 0.2, StakeExcise: 0.125},
+			{Name: "B", Odds: 2.55, TaxOnWinnings: 0.2},
+		},
+		TotalStake: money.FromFloat(10000),
+	}
 
-			// Check stakes
-			if !floatAlmostEqual(result.OptionA.Stake, tt.wantStakeA, 1.0) {
-				t.Errorf("OptionA.Stake = %.2f, want %.2f", result.OptionA.Stake, tt.wantStakeA)
-			}
+	result, err := (&ArbitrageCalculator{}).Calculate(input)
+	if err != nil {
+		t.Fatalf("Calculate() error: %v", err)
+	}
+	for i, odds := range []float64{1.995, 2.24} {
+		opt := result.Options[i]
+		if !floatAlmostEqual(opt.ReturnIfWins.Float64(), opt.Stake.Float64()*odds, 0.01) {
+			t.Errorf("%s: ReturnIfWins = %.2f, want %.2f net", opt.Name, opt.ReturnIfWins.Float64(), opt.Stake.Float64()*odds)
+		}
+		gross := input.Options[i].Odds * opt.Stake.Float64()
+		if !floatAlmostEqual(opt.GrossReturn.Float64(), gross, 0.01) {
+			t.Errorf("%s: GrossReturn = %.2f, want %.2f", opt.Name, opt.GrossReturn.Float64(), gross)
+		}
+	}
+	// Stakes follow the net odds, so both outcomes still profit the same.
+	if !floatAlmostEqual(result.Options[0].ProfitIfWins.Float64(), result.Options[1].ProfitIfWins.Float64(), 0.05) {
+		t.Errorf("profits = %.2f / %.2f, want equal after tax and excise",
+			result.Options[0].ProfitIfWins.Float64(), result.Options[1].ProfitIfWins.Float64())
+	}
 
-			if !floatAlmostEqual(result.OptionB.Stake, tt.wantStakeB, 1.0) {
-				t.Errorf("OptionB.Stake = %.2f, want %.2f", result.OptionB.Stake, tt.wantStakeB)
-			}
+	// An edge at 2.2 with a 50% chance is gone once the winnings are taxed.
+	kelly := &types.CalculationInput{
+		Method:     types.MethodKelly,
+		Options:    []types.Option{{Odds: 2.2, Probability: 0.5, TaxOnWinnings: 0.2}, {Odds: 1.5, Probability: 0.5}},
+		TotalStake: money.FromFloat(1000),
+	}
+	result, err = (&KellyCalculator{}).Calculate(kelly)
+	if err != nil {
+		t.Fatalf("Calculate() error: %v", err)
+	}
+	if result.Options[0].Stake != 0 {
+		t.Errorf("taxed stake = %.2f, want 0 with no edge left", result.Options[0].Stake.Float64())
+	}
 
-			// Check that method is correct
-			if result.Method != types.MethodProportional {
-				t.Errorf("Method = %v, want %v", result.Method, types.MethodProportional)
-			}
+	untaxed := &types.CalculationInput{
+		Method:     types.MethodArbitrage,
+		Options:    []types.Option{{Odds: 2.2}, {Odds: 1.5}},
+		TotalStake: money.FromFloat(1000),
+	}
+	result, err = (&ArbitrageCalculator{}).Calculate(untaxed)
+	if err != nil {
+		t.Fatalf("Calculate() error: %v", err)
+	}
+	if result.Options[0].GrossReturn != 0 {
+		t.Errorf("GrossReturn = %.2f, want it left out without tax or excise", result.Options[0].GrossReturn.Float64())
+	}
 
-			// Check that stakes sum to total (within rounding error)
-			totalStake := result.OptionA.Stake + result.OptionB.Stake
-			if !floatAlmostEqual(totalStake, tt.input.TotalStake, 1.0) {
-				t.Errorf("Total stake = %.2f, want %.2f", totalStake, tt.input.TotalStake)
-			}
-		})
+	input.Options[0] = types.Option{Odds: 1.1, StakeExcise: 0.125}
+	if _, err := (&ArbitrageCalculator{}).Calculate(input); err == nil {
+		t.Error("Calculate() should reject odds that return less than the stake after excise")
 	}
 }
 
-func TestProportionalCalculator_InverseProportionality(t *testing.T) {
-	// Test that higher odds result in lower stakes (inverse proportionality)
-	calc := &ProportionalCalculator{}
+func TestProportionalCalculator_RunsAsArbitrage(t *testing.T) {
+	// The retired proportional method is an alias: same stakes, reported
+	// as arbitrage.
 	input := &types.CalculationInput{
 		Method:     types.MethodProportional,
-		OddsA:      5.0, // Higher odds
-		OddsB:      2.0, // Lower odds
-		TotalStake: 1000,
+		OddsA:      5.0,
+		OddsB:      2.0,
+		TotalStake: money.FromFloat(1000),
 		NameA:      "High Odds",
 		NameB:      "Low Odds",
 		Currency:   "$",
 	}
 
-	result, err := calc.Calculate(input)
+	result, err := NewCalculator(types.MethodProportional).Calculate(input)
 	if err != nil {
 		t.Fatalf("Calculate() error: %v", err)
 	}
-
-	// Higher odds should get lower stake
-	if result.OptionA.Stake >= result.OptionB.Stake {
-		t.Errorf("Higher odds (A) should get lower stake: A=%.2f, B=%.2f",
-			result.OptionA.Stake, result.OptionB.Stake)
+	if result.Method != types.MethodArbitrage {
+		t.Errorf("Method = %v, want %v", result.Method, types.MethodArbitrage)
 	}
 
-	// Check that the ratio is correct
-	// Weight_A = 1/5 = 0.2, Weight_B = 1/2 = 0.5, Total = 0.7
-	// Stake_A should be 0.2/0.7 ≈ 28.57%, Stake_B should be 0.5/0.7 ≈ 71.43%
-	expectedRatio := (1.0 / input.OddsA) / (1.0/input.OddsA + 1.0/input.OddsB)
-	actualRatio := result.OptionA.Stake / input.TotalStake
-
-	if !floatAlmostEqual(actualRatio, expectedRatio, 0.01) {
-		t.Errorf("Stake ratio = %.4f, want %.4f", actualRatio, expectedRatio)
+	// Weight_A = 1/5 = 0.2, Weight_B = 1/2 = 0.5: 285.71 and 714.29.
+	if !floatAlmostEqual(result.Options[0].Stake.Float64(), 285.71, 0.01) {
+		t.Errorf("StakeA = %.2f, want 285.71", result.Options[0].Stake.Float64())
+	}
+	if !floatAlmostEqual(result.Options[1].Stake.Float64(), 714.29, 0.01) {
+		t.Errorf("StakeB = %.2f, want 714.29", result.Options[1].Stake.Float64())
 	}
 }
 
-func TestProportionalCalculator_StakesAlwaysPositive(t *testing.T) {
-	// Test that stakes are always positive regardless of odds
-	tests := []struct {
-		name  string
-		oddsA float64
-		oddsB float64
-	}{
-		{"normal odds", 2.0, 3.0},
-		{"extreme odds", 100.0, 1.01},
-		{"near-even odds", 1.01, 1.02},
+func TestCalculators_ThreeWayMarket(t *testing.T) {
+	input := &types.CalculationInput{
+		Options: []types.Option{
+			{Name: "Home", Odds: 2.9, Probability: 0.40},
+			{Name: "Draw", Odds: 3.6, Probability: 0.30},
+			{Name: "Away", Odds: 4.2, Probability: 0.30},
+		},
+		TotalStake: money.FromFloat(1000),
+		Currency:   "$",
 	}
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			calc := &ProportionalCalculator{}
-			input := &types.CalculationInput{
-				Method:     types.MethodProportional,
-				OddsA:      tt.oddsA,
-				OddsB:      tt.oddsB,
-				TotalStake: 1000,
-				NameA:      "A",
-				NameB:      "B",
-				Currency:   "$",
-			}
-
-			result, err := calc.Calculate(input)
+	for _, method := range []types.CalculationMethod{
+		types.MethodArbitrage, types.MethodKelly, types.MethodKellySimultaneous,
+	} {
+		t.Run(string(method), func(t *testing.T) {
+			input.Method = method
+			result, err := NewCalculator(method).Calculate(input)
 			if err != nil {
 				t.Fatalf("Calculate() error: %v", err)
 			}
 
-			if result.OptionA.Stake <= 0 {
-				t.Errorf("OptionA stake should be positive, got %.2f", result.OptionA.Stake)
+			if len(result.Options) != 3 {
+				t.Fatalf("len(Options) = %d, want 3", len(result.Options))
+			}
+
+			var totalAllocated float64
+			for i, opt := range result.Options {
+				if opt.Name != input.Options[i].Name {
+					t.Errorf("Options[%d].Name = %s, want %s", i, opt.Name, input.Options[i].Name)
+				}
+				if opt.Stake < 0 {
+					t.Errorf("Options[%d].Stake = %.2f, should be non-negative", i, opt.Stake.Float64())
+				}
+				totalAllocated += opt.Stake.Float64()
+			}
+			if totalAllocated > input.TotalStake.Float64()*1.01 {
+				t.Errorf("Total allocated (%.2f) exceeds total stake (%s)", totalAllocated, input.TotalStake)
 			}
 
-			if result.OptionB.Stake <= 0 {
-				t.Errorf("OptionB stake should be positive, got %.2f", result.OptionB.Stake)
+			wantEff := 1/2.9 + 1/3.6 + 1/4.2
+			if !floatAlmostEqual(result.Summary.MarketEfficiency, wantEff, 0.0001) {
+				t.Errorf("MarketEfficiency = %.4f, want %.4f", result.Summary.MarketEfficiency, wantEff)
 			}
 		})
 	}
 }
 
+func TestArbitrageCalculator_ThreeWayStakes(t *testing.T) {
+	// Stakes are proportional to 1/odds: 20, 15 and 12 parts of 47, so
+	// every outcome returns 1800.
+	calc := &ArbitrageCalculator{}
+	input := &types.CalculationInput{
+		Method: types.MethodArbitrage,
+		Options: []types.Option{
+			{Name: "A", Odds: 3.0},
+			{Name: "B", Odds: 4.0},
+			{Name: "C", Odds: 5.0},
+		},
+		TotalStake: money.FromFloat(1410),
+	}
+
+	result, err := calc.Calculate(input)
+	if err != nil {
+		t.Fatalf("Calculate() error: %v", err)
+	}
+
+	want := []float64{600, 450, 360}
+	for i, w := range want {
+		if !floatAlmostEqual(result.Options[i].Stake.Float64(), w, 0.01) {
+			t.Errorf("Options[%d].Stake = %.2f, want %.2f", i, result.Options[i].Stake.Float64(), w)
+		}
+	}
+	if !result.Summary.GuaranteedProfit {
+		t.Error("GuaranteedProfit should be true for a 78% book")
+	}
+}
+
+func TestCalculators_StakesAddUpToTotal(t *testing.T) {
+	// Splitting 100.01 three ways leaves cents over; they go to the stakes
+	// with the largest remainders so nothing is lost or created.
+	markets := [][]types.Option{
+		{{Odds: 2.56}, {Odds: 3.85}},
+		{{Odds: 2.1}, {Odds: 3.4}, {Odds: 3.6}},
+		{{Odds: 3.0, Side: types.SideBack}, {Odds: 3.1, Side: types.SideLay, Commission: 0.02}},
+	}
+	methods := []types.CalculationMethod{types.MethodArbitrage, types.MethodProportional}
+
+	for _, options := range markets {
+		for _, method := range methods {
+			for _, total := range []money.Amount{10001, 999999, 1000000, 7} {
+				result, err := NewCalculator(method).Calculate(&types.CalculationInput{
+					Method: method, Options: options, TotalStake: total,
+				})
+				if err != nil {
+					t.Fatalf("%s %s: Calculate() error: %v", method, total, err)
+				}
+				var staked money.Amount
+				for _, opt := range result.Options {
+					capital := opt.Stake
+					if opt.Side == types.SideLay {
+						capital = opt.Liability
+					}
+					staked += capital
+				}
+				if staked != total {
+					t.Errorf("%s over %d options: stakes add up to %s, want %s", method, len(options), staked, total)
+				}
+			}
+		}
+	}
+}
+
+func TestCalculationInput_OutcomesShorthand(t *testing.T) {
+	input := &types.CalculationInput{
+		OddsA: 2.0, OddsB: 3.0, ProbA: 0.5, ProbB: 0.3, NameA: "A", NameB: "B",
+	}
+
+	outcomes := input.Outcomes()
+	if len(outcomes) != 2 {
+		t.Fatalf("len(Outcomes()) = %d, want 2", len(outcomes))
+	}
+	if outcomes[0].Odds != 2.0 || outcomes[1].Probability != 0.3 || outcomes[1].Name != "B" {
+		t.Errorf("Outcomes() = %+v, want shorthand fields", outcomes)
+	}
+}
+
 func TestNewCalculator(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -627,7 +856,9 @@ func TestNewCalculator(t *testing.T) {
 	}{
 		{"arbitrage", types.MethodArbitrage, "*calculator.ArbitrageCalculator"},
 		{"kelly", types.MethodKelly, "*calculator.KellyCalculator"},
-		{"proportional", types.MethodProportional, "*calculator.ProportionalCalculator"},
+		{"kelly-simultaneous", types.MethodKellySimultaneous, "*calculator.SimultaneousKellyCalculator"},
+		{"proportional (retired, runs as arbitrage)", types.MethodProportional, "*calculator.ArbitrageCalculator"},
+		{"matched", types.MethodMatched, "*calculator.MatchedCalculator"},
 		{"unknown (defaults to arbitrage)", "unknown", "*calculator.ArbitrageCalculator"},
 	}
 
@@ -645,8 +876,10 @@ func TestNewCalculator(t *testing.T) {
 				calcType = "*calculator.ArbitrageCalculator"
 			case *KellyCalculator:
 				calcType = "*calculator.KellyCalculator"
-			case *ProportionalCalculator:
-				calcType = "*calculator.ProportionalCalculator"
+			case *SimultaneousKellyCalculator:
+				calcType = "*calculator.SimultaneousKellyCalculator"
+			case *MatchedCalculator:
+				calcType = "*calculator.MatchedCalculator"
 			}
 
 			if calcType != tt.wantType {
@@ -0,0 +1,156 @@
+package calculator
+
+import (
+	"errors"
+	"math"
+
+	"github.com/codehakase/kelly/pkg/money"
+	"github.com/codehakase/kelly/pkg/types"
+)
+
+// EachWayCalculator splits TotalStake equally between a win bet and a place
+// bet on the same selection, the place bet paying PlaceFraction of the win
+// odds. The first option is the selection; when two more are given they are
+// exchange lays of its win and place markets, each sized to match its part
+// of the bet so the position pays the same however the selection finishes.
+// Winnings tax and stake excise are the bookmaker's, charged on the
+// selection; exchange lays pay only commission.
+type EachWayCalculator struct{}
+
+func (c *EachWayCalculator) Calculate(input *types.CalculationInput) (*types.CalculationResult, error) {
+	options := input.Outcomes()
+	if len(options) != 1 && len(options) != 3 {
+		return nil, errors.New("each-way method requires the selection, optionally followed by exchange win and place lay odds")
+	}
+	if input.PlaceFraction <= 0 || input.PlaceFraction > 1 {
+		return nil, errors.New("each-way method requires place terms between 0 and 1")
+	}
+
+	sel := options[0]
+	sel.Side = types.SideBack
+
+	// Both parts are the same stake, so a maximum caps each of them.
+	part := input.TotalStake.Float64() / 2.0
+	var constraint types.Constraint
+	if sel.MaxStake > 0 && part > sel.MaxStake.Float64() {
+		part = sel.MaxStake.Float64()
+		constraint = types.ConstraintBookMax
+	}
+	total := 2.0 * part
+
+	placeOdds := 1.0 + (sel.Odds-1.0)*input.PlaceFraction
+	place := sel
+	place.Odds = placeOdds
+	winReturn, placeReturn := part*sel.EffectiveOdds(), part*place.EffectiveOdds()
+
+	ifWins := winReturn + placeReturn - total
+	ifPlaces := placeReturn - total
+	ifLoses := -total
+
+	legs := []types.Option{
+		{
+			Name: "Win", Odds: sel.Odds, ImpliedProbability: impliedProbability(sel.Odds),
+			Probability: sel.Probability, Side: types.SideBack, Commission: sel.Commission,
+			TaxOnWinnings: sel.TaxOnWinnings, StakeExcise: sel.StakeExcise,
+			MinStake: sel.MinStake, MaxStake: sel.MaxStake, Stake: money.FromFloat(part),
+			BindingConstraint: constraint,
+		},
+		{
+			Name: "Place", Odds: round(placeOdds, 4), ImpliedProbability: impliedProbability(placeOdds),
+			Probability: input.PlaceProbability, Side: types.SideBack, Commission: sel.Commission,
+			TaxOnWinnings: sel.TaxOnWinnings, StakeExcise: sel.StakeExcise,
+			MinStake: sel.MinStake, MaxStake: sel.MaxStake, Stake: money.FromFloat(part),
+			BindingConstraint: constraint,
+		},
+	}
+
+	probWin, probPlace := sel.Probability, input.PlaceProbability
+	if len(options) == 3 {
+		// Each lay matches its part as in matched betting: the win lay
+		// covers the win part's return, the place lay the place part's.
+		var lays [2]float64
+		for i, ret := range []float64{winReturn, placeReturn} {
+			lay := options[i+1]
+			lays[i] = ret / (lay.Odds - lay.Commission)
+		}
+		layWin, layPlace := options[1], options[2]
+		winLiability := lays[0] * (layWin.Odds - 1.0)
+		placeLiability := lays[1] * (layPlace.Odds - 1.0)
+
+		ifWins -= winLiability + placeLiability
+		ifPlaces += lays[0]*(1.0-layWin.Commission) - placeLiability
+		ifLoses += lays[0]*(1.0-layWin.Commission) + lays[1]*(1.0-layPlace.Commission)
+
+		for i, name := range []string{"Lay win", "Lay place"} {
+			lay := options[i+1]
+			legs = append(legs, types.Option{
+				Name: name, Odds: lay.Odds, ImpliedProbability: impliedProbability(lay.Odds),
+				Side: types.SideLay, Commission: lay.Commission,
+				Stake: money.FromFloat(lays[i]), Liability: money.FromFloat(lays[i] * (lay.Odds - 1.0)),
+			})
+		}
+
+		// Without estimates the exchange prices are the fairest guide.
+		if probWin == 0 {
+			probWin = impliedProbability(layWin.Odds)
+		}
+		if probPlace == 0 {
+			probPlace = impliedProbability(layPlace.Odds)
+		}
+	}
+	if probWin == 0 {
+		probWin = impliedProbability(sel.Odds)
+	}
+	if probPlace == 0 {
+		probPlace = impliedProbability(placeOdds)
+	}
+	probPlace = math.Max(probPlace, probWin)
+
+	// Each leg reports the position's profit when that leg pays: the win
+	// leg on a win, the place leg on a place alone, the lays on a loss.
+	outcomes := []float64{ifWins, ifPlaces, ifLoses, ifLoses}
+	for i := range legs {
+		legs[i].ReturnIfWins = money.FromFloat(outcomes[i] + total)
+		legs[i].ProfitIfWins = money.FromFloat(outcomes[i])
+		legs[i].ROI = round(outcomes[i]/total, 4)
+	}
+	if sel.Taxed() {
+		// What tax and excise take from each part when it pays.
+		winTax := part * (sel.Untaxed().EffectiveOdds() - sel.EffectiveOdds())
+		placeTax := part * (place.Untaxed().EffectiveOdds() - place.EffectiveOdds())
+		legs[0].GrossReturn = money.FromFloat(outcomes[0] + total + winTax + placeTax)
+		legs[1].GrossReturn = money.FromFloat(outcomes[1] + total + placeTax)
+	}
+
+	minProfit := math.Min(ifWins, math.Min(ifPlaces, ifLoses))
+	maxProfit := math.Max(ifWins, math.Max(ifPlaces, ifLoses))
+	expected := probWin*ifWins + (probPlace-probWin)*ifPlaces + (1.0-probPlace)*ifLoses
+
+	result := &types.CalculationResult{
+		Method:     types.MethodEachWay,
+		TotalStake: money.FromFloat(total),
+		Currency:   input.Currency,
+		Options:    legs,
+		Summary: types.Summary{
+			GuaranteedProfit: minProfit > 0,
+			MinProfit:        money.FromFloat(minProfit),
+			MaxProfit:        money.FromFloat(maxProfit),
+			ExpectedValue:    money.FromFloat(expected),
+			MinROI:           round(minProfit/total, 4),
+			MaxROI:           round(maxProfit/total, 4),
+			EachWay: &types.EachWayOutcome{
+				Selection:     sel.Name,
+				PlaceFraction: input.PlaceFraction,
+				Places:        input.Places,
+				PlaceOdds:     round(placeOdds, 4),
+				IfWins:        money.FromFloat(ifWins),
+				IfPlaces:      money.FromFloat(ifPlaces),
+				IfLoses:       money.FromFloat(ifLoses),
+			},
+		},
+	}
+	if result.TotalStake != input.TotalStake {
+		result.Summary.RequestedTotal = input.TotalStake
+	}
+	return result, nil
+}
@@ -0,0 +1,154 @@
+package calculator
+
+import (
+	"testing"
+
+	"github.com/codehakase/kelly/pkg/money"
+	"github.com/codehakase/kelly/pkg/types"
+)
+
+func TestEachWayCalculator_Calculate(t *testing.T) {
+	tests := []struct {
+		name          string
+		options       []types.Option
+		fraction      float64
+		placeProb     float64
+		wantPlaceOdds float64
+		wantProfits   [3]float64 // wins, places, loses
+		wantLays      []float64
+		wantEV        float64
+		wantGuarantee bool
+	}{
+		{
+			// 10 each way at 9.0, 1/5 odds: place odds 2.6.
+			// EV = 0.1 × 96 + 0.25 × 6 - 0.65 × 20
+			name:          "bookmaker only",
+			options:       []types.Option{{Name: "Frankel", Odds: 9.0, Probability: 0.1}},
+			fraction:      0.2,
+			placeProb:     0.35,
+			wantPlaceOdds: 2.6,
+			wantProfits:   [3]float64{96, 6, -20},
+			wantEV:        -1.9,
+		},
+		{
+			// Lays of 120 / 11.5 = 10.43 and 37.5 / 3.2 = 11.72 return
+			// 22.15 on a loss, and the same on a win or a place.
+			name: "each-way arbitrage",
+			options: []types.Option{
+				{Name: "Frankel", Odds: 12.0},
+				{Odds: 11.5},
+				{Odds: 3.2},
+			},
+			fraction:      0.25,
+			wantPlaceOdds: 3.75,
+			wantProfits:   [3]float64{2.15, 2.15, 2.15},
+			wantLays:      []float64{10.43, 11.72},
+			wantEV:        2.15,
+			wantGuarantee: true,
+		},
+		{
+			// 90 / 9.38 = 9.59 and 26 / 2.58 = 10.08 lock in a small loss.
+			name: "matched with commission",
+			options: []types.Option{
+				{Name: "Frankel", Odds: 9.0},
+				{Odds: 9.4, Commission: 0.02},
+				{Odds: 2.6, Commission: 0.02},
+			},
+			fraction:      0.2,
+			wantPlaceOdds: 2.6,
+			wantProfits:   [3]float64{-0.72, -0.72, -0.72},
+			wantLays:      []float64{9.59, 10.08},
+			wantEV:        -0.72,
+		},
+	}
+
+	calc := &EachWayCalculator{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := calc.Calculate(&types.CalculationInput{
+				Method: types.MethodEachWay, Options: tt.options, TotalStake: money.FromFloat(20),
+				PlaceFraction: tt.fraction, Places: 4, PlaceProbability: tt.placeProb,
+			})
+			if err != nil {
+				t.Fatalf("Calculate() error: %v", err)
+			}
+
+			ew := result.Summary.EachWay
+			if ew == nil {
+				t.Fatal("Summary.EachWay should be set")
+			}
+			if ew.PlaceOdds != tt.wantPlaceOdds || ew.Places != 4 || ew.Selection != "Frankel" {
+				t.Errorf("EachWay = %+v, want place odds %.2f, 4 places", ew, tt.wantPlaceOdds)
+			}
+			for i, got := range []float64{ew.IfWins.Float64(), ew.IfPlaces.Float64(), ew.IfLoses.Float64()} {
+				if !floatAlmostEqual(got, tt.wantProfits[i], 0.01) {
+					t.Errorf("profits = %.2f/%.2f/%.2f, want %v", ew.IfWins.Float64(), ew.IfPlaces.Float64(), ew.IfLoses.Float64(), tt.wantProfits)
+					break
+				}
+			}
+
+			if len(result.Options) != 2+len(tt.wantLays) {
+				t.Fatalf("got %d legs, want %d", len(result.Options), 2+len(tt.wantLays))
+			}
+			for _, leg := range result.Options[:2] {
+				if leg.Stake != money.FromFloat(10) {
+					t.Errorf("%s stake = %.2f, want 10", leg.Name, leg.Stake.Float64())
+				}
+			}
+			for i, want := range tt.wantLays {
+				lay := result.Options[2+i]
+				if lay.Side != types.SideLay || !floatAlmostEqual(lay.Stake.Float64(), want, 0.01) {
+					t.Errorf("%s = %s %.2f, want lay %.2f", lay.Name, lay.Side, lay.Stake.Float64(), want)
+				}
+			}
+
+			if !floatAlmostEqual(result.Summary.ExpectedValue.Float64(), tt.wantEV, 0.01) {
+				t.Errorf("ExpectedValue = %.2f, want %.2f", result.Summary.ExpectedValue.Float64(), tt.wantEV)
+			}
+			if result.Summary.GuaranteedProfit != tt.wantGuarantee {
+				t.Errorf("GuaranteedProfit = %v, want %v", result.Summary.GuaranteedProfit, tt.wantGuarantee)
+			}
+		})
+	}
+}
+
+func TestEachWayCalculator_MaxStake(t *testing.T) {
+	result, err := (&EachWayCalculator{}).Calculate(&types.CalculationInput{
+		Method:        types.MethodEachWay,
+		Options:       []types.Option{{Odds: 9.0, MaxStake: money.FromFloat(5)}},
+		TotalStake:    money.FromFloat(20),
+		PlaceFraction: 0.2, Places: 4,
+	})
+	if err != nil {
+		t.Fatalf("Calculate() error: %v", err)
+	}
+	if result.TotalStake != money.FromFloat(10) || result.Summary.RequestedTotal != money.FromFloat(20) {
+		t.Errorf("TotalStake = %.2f (requested %.2f), want 10 of 20", result.TotalStake.Float64(), result.Summary.RequestedTotal.Float64())
+	}
+	if win := result.Options[0]; win.Stake != money.FromFloat(5) || win.BindingConstraint != types.ConstraintBookMax {
+		t.Errorf("win leg = %.2f (%q), want 5 capped at book-max", win.Stake.Float64(), win.BindingConstraint)
+	}
+}
+
+func TestEachWayCalculator_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		options  []types.Option
+		fraction float64
+	}{
+		{"two options", []types.Option{{Odds: 9.0}, {Odds: 9.4}}, 0.2},
+		{"no place terms", []types.Option{{Odds: 9.0}}, 0},
+		{"place terms above one", []types.Option{{Odds: 9.0}}, 1.5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := (&EachWayCalculator{}).Calculate(&types.CalculationInput{
+				Method: types.MethodEachWay, Options: tt.options, TotalStake: money.FromFloat(20), PlaceFraction: tt.fraction,
+			})
+			if err == nil {
+				t.Error("Calculate() expected an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,177 @@
+package calculator
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/codehakase/kelly/internal/fx"
+	"github.com/codehakase/kelly/pkg/money"
+	"github.com/codehakase/kelly/pkg/types"
+)
+
+// fxArbitrage solves an arbitrage across books in different currencies.
+// Each option's odds are first turned into odds in the base currency: its
+// stake is bought at the buy rate and its return sold back at the sell
+// rate, so the spread comes out of the odds. The base total is then split
+// in inverse proportion to those odds, so every outcome returns the same in
+// the base currency, and each share bought in the book's currency, to the
+// cent.
+//
+// Stake limits and rounding apply in each book's own currency: a leg over
+// its maximum scales the whole position down, and stakes are rounded to
+// multiples of RoundTo in their book's currency, searching for the
+// combination with the best guaranteed ROI in the base currency.
+func fxArbitrage(input *types.CalculationInput) (*types.CalculationResult, error) {
+	options := input.Outcomes()
+	if hasLay(options) {
+		return nil, errors.New("arbitrage across currencies supports back bets only")
+	}
+	net, err := netOptions(options)
+	if err != nil {
+		return nil, err
+	}
+
+	base := fx.Code(input.BaseCurrency)
+	if base == "" {
+		base = input.Rates.Base
+	}
+	currencies := make([]string, len(options))
+	rates := make([]types.FXRate, len(options))
+	used := make(map[string]types.FXRate)
+	weights := make([]float64, len(options))
+	prices := make([]float64, len(options))
+	baseOptions := make([]types.Option, len(options))
+	for i, opt := range net {
+		currencies[i] = fx.Code(opt.Currency)
+		if currencies[i] == "" {
+			currencies[i] = base
+		}
+		if rates[i], err = fx.Rate(input.Rates, currencies[i], base); err != nil {
+			return nil, err
+		}
+		used[currencies[i]] = rates[i]
+
+		baseOdds := opt.Odds * rates[i].Sell / rates[i].Buy
+		if baseOdds <= 1.0 {
+			return nil, fmt.Errorf("%s: odds of %.2f return less than the stake once converted to %s",
+				types.OptionLabel(i), options[i].Odds, base)
+		}
+		weights[i] = 1.0 / baseOdds
+		prices[i] = rates[i].Buy
+		baseOptions[i] = types.Option{Odds: baseOdds, MinStake: opt.MinStake, MaxStake: opt.MaxStake}
+	}
+
+	capital := money.Split(input.TotalStake, weights)
+	stakes := make([]float64, len(options))
+	for i := range stakes {
+		stakes[i] = money.Round(capital[i] / rates[i].Buy)
+	}
+	limited, stakes, constraints, err := withLimits(input, options, stakes, true)
+	if err != nil {
+		return nil, err
+	}
+
+	mids := make([]types.FXRate, len(rates))
+	for i, r := range rates {
+		mids[i] = types.FXRate{Buy: r.Mid(), Sell: r.Mid()}
+	}
+	build := func(stakes []float64) *types.CalculationResult {
+		return fxResult(input, options, net, stakes, currencies, rates, mids, base, used)
+	}
+
+	result := build(stakes)
+	if limited != input {
+		// withLimits sums the stakes, which are in different currencies;
+		// what the position costs in the base currency is the total.
+		limited.TotalStake = result.TotalStake
+	}
+	if input.Rounding() {
+		exact := result
+		result = build(searchStakes(limited, baseOptions, stakes, prices))
+		result.Summary.RoundTo = input.RoundTo
+		result.Summary.RoundMode = roundMode(input)
+		result.Summary.RoundingCost = exact.Summary.MinProfit - result.Summary.MinProfit
+	}
+	return markLimits(result, input, limited, constraints), nil
+}
+
+// fxResult builds the result for stakes in each option's currency, with
+// profits in the base currency at the given rates and the spread measured
+// against mids.
+func fxResult(input *types.CalculationInput, options, net []types.Option, stakes []float64,
+	currencies []string, rates, mids []types.FXRate, base string, used map[string]types.FXRate) *types.CalculationResult {
+
+	spent, profits := fxProfits(net, stakes, rates)
+	_, midProfits := fxProfits(net, stakes, mids)
+
+	result := &types.CalculationResult{
+		Method:     types.MethodArbitrage,
+		TotalStake: money.FromFloat(spent),
+		Currency:   input.Currency,
+		Options:    make([]types.Option, len(options)),
+	}
+	minProfit, maxProfit := math.Inf(1), math.Inf(-1)
+	var efficiency, sum float64
+	for i, opt := range options {
+		minProfit = math.Min(minProfit, profits[i])
+		maxProfit = math.Max(maxProfit, profits[i])
+		efficiency += rates[i].Buy / (net[i].Odds * rates[i].Sell)
+		sum += profits[i]
+
+		result.Options[i] = types.Option{
+			Name:               opt.Name,
+			Bookmaker:          opt.Bookmaker,
+			Currency:           currencies[i],
+			Odds:               opt.Odds,
+			ImpliedProbability: impliedProbability(opt.Odds),
+			SharpOdds:          opt.SharpOdds,
+			Side:               opt.Side,
+			Commission:         opt.Commission,
+			TaxOnWinnings:      opt.TaxOnWinnings,
+			StakeExcise:        opt.StakeExcise,
+			MinStake:           opt.MinStake,
+			MaxStake:           opt.MaxStake,
+			Stake:              money.FromFloat(stakes[i]),
+			ReturnIfWins:       money.FromFloat(stakes[i] * net[i].Odds),
+			GrossReturn:        grossReturn(opt, stakes[i]),
+			ProfitIfWins:       money.FromFloat(profits[i]),
+			ROI:                round(profits[i]/spent, 4),
+		}
+	}
+
+	minMid := math.Inf(1)
+	for _, p := range midProfits {
+		minMid = math.Min(minMid, p)
+	}
+	result.Summary = types.Summary{
+		GuaranteedProfit: minProfit > 0,
+		MinProfit:        money.FromFloat(minProfit),
+		MaxProfit:        money.FromFloat(maxProfit),
+		ExpectedValue:    money.FromFloat(sum / float64(len(options))),
+		MinROI:           round(minProfit/spent, 4),
+		MaxROI:           round(maxProfit/spent, 4),
+		MarketEfficiency: round(efficiency, 4),
+		FX: &types.FXSummary{
+			Base:       base,
+			Rates:      used,
+			SpreadCost: money.FromFloat(minMid - minProfit),
+		},
+	}
+	return result
+}
+
+// fxProfits returns what stakes in each option's currency cost in the base
+// currency, and the position's profit in it when each option wins, buying
+// stakes and selling returns at the given rates.
+func fxProfits(net []types.Option, stakes []float64, rates []types.FXRate) (float64, []float64) {
+	var spent float64
+	for i, s := range stakes {
+		spent += s * rates[i].Buy
+	}
+	profits := make([]float64, len(stakes))
+	for i, s := range stakes {
+		profits[i] = s*net[i].Odds*rates[i].Sell - spent
+	}
+	return spent, profits
+}
@@ -0,0 +1,235 @@
+package calculator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/codehakase/kelly/pkg/money"
+	"github.com/codehakase/kelly/pkg/types"
+)
+
+func fxRates() *types.RateTable {
+	return &types.RateTable{Base: "NGN", Rates: map[string]types.FXRate{
+		"USD": {Buy: 1550, Sell: 1500},
+		"GBP": {Buy: 1950, Sell: 1950},
+	}}
+}
+
+func TestArbitrageCalculator_Currencies(t *testing.T) {
+	// A USD book at 2.15 pays 2.15 × 1500 / 1550 = 2.0806 in naira. The
+	// total is split so both outcomes return the same in naira.
+	result, err := (&ArbitrageCalculator{}).Calculate(&types.CalculationInput{
+		Method: types.MethodArbitrage,
+		Options: []types.Option{
+			{Name: "Home", Odds: 2.10, Currency: "NGN"},
+			{Name: "Away", Odds: 2.15, Currency: "USD"},
+		},
+		TotalStake: money.FromFloat(100000),
+		Rates:      fxRates(),
+	})
+	if err != nil {
+		t.Fatalf("Calculate() error: %v", err)
+	}
+
+	home, away := result.Options[0], result.Options[1]
+	if home.Currency != "NGN" || away.Currency != "USD" {
+		t.Errorf("currencies = %s/%s, want NGN/USD", home.Currency, away.Currency)
+	}
+	if !floatAlmostEqual(home.Stake.Float64(), 49769, 1) || !floatAlmostEqual(away.Stake.Float64(), 32.41, 0.01) {
+		t.Errorf("stakes = ₦%.2f / $%.2f, want ₦49769 / $32.41", home.Stake.Float64(), away.Stake.Float64())
+	}
+	if !floatAlmostEqual(away.ReturnIfWins.Float64(), 69.68, 0.01) {
+		t.Errorf("away return = $%.2f, want $69.68 paid by the book", away.ReturnIfWins.Float64())
+	}
+	// Both profits are in naira and match to within a cent of a dollar.
+	if !floatAlmostEqual(home.ProfitIfWins.Float64(), away.ProfitIfWins.Float64(), 15) || !result.Summary.GuaranteedProfit {
+		t.Errorf("profits = ₦%.2f / ₦%.2f, want equal and positive", home.ProfitIfWins.Float64(), away.ProfitIfWins.Float64())
+	}
+
+	fx := result.Summary.FX
+	if fx == nil || fx.Base != "NGN" || fx.Rates["USD"].Buy != 1550 {
+		t.Fatalf("FX = %+v, want NGN base with the USD rate", fx)
+	}
+	// At the mid rate of 1525 the worst result, a home win, would save
+	// the 25 a dollar paid over it to buy $32.41.
+	if !floatAlmostEqual(fx.SpreadCost.Float64(), 810.25, 0.01) {
+		t.Errorf("SpreadCost = %.2f, want 810.25", fx.SpreadCost.Float64())
+	}
+}
+
+func TestArbitrageCalculator_CurrenciesWithoutSpread(t *testing.T) {
+	// With no spread converting changes nothing: a GBP book at 2.1 is a
+	// naira book at 2.1.
+	options := []types.Option{{Odds: 2.1, Currency: "GBP"}, {Odds: 2.0}}
+	withFX, err := (&ArbitrageCalculator{}).Calculate(&types.CalculationInput{
+		Method: types.MethodArbitrage, Options: options, TotalStake: money.FromFloat(19500), Rates: fxRates(),
+	})
+	if err != nil {
+		t.Fatalf("Calculate() error: %v", err)
+	}
+	if withFX.Summary.FX.SpreadCost != 0 {
+		t.Errorf("SpreadCost = %.2f, want 0 without a spread", withFX.Summary.FX.SpreadCost.Float64())
+	}
+	if withFX.Options[1].Currency != "NGN" {
+		t.Errorf("option without a currency is in %q, want the base NGN", withFX.Options[1].Currency)
+	}
+	// 19500 × (1/2.1) / (1/2.1 + 1/2) in pounds: 9512.20 / 1950.
+	if !floatAlmostEqual(withFX.Options[0].Stake.Float64(), 4.88, 0.01) {
+		t.Errorf("GBP stake = £%.2f, want £4.88", withFX.Options[0].Stake.Float64())
+	}
+}
+
+func TestArbitrageCalculator_IdentityRates(t *testing.T) {
+	// At rates of one, arbitrage across currencies is plain arbitrage.
+	rates := &types.RateTable{Base: "NGN", Rates: map[string]types.FXRate{"USD": {Buy: 1, Sell: 1}}}
+	markets := [][]types.Option{
+		{{Odds: 2.56}, {Odds: 3.85}},
+		{{Odds: 3.0}, {Odds: 4.0}, {Odds: 5.0}},
+		{{Odds: 2.6, TaxOnWinnings: 0.2, StakeExcise: 0.125}, {Odds: 2.55, TaxOnWinnings: 0.2}},
+	}
+
+	for _, options := range markets {
+		plain, err := (&ArbitrageCalculator{}).Calculate(&types.CalculationInput{
+			Method: types.MethodArbitrage, Options: options, TotalStake: money.FromFloat(10000),
+		})
+		if err != nil {
+			t.Fatalf("Calculate() error: %v", err)
+		}
+		converted := append([]types.Option(nil), options...)
+		converted[0].Currency = "USD"
+		withFX, err := (&ArbitrageCalculator{}).Calculate(&types.CalculationInput{
+			Method: types.MethodArbitrage, Options: converted, TotalStake: money.FromFloat(10000), Rates: rates,
+		})
+		if err != nil {
+			t.Fatalf("Calculate() error: %v", err)
+		}
+
+		for i := range options {
+			p, f := plain.Options[i], withFX.Options[i]
+			if p.Stake != f.Stake || p.ProfitIfWins != f.ProfitIfWins {
+				t.Errorf("odds %v option %d: stake %.2f / profit %.2f with rates, want %.2f / %.2f",
+					options[i].Odds, i, f.Stake.Float64(), f.ProfitIfWins.Float64(), p.Stake.Float64(), p.ProfitIfWins.Float64())
+			}
+		}
+		if plain.Summary.MinProfit != withFX.Summary.MinProfit {
+			t.Errorf("MinProfit = %.2f with rates, want %.2f", withFX.Summary.MinProfit.Float64(), plain.Summary.MinProfit.Float64())
+		}
+	}
+}
+
+func TestArbitrageCalculator_CurrencyBase(t *testing.T) {
+	result, err := (&ArbitrageCalculator{}).Calculate(&types.CalculationInput{
+		Method:       types.MethodArbitrage,
+		Options:      []types.Option{{Odds: 2.1, Currency: "GBP"}, {Odds: 2.25, Currency: "USD"}},
+		TotalStake:   money.FromFloat(100),
+		Rates:        fxRates(),
+		BaseCurrency: "usd",
+	})
+	if err != nil {
+		t.Fatalf("Calculate() error: %v", err)
+	}
+	if result.Summary.FX.Base != "USD" || result.Summary.FX.Rates["USD"].Buy != 1 {
+		t.Errorf("FX = %+v, want a USD base", result.Summary.FX)
+	}
+	// USD stakes are bought one for one in a USD base.
+	var spent float64
+	for _, opt := range result.Options {
+		spent += opt.Stake.Float64() * result.Summary.FX.Rates[opt.Currency].Buy
+	}
+	if !floatAlmostEqual(spent, result.TotalStake.Float64(), 0.01) || !floatAlmostEqual(result.TotalStake.Float64(), 100, 0.02) {
+		t.Errorf("stakes cost $%.2f of a $%.2f total, want about $100", spent, result.TotalStake.Float64())
+	}
+}
+
+func TestArbitrageCalculator_CurrencyLimits(t *testing.T) {
+	// The USD book's $20 maximum is in dollars: the $32.41 it would take
+	// scales the whole position down to fit.
+	input := &types.CalculationInput{
+		Method: types.MethodArbitrage,
+		Options: []types.Option{
+			{Name: "Home", Odds: 2.10, Currency: "NGN"},
+			{Name: "Away", Odds: 2.15, Currency: "USD", MaxStake: money.FromFloat(20)},
+		},
+		TotalStake: money.FromFloat(100000),
+		Rates:      fxRates(),
+	}
+	result, err := (&ArbitrageCalculator{}).Calculate(input)
+	if err != nil {
+		t.Fatalf("Calculate() error: %v", err)
+	}
+
+	home, away := result.Options[0], result.Options[1]
+	if away.Stake != money.FromFloat(20) || away.BindingConstraint != types.ConstraintBookMax {
+		t.Errorf("away = $%s (%s), want $20.00 capped at its maximum", away.Stake, away.BindingConstraint)
+	}
+	if !floatAlmostEqual(home.ProfitIfWins.Float64(), away.ProfitIfWins.Float64(), 15) {
+		t.Errorf("profits = ₦%.2f / ₦%.2f, want equal after scaling", home.ProfitIfWins.Float64(), away.ProfitIfWins.Float64())
+	}
+	// The total is in naira: ₦31000 buys the $20.
+	if want := home.Stake.Float64() + 31000; !floatAlmostEqual(result.TotalStake.Float64(), want, 0.01) {
+		t.Errorf("TotalStake = ₦%.2f, want ₦%.2f", result.TotalStake.Float64(), want)
+	}
+	if result.Summary.RequestedTotal != input.TotalStake {
+		t.Errorf("RequestedTotal = %s, want %s", result.Summary.RequestedTotal, input.TotalStake)
+	}
+
+	input.Options[1].MaxStake = 0
+	input.Options[1].MinStake = money.FromFloat(50)
+	if _, err := (&ArbitrageCalculator{}).Calculate(input); err == nil || !strings.Contains(err.Error(), "below its minimum") {
+		t.Errorf("Calculate() error = %v, want the $32.41 stake below its $50 minimum", err)
+	}
+}
+
+func TestArbitrageCalculator_CurrencyRounding(t *testing.T) {
+	// Stakes are rounded to whole units in their own currency, the dollar
+	// stake to whole dollars rather than whole naira.
+	input := &types.CalculationInput{
+		Method:     types.MethodArbitrage,
+		Options:    []types.Option{{Odds: 2.10, Currency: "NGN"}, {Odds: 2.15, Currency: "USD"}},
+		TotalStake: money.FromFloat(100000),
+		Rates:      fxRates(),
+		RoundTo:    1,
+	}
+	exact, _ := (&ArbitrageCalculator{}).Calculate(&types.CalculationInput{
+		Method: input.Method, Options: input.Options, TotalStake: input.TotalStake, Rates: input.Rates,
+	})
+	result, err := (&ArbitrageCalculator{}).Calculate(input)
+	if err != nil {
+		t.Fatalf("Calculate() error: %v", err)
+	}
+
+	for _, opt := range result.Options {
+		if !isMultiple(opt.Stake.Float64(), 1) {
+			t.Errorf("%s stake %s should be a whole number of %s", opt.Currency, opt.Stake, opt.Currency)
+		}
+	}
+	if !result.Summary.GuaranteedProfit {
+		t.Error("rounded stakes should keep the guaranteed profit")
+	}
+	wantCost := exact.Summary.MinProfit - result.Summary.MinProfit
+	if result.Summary.RoundTo != 1 || result.Summary.RoundingCost != wantCost {
+		t.Errorf("rounding = %v costing %s, want 1 costing %s", result.Summary.RoundTo, result.Summary.RoundingCost, wantCost)
+	}
+}
+
+func TestArbitrageCalculator_CurrencyErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		options []types.Option
+	}{
+		{"unknown currency", []types.Option{{Odds: 2.1, Currency: "JPY"}, {Odds: 2.1}}},
+		{"lay option", []types.Option{{Odds: 2.1, Currency: "USD"}, {Odds: 2.1, Side: types.SideLay}}},
+		{"spread eats the odds", []types.Option{{Odds: 1.02, Currency: "USD"}, {Odds: 60}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := (&ArbitrageCalculator{}).Calculate(&types.CalculationInput{
+				Method: types.MethodArbitrage, Options: tt.options, TotalStake: money.FromFloat(1000), Rates: fxRates(),
+			})
+			if err == nil {
+				t.Error("Calculate() expected an error")
+			}
+		})
+	}
+}
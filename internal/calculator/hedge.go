@@ -0,0 +1,64 @@
+package calculator
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/codehakase/kelly/pkg/money"
+	"github.com/codehakase/kelly/pkg/types"
+)
+
+// HedgeCalculator sizes a bet against an open position. The first option is
+// the position held, with TotalStake its stake, and the second the bet
+// available now: a back of the opposing outcome or a lay of the same
+// selection (or a back of it when the position is a lay).
+type HedgeCalculator struct{}
+
+func (c *HedgeCalculator) Calculate(input *types.CalculationInput) (*types.CalculationResult, error) {
+	options := input.Outcomes()
+	if len(options) != 2 {
+		return nil, errors.New("hedge method requires the position held and the hedge bet")
+	}
+	held, hedge := options[0], options[1]
+	if held.Side == types.SideLay && hedge.Side == types.SideLay {
+		return nil, errors.New("a lay position must be hedged with a back bet")
+	}
+
+	// Capital is what each bet risks: the stake of a back, the liability of
+	// a lay. A result's profit is its return less all of the capital.
+	position := input.TotalStake.Float64()
+	if held.Side == types.SideLay {
+		position *= held.Odds - 1.0
+	}
+	heldOdds, hedgeOdds := held.EffectiveOdds(), hedge.EffectiveOdds()
+
+	target := input.HedgeTarget
+	if target == "" {
+		target = types.HedgeEqual
+	}
+	var capital float64
+	switch target {
+	case types.HedgeEqual:
+		capital = position * heldOdds / hedgeOdds
+	case types.HedgeFreeBet:
+		capital = position / (hedgeOdds - 1.0)
+	case types.HedgeBreakEven:
+		capital = position * (heldOdds - 1.0)
+	default:
+		return nil, fmt.Errorf("invalid hedge target: %s", target)
+	}
+
+	stakes := []float64{money.Round(position), money.Round(capital)}
+	_, stakes, constraints, err := withLimits(input, options, stakes, false)
+	if err != nil {
+		return nil, err
+	}
+
+	committed := *input
+	committed.TotalStake = money.Sum(stakes...)
+	result := newResult(types.MethodHedge, &committed, options, stakes)
+	result.Summary.GuaranteedProfit = result.Summary.MinProfit > 0
+	result.Summary.ExpectedValue = money.FromFloat(meanProfit(options, stakes, committed.TotalStake))
+	result.Summary.HedgeTarget = target
+	return markLimits(result, &committed, &committed, constraints), nil
+}
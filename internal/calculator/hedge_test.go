@@ -0,0 +1,185 @@
+package calculator
+
+import (
+	"testing"
+
+	"github.com/codehakase/kelly/pkg/money"
+	"github.com/codehakase/kelly/pkg/types"
+)
+
+func TestHedgeCalculator_Calculate(t *testing.T) {
+	tests := []struct {
+		name          string
+		target        types.HedgeTarget
+		held, hedge   types.Option
+		stake         float64
+		wantStake     float64
+		wantLiability float64
+		wantProfits   [2]float64
+		wantGuarantee bool
+	}{
+		{
+			// 100 × 3.0 / 2.0 = 150; both results return 300 of 250.
+			name:          "equal profit with a back",
+			target:        types.HedgeEqual,
+			held:          types.Option{Odds: 3.0},
+			hedge:         types.Option{Odds: 2.0},
+			stake:         100,
+			wantStake:     150,
+			wantProfits:   [2]float64{50, 50},
+			wantGuarantee: true,
+		},
+		{
+			name:          "default target is equal",
+			held:          types.Option{Odds: 3.0},
+			hedge:         types.Option{Odds: 2.0},
+			stake:         100,
+			wantStake:     150,
+			wantProfits:   [2]float64{50, 50},
+			wantGuarantee: true,
+		},
+		{
+			// 100 / (2.0 - 1) = 100 recovers the stake if the hedge wins.
+			name:        "free bet on the position",
+			target:      types.HedgeFreeBet,
+			held:        types.Option{Odds: 3.0},
+			hedge:       types.Option{Odds: 2.0},
+			stake:       100,
+			wantStake:   100,
+			wantProfits: [2]float64{100, 0},
+		},
+		{
+			// 100 × (3.0 - 1) = 200 so the position winning breaks even.
+			name:        "break even on the position",
+			target:      types.HedgeBreakEven,
+			held:        types.Option{Odds: 3.0},
+			hedge:       types.Option{Odds: 2.0},
+			stake:       100,
+			wantStake:   200,
+			wantProfits: [2]float64{0, 100},
+		},
+		{
+			// Lay 100 × 5.0 / (3.0 - 0.02) = 167.79, liability 335.57.
+			name:          "equal profit with an exchange lay",
+			target:        types.HedgeEqual,
+			held:          types.Option{Odds: 5.0},
+			hedge:         types.Option{Odds: 3.0, Side: types.SideLay, Commission: 0.02},
+			stake:         100,
+			wantStake:     167.79,
+			wantLiability: 335.57,
+			wantProfits:   [2]float64{64.43, 64.43},
+			wantGuarantee: true,
+		},
+		{
+			// The position drifted: 100 × 2.0 / 1.5 = 133.33 caps the loss.
+			name:        "cutting a loss",
+			target:      types.HedgeEqual,
+			held:        types.Option{Odds: 2.0},
+			hedge:       types.Option{Odds: 1.5},
+			stake:       100,
+			wantStake:   133.33,
+			wantProfits: [2]float64{-33.33, -33.33},
+		},
+	}
+
+	calc := &HedgeCalculator{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := calc.Calculate(&types.CalculationInput{
+				Method: types.MethodHedge, Options: []types.Option{tt.held, tt.hedge},
+				TotalStake: money.FromFloat(tt.stake), HedgeTarget: tt.target,
+			})
+			if err != nil {
+				t.Fatalf("Calculate() error: %v", err)
+			}
+
+			hedge := result.Options[1]
+			if !floatAlmostEqual(hedge.Stake.Float64(), tt.wantStake, 0.01) {
+				t.Errorf("hedge stake = %.2f, want %.2f", hedge.Stake.Float64(), tt.wantStake)
+			}
+			if !floatAlmostEqual(hedge.Liability.Float64(), tt.wantLiability, 0.01) {
+				t.Errorf("hedge liability = %.2f, want %.2f", hedge.Liability.Float64(), tt.wantLiability)
+			}
+			for i, want := range tt.wantProfits {
+				if !floatAlmostEqual(result.Options[i].ProfitIfWins.Float64(), want, 0.02) {
+					t.Errorf("%s profit = %.2f, want %.2f", types.OptionLabel(i), result.Options[i].ProfitIfWins.Float64(), want)
+				}
+			}
+			if result.Options[0].Stake.Float64() != tt.stake {
+				t.Errorf("position stake = %.2f, want %.2f", result.Options[0].Stake.Float64(), tt.stake)
+			}
+			if result.Summary.GuaranteedProfit != tt.wantGuarantee {
+				t.Errorf("GuaranteedProfit = %v, want %v", result.Summary.GuaranteedProfit, tt.wantGuarantee)
+			}
+			if result.Summary.HedgeTarget == "" || result.Method != types.MethodHedge {
+				t.Errorf("result should record the hedge method and target, got %s / %q", result.Method, result.Summary.HedgeTarget)
+			}
+		})
+	}
+}
+
+func TestHedgeCalculator_LayPosition(t *testing.T) {
+	// Laid 100 at 4.0 (liability 300); backing at 2.5 for 300 × 1.49 / 2.5
+	// pays the same either way.
+	result, err := (&HedgeCalculator{}).Calculate(&types.CalculationInput{
+		Method: types.MethodHedge,
+		Options: []types.Option{
+			{Odds: 4.0, Side: types.SideLay, Commission: 0.02},
+			{Odds: 2.5},
+		},
+		TotalStake: money.FromFloat(100),
+	})
+	if err != nil {
+		t.Fatalf("Calculate() error: %v", err)
+	}
+	if pos := result.Options[0]; pos.Stake != money.FromFloat(100) || pos.Liability != money.FromFloat(300) {
+		t.Errorf("position = stake %.2f, liability %.2f, want 100 and 300", pos.Stake.Float64(), pos.Liability.Float64())
+	}
+	if !floatAlmostEqual(result.Options[1].Stake.Float64(), 300*(1+0.98/3)/2.5, 0.01) {
+		t.Errorf("hedge stake = %.2f", result.Options[1].Stake.Float64())
+	}
+	if !floatAlmostEqual(result.Summary.MinProfit.Float64(), result.Summary.MaxProfit.Float64(), 0.02) {
+		t.Errorf("profits %.2f and %.2f should be equal", result.Summary.MinProfit.Float64(), result.Summary.MaxProfit.Float64())
+	}
+}
+
+func TestHedgeCalculator_MaxStake(t *testing.T) {
+	result, err := (&HedgeCalculator{}).Calculate(&types.CalculationInput{
+		Method:     types.MethodHedge,
+		Options:    []types.Option{{Odds: 3.0}, {Odds: 2.0, MaxStake: money.FromFloat(120)}},
+		TotalStake: money.FromFloat(100),
+	})
+	if err != nil {
+		t.Fatalf("Calculate() error: %v", err)
+	}
+	hedge := result.Options[1]
+	if hedge.Stake != money.FromFloat(120) || hedge.BindingConstraint != types.ConstraintBookMax {
+		t.Errorf("hedge = %.2f (%q), want a partial hedge of 120", hedge.Stake.Float64(), hedge.BindingConstraint)
+	}
+	if result.TotalStake != money.FromFloat(220) {
+		t.Errorf("TotalStake = %.2f, want 220", result.TotalStake.Float64())
+	}
+}
+
+func TestHedgeCalculator_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		options []types.Option
+		target  types.HedgeTarget
+	}{
+		{"three options", []types.Option{{Odds: 2}, {Odds: 3}, {Odds: 4}}, ""},
+		{"lay against lay", []types.Option{{Odds: 2, Side: types.SideLay}, {Odds: 3, Side: types.SideLay}}, ""},
+		{"unknown target", []types.Option{{Odds: 2}, {Odds: 3}}, "all-in"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := (&HedgeCalculator{}).Calculate(&types.CalculationInput{
+				Method: types.MethodHedge, Options: tt.options, TotalStake: money.FromFloat(100), HedgeTarget: tt.target,
+			})
+			if err == nil {
+				t.Error("Calculate() expected an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package calculator
+
+import (
+	"fmt"
+
+	"github.com/codehakase/kelly/pkg/money"
+	"github.com/codehakase/kelly/pkg/types"
+)
+
+// capitalLimits returns the least and most capital an option may take: its
+// MinStake and MaxStake, or for a lay the liabilities those backer's stakes
+// imply. Zero means no limit.
+func capitalLimits(opt types.Option) (lo, hi float64) {
+	lo, hi = opt.MinStake.Float64(), opt.MaxStake.Float64()
+	if opt.Side == types.SideLay {
+		lo *= opt.Odds - 1.0
+		hi *= opt.Odds - 1.0
+	}
+	return lo, hi
+}
+
+// withLimits applies the options' bookmaker stake limits to stakes, which
+// hold the capital committed to each option.
+//
+// When allocate is set the stakes are a fixed split of the total, so a leg
+// over its maximum scales the whole position down until it fits and the
+// returned input carries the reduced total. A leg below its minimum cannot
+// be raised without breaking the split and is an error. Otherwise each
+// stake is sized on its own: it is capped at its maximum, or dropped when
+// it falls below its minimum, and the total is left alone.
+//
+// The returned constraints name the limit that bound each option.
+func withLimits(input *types.CalculationInput, options []types.Option, stakes []float64,
+	allocate bool) (*types.CalculationInput, []float64, []types.Constraint, error) {
+
+	limited := append([]float64(nil), stakes...)
+	constraints := make([]types.Constraint, len(options))
+
+	if !allocate {
+		for i, opt := range options {
+			lo, hi := capitalLimits(opt)
+			switch {
+			case hi > 0 && limited[i] > hi:
+				limited[i] = money.Round(hi)
+				constraints[i] = types.ConstraintBookMax
+			case lo > 0 && limited[i] > 0 && limited[i] < lo:
+				limited[i] = 0
+				constraints[i] = types.ConstraintBookMin
+			}
+		}
+		return input, limited, constraints, nil
+	}
+
+	scale, binding := 1.0, -1
+	for i, opt := range options {
+		if _, hi := capitalLimits(opt); hi > 0 && stakes[i] > hi && hi/stakes[i] < scale {
+			scale, binding = hi/stakes[i], i
+		}
+	}
+
+	scaled := input
+	if binding >= 0 {
+		scaled = new(types.CalculationInput)
+		*scaled = *input
+		for i := range limited {
+			limited[i] = money.Round(stakes[i] * scale)
+		}
+		scaled.TotalStake = money.Sum(limited...)
+		constraints[binding] = types.ConstraintBookMax
+	}
+
+	for i, opt := range options {
+		lo, _ := capitalLimits(opt)
+		if lo > 0 && limited[i] > 0 && limited[i] < lo-0.005 {
+			stake := limited[i]
+			if opt.Side == types.SideLay {
+				stake /= opt.Odds - 1.0
+			}
+			if binding >= 0 {
+				return nil, nil, nil, fmt.Errorf("%s: stake %.2f is below its minimum of %s once %s is capped at its maximum",
+					types.OptionLabel(i), stake, opt.MinStake, types.OptionLabel(binding))
+			}
+			return nil, nil, nil, fmt.Errorf("%s: stake %.2f is below its minimum of %s; raise the total",
+				types.OptionLabel(i), stake, opt.MinStake)
+		}
+	}
+	return scaled, limited, constraints, nil
+}
+
+// markLimits records on result the stake limits that bound it, and the
+// requested total when they scaled the position down.
+func markLimits(result *types.CalculationResult, input, limited *types.CalculationInput,
+	constraints []types.Constraint) *types.CalculationResult {
+
+	for i, c := range constraints {
+		if c != "" {
+			result.Options[i].BindingConstraint = c
+		}
+	}
+	if limited.TotalStake != input.TotalStake {
+		result.Summary.RequestedTotal = input.TotalStake
+	}
+	return result
+}
@@ -0,0 +1,211 @@
+package calculator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/codehakase/kelly/pkg/money"
+	"github.com/codehakase/kelly/pkg/types"
+)
+
+func TestStakeLimits_AllocatingMethods(t *testing.T) {
+	tests := []struct {
+		name    string
+		method  types.CalculationMethod
+		options []types.Option
+		binding int
+		cap     float64
+	}{
+		{
+			name:   "arbitrage capped soft book",
+			method: types.MethodArbitrage,
+			options: []types.Option{
+				{Name: "Home", Odds: 2.56, MaxStake: money.FromFloat(2000)},
+				{Name: "Away", Odds: 3.85},
+			},
+			binding: 0,
+			cap:     2000,
+		},
+		{
+			name:   "proportional tightest cap wins",
+			method: types.MethodProportional,
+			options: []types.Option{
+				{Name: "Home", Odds: 2.1, MaxStake: money.FromFloat(3000)},
+				{Name: "Draw", Odds: 3.4, MaxStake: money.FromFloat(1000)},
+				{Name: "Away", Odds: 3.6},
+			},
+			binding: 1,
+			cap:     1000,
+		},
+		{
+			name:   "hedge capped lay",
+			method: types.MethodArbitrage,
+			options: []types.Option{
+				{Name: "Back", Odds: 3.2},
+				{Name: "Lay", Odds: 3.1, Side: types.SideLay, Commission: 0.02, MaxStake: money.FromFloat(100)},
+			},
+			binding: 1,
+			cap:     100,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			unlimited := make([]types.Option, len(tt.options))
+			for i, opt := range tt.options {
+				opt.MaxStake = 0
+				unlimited[i] = opt
+			}
+			calc := NewCalculator(tt.method)
+			exact, err := calc.Calculate(&types.CalculationInput{Method: tt.method, Options: unlimited, TotalStake: money.FromFloat(10000)})
+			if err != nil {
+				t.Fatalf("Calculate() error: %v", err)
+			}
+			result, err := calc.Calculate(&types.CalculationInput{Method: tt.method, Options: tt.options, TotalStake: money.FromFloat(10000)})
+			if err != nil {
+				t.Fatalf("Calculate() error: %v", err)
+			}
+
+			if got := result.Options[tt.binding]; !floatAlmostEqual(got.Stake.Float64(), tt.cap, 0.01) ||
+				got.BindingConstraint != types.ConstraintBookMax {
+				t.Errorf("%s stake = %.2f (%q), want %.2f capped at book-max", got.Name, got.Stake.Float64(), got.BindingConstraint, tt.cap)
+			}
+			if result.Summary.RequestedTotal != money.FromFloat(10000) {
+				t.Errorf("RequestedTotal = %.2f, want 10000", result.Summary.RequestedTotal.Float64())
+			}
+			if result.TotalStake >= money.FromFloat(10000) {
+				t.Errorf("TotalStake = %.2f, want the position scaled below 10000", result.TotalStake.Float64())
+			}
+			// Scaling keeps the split, so the ROI is unchanged.
+			if !floatAlmostEqual(result.Summary.MinROI, exact.Summary.MinROI, 0.001) {
+				t.Errorf("MinROI = %.4f, want %.4f as without the limit", result.Summary.MinROI, exact.Summary.MinROI)
+			}
+		})
+	}
+}
+
+func TestStakeLimits_Kelly(t *testing.T) {
+	input := &types.CalculationInput{
+		Method: types.MethodKelly,
+		Options: []types.Option{
+			{Name: "A", Odds: 2.1, Probability: 0.55, MaxStake: money.FromFloat(50)},
+			{Name: "B", Odds: 3.5, Probability: 0.40, MinStake: money.FromFloat(200)},
+		},
+		TotalStake: money.FromFloat(1000),
+	}
+	result, err := NewCalculator(types.MethodKelly).Calculate(input)
+	if err != nil {
+		t.Fatalf("Calculate() error: %v", err)
+	}
+
+	if a := result.Options[0]; a.Stake != money.FromFloat(50) || a.BindingConstraint != types.ConstraintBookMax {
+		t.Errorf("A = %.2f (%q), want 50 capped at book-max", a.Stake.Float64(), a.BindingConstraint)
+	}
+	if b := result.Options[1]; b.Stake != 0 || b.BindingConstraint != types.ConstraintBookMin {
+		t.Errorf("B = %.2f (%q), want 0 skipped below book-min", b.Stake.Float64(), b.BindingConstraint)
+	}
+	if result.TotalStake != money.FromFloat(1000) || result.Summary.RequestedTotal != 0 {
+		t.Errorf("bankroll should be unchanged, got TotalStake %.2f, RequestedTotal %.2f",
+			result.TotalStake.Float64(), result.Summary.RequestedTotal.Float64())
+	}
+}
+
+func TestStakeLimits_Simultaneous(t *testing.T) {
+	input := &types.CalculationInput{
+		Method: types.MethodKellySimultaneous,
+		Options: []types.Option{
+			{Odds: 2.1, Probability: 0.5, MaxStake: money.FromFloat(20)},
+			{Odds: 3.4, Probability: 0.27},
+			{Odds: 3.6, Probability: 0.23},
+		},
+		TotalStake: money.FromFloat(1000),
+	}
+	result, err := NewCalculator(types.MethodKellySimultaneous).Calculate(input)
+	if err != nil {
+		t.Fatalf("Calculate() error: %v", err)
+	}
+
+	if result.Options[0].Stake != money.FromFloat(20) || result.Options[0].Fraction != 0.02 {
+		t.Errorf("capped leg = %.2f (fraction %.4f), want 20 (0.02)", result.Options[0].Stake.Float64(), result.Options[0].Fraction)
+	}
+	var staked float64
+	for _, opt := range result.Options {
+		staked += opt.Stake.Float64()
+	}
+	if !floatAlmostEqual(result.Summary.CashReserve.Float64(), 1000-staked, 0.01) {
+		t.Errorf("CashReserve = %.2f, want the unstaked %.2f", result.Summary.CashReserve.Float64(), 1000-staked)
+	}
+}
+
+func TestStakeLimits_Matched(t *testing.T) {
+	input := &types.CalculationInput{
+		Method: types.MethodMatched,
+		Options: []types.Option{
+			{Name: "Back", Odds: 4.0},
+			{Name: "Lay", Odds: 4.2, Commission: 0.02, MaxStake: money.FromFloat(40)},
+		},
+		TotalStake: money.FromFloat(100),
+	}
+	result, err := NewCalculator(types.MethodMatched).Calculate(input)
+	if err != nil {
+		t.Fatalf("Calculate() error: %v", err)
+	}
+
+	lay := result.Options[1]
+	if !floatAlmostEqual(lay.Stake.Float64(), 40, 0.01) || lay.BindingConstraint != types.ConstraintBookMax {
+		t.Errorf("lay = %.2f (%q), want 40 capped at book-max", lay.Stake.Float64(), lay.BindingConstraint)
+	}
+	if want := round(40*(4.2-0.02)/4.0, 2); result.TotalStake.Float64() != want || result.Options[0].Stake.Float64() != want {
+		t.Errorf("back stake = %.2f, want %.2f", result.Options[0].Stake.Float64(), want)
+	}
+	if result.Summary.RequestedTotal != money.FromFloat(100) {
+		t.Errorf("RequestedTotal = %.2f, want 100", result.Summary.RequestedTotal.Float64())
+	}
+}
+
+func TestStakeLimits_BelowMinimum(t *testing.T) {
+	tests := []struct {
+		name    string
+		options []types.Option
+		want    string
+	}{
+		{
+			name:    "total too small",
+			options: []types.Option{{Odds: 2.56}, {Odds: 3.85, MinStake: money.FromFloat(50)}},
+			want:    "Option B: stake 39.94 is below its minimum of 50.00",
+		},
+		{
+			name:    "minimum broken by another leg's cap",
+			options: []types.Option{{Odds: 2.56, MaxStake: money.FromFloat(30)}, {Odds: 3.85, MinStake: money.FromFloat(20)}},
+			want:    "once Option A is capped",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewCalculator(types.MethodArbitrage).Calculate(&types.CalculationInput{
+				Method: types.MethodArbitrage, Options: tt.options, TotalStake: money.FromFloat(100),
+			})
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Calculate() error = %v, want one containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestStakeLimits_WithRounding(t *testing.T) {
+	result, err := NewCalculator(types.MethodArbitrage).Calculate(&types.CalculationInput{
+		Method: types.MethodArbitrage,
+		Options: []types.Option{
+			{Odds: 2.56, MaxStake: money.FromFloat(1998)},
+			{Odds: 3.85},
+		},
+		TotalStake: money.FromFloat(10000), RoundTo: 5,
+	})
+	if err != nil {
+		t.Fatalf("Calculate() error: %v", err)
+	}
+	if stake := result.Options[0].Stake; stake > money.FromFloat(1998) || !isMultiple(stake.Float64(), 5) {
+		t.Errorf("capped stake = %.2f, want a multiple of 5 within 1998", stake.Float64())
+	}
+}
@@ -0,0 +1,148 @@
+package calculator
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/codehakase/kelly/pkg/money"
+	"github.com/codehakase/kelly/pkg/types"
+)
+
+// MatchedCalculator sizes the exchange lay for a bookmaker back bet so the
+// result is the same whichever way the selection goes. The first option is
+// the bookmaker back bet and the second the exchange lay; TotalStake is the
+// back stake and only the lay commission is applied, with any winnings tax
+// and stake excise the bookmaker charges on the back bet. Exchanges charge
+// neither, so the lay is priced without them.
+type MatchedCalculator struct{}
+
+func (c *MatchedCalculator) Calculate(input *types.CalculationInput) (*types.CalculationResult, error) {
+	options := input.Outcomes()
+	if len(options) != 2 {
+		return nil, errors.New("matched method requires a back option and a lay option")
+	}
+
+	back, lay := options[0], options[1]
+	back.Side, back.Commission = types.SideBack, 0
+	lay = lay.Untaxed()
+	lay.Side = types.SideLay
+	backStake := input.TotalStake.Float64()
+	commission := lay.Commission
+
+	betType := input.BetType
+	if betType == "" {
+		betType = types.BetQualifying
+	}
+
+	switch betType {
+	case types.BetQualifying, types.BetFreeSNR, types.BetFreeSR:
+	default:
+		return nil, errors.New("invalid bet type: " + string(betType))
+	}
+
+	// backPays is what the back bet pays per unit staked when it wins. A
+	// stake-not-returned free bet only pays its winnings, so the lay covers
+	// (odds - 1) × stake instead of the full return. A free bet stakes
+	// nothing of the bettor's, so no excise is taken from it.
+	backPays := func(o types.Option) float64 {
+		switch betType {
+		case types.BetFreeSNR:
+			return o.NetWinnings(o.Odds - 1.0)
+		case types.BetFreeSR:
+			return 1.0 + o.NetWinnings(o.Odds-1.0)
+		}
+		return o.EffectiveOdds()
+	}
+	backReturn := backPays(back)
+	layPerBack := backReturn / (lay.Odds - commission)
+
+	// The lay grows with the back stake, so a maximum on either leg caps
+	// the back stake.
+	var constraints [2]types.Constraint
+	if back.MaxStake > 0 && backStake > back.MaxStake.Float64() {
+		backStake = back.MaxStake.Float64()
+		constraints[0] = types.ConstraintBookMax
+	}
+	if lay.MaxStake > 0 && backStake*layPerBack > lay.MaxStake.Float64() {
+		backStake = lay.MaxStake.Float64() / layPerBack
+		constraints = [2]types.Constraint{"", types.ConstraintBookMax}
+	}
+	layStake := backStake * layPerBack
+	if back.MinStake > 0 && backStake < back.MinStake.Float64() {
+		return nil, fmt.Errorf("%s: stake %.2f is below its minimum of %s", types.OptionLabel(0), backStake, back.MinStake)
+	}
+	if lay.MinStake > 0 && layStake < lay.MinStake.Float64() {
+		return nil, fmt.Errorf("%s: lay stake %.2f is below its minimum of %s", types.OptionLabel(1), layStake, lay.MinStake)
+	}
+	liability := layStake * (lay.Odds - 1.0)
+
+	ifBackWins := backStake*backReturn - liability
+	ifLayWins := layStake * (1.0 - commission)
+	if betType == types.BetQualifying {
+		ifBackWins -= backStake
+		ifLayWins -= backStake
+	}
+
+	minProfit := math.Min(ifBackWins, ifLayWins)
+	maxProfit := math.Max(ifBackWins, ifLayWins)
+
+	result := &types.CalculationResult{
+		Method:     types.MethodMatched,
+		TotalStake: money.FromFloat(backStake),
+		Currency:   input.Currency,
+		Options: []types.Option{
+			{
+				Name:               back.Name,
+				Odds:               back.Odds,
+				ImpliedProbability: impliedProbability(back.Odds),
+				Side:               types.SideBack,
+				TaxOnWinnings:      back.TaxOnWinnings,
+				StakeExcise:        back.StakeExcise,
+				MinStake:           back.MinStake,
+				MaxStake:           back.MaxStake,
+				Stake:              money.FromFloat(backStake),
+				ReturnIfWins:       money.FromFloat(ifBackWins + backStake),
+				ProfitIfWins:       money.FromFloat(ifBackWins),
+				ROI:                round(ifBackWins/backStake, 4),
+				BindingConstraint:  constraints[0],
+			},
+			{
+				Name:               lay.Name,
+				Odds:               lay.Odds,
+				ImpliedProbability: impliedProbability(lay.Odds),
+				Side:               types.SideLay,
+				Commission:         commission,
+				MinStake:           lay.MinStake,
+				MaxStake:           lay.MaxStake,
+				Stake:              money.FromFloat(layStake),
+				Liability:          money.FromFloat(liability),
+				ReturnIfWins:       money.FromFloat(ifLayWins + backStake),
+				ProfitIfWins:       money.FromFloat(ifLayWins),
+				ROI:                round(ifLayWins/backStake, 4),
+				BindingConstraint:  constraints[1],
+			},
+		},
+		Summary: types.Summary{
+			GuaranteedProfit: minProfit > 0,
+			MinProfit:        money.FromFloat(minProfit),
+			MaxProfit:        money.FromFloat(maxProfit),
+			ExpectedValue:    money.FromFloat((ifBackWins + ifLayWins) / 2.0),
+			MinROI:           round(minProfit/backStake, 4),
+			MaxROI:           round(maxProfit/backStake, 4),
+			MarketEfficiency: round(marketEfficiency([]types.Option{back, lay}), 4),
+			BetType:          betType,
+		},
+	}
+	if back.Taxed() {
+		untaxed := backStake * (backPays(back.Untaxed()) - backReturn)
+		result.Options[0].GrossReturn = money.FromFloat(ifBackWins + backStake + untaxed)
+	}
+	if result.TotalStake != input.TotalStake {
+		result.Summary.RequestedTotal = input.TotalStake
+	}
+	if betType != types.BetQualifying {
+		result.Summary.ExtractionRate = round(minProfit/backStake, 4)
+	}
+	return result, nil
+}
@@ -0,0 +1,139 @@
+package calculator
+
+import (
+	"testing"
+
+	"github.com/codehakase/kelly/pkg/money"
+	"github.com/codehakase/kelly/pkg/types"
+)
+
+func TestMatchedCalculator_Calculate(t *testing.T) {
+	tests := []struct {
+		name           string
+		betType        types.BetType
+		backOdds       float64
+		layOdds        float64
+		stake          float64
+		wantLayStake   float64
+		wantProfit     float64
+		wantExtraction float64
+	}{
+		{
+			// 10 × 2.0 / (2.06 - 0.02) = 9.80; loss = 9.80 × 0.98 - 10
+			name:         "qualifying bet",
+			betType:      types.BetQualifying,
+			backOdds:     2.0,
+			layOdds:      2.06,
+			stake:        10,
+			wantLayStake: 9.80,
+			wantProfit:   -0.39,
+		},
+		{
+			// 25 × 3.0 / (4.2 - 0.02) = 17.94; profit = 17.94 × 0.98
+			name:           "stake not returned free bet",
+			betType:        types.BetFreeSNR,
+			backOdds:       4.0,
+			layOdds:        4.2,
+			stake:          25,
+			wantLayStake:   17.94,
+			wantProfit:     17.58,
+			wantExtraction: 0.7033,
+		},
+		{
+			// 25 × 4.0 / (4.2 - 0.02) = 23.92; profit = 23.92 × 0.98
+			name:           "stake returned free bet",
+			betType:        types.BetFreeSR,
+			backOdds:       4.0,
+			layOdds:        4.2,
+			stake:          25,
+			wantLayStake:   23.92,
+			wantProfit:     23.44,
+			wantExtraction: 0.9378,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := &types.CalculationInput{
+				Method: types.MethodMatched,
+				Options: []types.Option{
+					{Name: "Bookmaker", Odds: tt.backOdds},
+					{Name: "Exchange", Odds: tt.layOdds, Commission: 0.02},
+				},
+				TotalStake: money.FromFloat(tt.stake),
+				BetType:    tt.betType,
+			}
+
+			result, err := (&MatchedCalculator{}).Calculate(input)
+			if err != nil {
+				t.Fatalf("Calculate() error: %v", err)
+			}
+
+			back, lay := result.Options[0], result.Options[1]
+			if back.Stake.Float64() != tt.stake {
+				t.Errorf("back Stake = %.2f, want %.2f", back.Stake.Float64(), tt.stake)
+			}
+			if lay.Side != types.SideLay {
+				t.Errorf("lay Side = %q, want %q", lay.Side, types.SideLay)
+			}
+			if !floatAlmostEqual(lay.Stake.Float64(), tt.wantLayStake, 0.01) {
+				t.Errorf("lay Stake = %.2f, want %.2f", lay.Stake.Float64(), tt.wantLayStake)
+			}
+			if !floatAlmostEqual(lay.Liability.Float64(), lay.Stake.Float64()*(tt.layOdds-1), 0.02) {
+				t.Errorf("Liability = %.2f, want %.2f", lay.Liability.Float64(), lay.Stake.Float64()*(tt.layOdds-1))
+			}
+			if !floatAlmostEqual(back.ProfitIfWins.Float64(), lay.ProfitIfWins.Float64(), 0.01) {
+				t.Errorf("profits should match: back %.2f, lay %.2f", back.ProfitIfWins.Float64(), lay.ProfitIfWins.Float64())
+			}
+			if !floatAlmostEqual(result.Summary.MinProfit.Float64(), tt.wantProfit, 0.01) {
+				t.Errorf("MinProfit = %.2f, want %.2f", result.Summary.MinProfit.Float64(), tt.wantProfit)
+			}
+			if !floatAlmostEqual(result.Summary.ExtractionRate, tt.wantExtraction, 0.0001) {
+				t.Errorf("ExtractionRate = %.4f, want %.4f", result.Summary.ExtractionRate, tt.wantExtraction)
+			}
+			if result.Summary.BetType != tt.betType {
+				t.Errorf("BetType = %q, want %q", result.Summary.BetType, tt.betType)
+			}
+		})
+	}
+}
+
+func TestMatchedCalculator_TaxedBack(t *testing.T) {
+	// The back pays 1 + 1.0 × 0.8 = 1.8 after tax, so the lay covers 18:
+	// 18 / (2.06 - 0.02) = 8.82, and either way loses 1.35. The exchange
+	// lay is not taxed.
+	result, err := (&MatchedCalculator{}).Calculate(&types.CalculationInput{
+		Method: types.MethodMatched,
+		Options: []types.Option{
+			{Odds: 2.0, TaxOnWinnings: 0.2},
+			{Odds: 2.06, Commission: 0.02, TaxOnWinnings: 0.2},
+		},
+		TotalStake: money.FromFloat(10),
+		BetType:    types.BetQualifying,
+	})
+	if err != nil {
+		t.Fatalf("Calculate() error: %v", err)
+	}
+	back, lay := result.Options[0], result.Options[1]
+	if !floatAlmostEqual(lay.Stake.Float64(), 8.82, 0.01) {
+		t.Errorf("lay stake = %.2f, want 8.82", lay.Stake.Float64())
+	}
+	if !floatAlmostEqual(back.ProfitIfWins.Float64(), -1.35, 0.01) || !floatAlmostEqual(lay.ProfitIfWins.Float64(), -1.35, 0.01) {
+		t.Errorf("profits = %.2f / %.2f, want -1.35 either way", back.ProfitIfWins.Float64(), lay.ProfitIfWins.Float64())
+	}
+	// Untaxed, the back's 20 would have returned 2 more.
+	if !floatAlmostEqual(back.GrossReturn.Float64(), back.ReturnIfWins.Float64()+2, 0.01) || lay.GrossReturn != 0 {
+		t.Errorf("gross returns = %.2f / %.2f, want %.2f / 0", back.GrossReturn.Float64(), lay.GrossReturn.Float64(), back.ReturnIfWins.Float64()+2)
+	}
+}
+
+func TestMatchedCalculator_RequiresTwoOptions(t *testing.T) {
+	input := &types.CalculationInput{
+		Method:     types.MethodMatched,
+		Options:    []types.Option{{Odds: 2.0}, {Odds: 2.1}, {Odds: 5.0}},
+		TotalStake: money.FromFloat(10),
+	}
+	if _, err := (&MatchedCalculator{}).Calculate(input); err == nil {
+		t.Error("Calculate() expected error for three options, got nil")
+	}
+}
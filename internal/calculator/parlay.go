@@ -0,0 +1,263 @@
+package calculator
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/codehakase/kelly/pkg/money"
+	"github.com/codehakase/kelly/pkg/types"
+)
+
+// MaxParlayLegs bounds the legs of a parlay; every win/lose outcome of the
+// legs is enumerated to size the Kelly stake.
+const MaxParlayLegs = 10
+
+// systemBet is a named full-cover system: every combination of the given
+// sizes over a fixed number of legs.
+type systemBet struct {
+	legs  int
+	sizes []int
+}
+
+var systemBets = map[string]systemBet{
+	"trixie":       {3, []int{2, 3}},
+	"patent":       {3, []int{1, 2, 3}},
+	"yankee":       {4, []int{2, 3, 4}},
+	"lucky15":      {4, []int{1, 2, 3, 4}},
+	"super-yankee": {5, []int{2, 3, 4, 5}},
+	"canadian":     {5, []int{2, 3, 4, 5}},
+	"lucky31":      {5, []int{1, 2, 3, 4, 5}},
+	"heinz":        {6, []int{2, 3, 4, 5, 6}},
+	"lucky63":      {6, []int{1, 2, 3, 4, 5, 6}},
+	"super-heinz":  {7, []int{2, 3, 4, 5, 6, 7}},
+	"goliath":      {8, []int{2, 3, 4, 5, 6, 7, 8}},
+}
+
+// SystemSizes returns the combination sizes of a parlay system over the
+// given number of legs, and the system's display name. The system is empty
+// (or "accumulator") for a single bet on every leg, a named system, or
+// comma-separated sizes for a round robin, e.g. "2" for every double.
+func SystemSizes(system string, legs int) ([]int, string, error) {
+	system = strings.ToLower(strings.TrimSpace(system))
+	switch system {
+	case "", "accumulator", "acca":
+		return []int{legs}, "accumulator", nil
+	}
+	if bet, ok := systemBets[system]; ok {
+		if legs != bet.legs {
+			return nil, "", fmt.Errorf("a %s needs %d legs, got %d", system, bet.legs, legs)
+		}
+		return bet.sizes, system, nil
+	}
+
+	seen := make(map[int]bool)
+	var sizes []int
+	for _, part := range strings.Split(system, ",") {
+		k, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil {
+			return nil, "", fmt.Errorf("unknown system '%s' (use a named system or sizes such as 2,3)", system)
+		}
+		if k < 1 || k > legs {
+			return nil, "", fmt.Errorf("combination size %d must be between 1 and the %d legs", k, legs)
+		}
+		if !seen[k] {
+			seen[k] = true
+			sizes = append(sizes, k)
+		}
+	}
+	sort.Ints(sizes)
+	return sizes, system, nil
+}
+
+// combinations returns every k-leg subset of n legs, in lexicographic order.
+func combinations(n, k int) [][]int {
+	var combos [][]int
+	combo := make([]int, k)
+	var pick func(start, depth int)
+	pick = func(start, depth int) {
+		if depth == k {
+			combos = append(combos, append([]int(nil), combo...))
+			return
+		}
+		for i := start; i <= n-(k-depth); i++ {
+			combo[depth] = i
+			pick(i+1, depth+1)
+		}
+	}
+	pick(0, 0)
+	return combos
+}
+
+// ParlayCalculator stakes combinations of independent legs: a single
+// accumulator of all of them, or a system of every combination of some
+// sizes. TotalStake is split equally across the combination lines, in whole
+// cents: what does not divide evenly is left unstaked. Legs without a
+// probability estimate are priced at their implied probability.
+type ParlayCalculator struct{}
+
+func (c *ParlayCalculator) Calculate(input *types.CalculationInput) (*types.CalculationResult, error) {
+	legs := input.Outcomes()
+	if len(legs) < 2 || len(legs) > MaxParlayLegs {
+		return nil, fmt.Errorf("parlay method requires between 2 and %d legs, got %d", MaxParlayLegs, len(legs))
+	}
+	for _, leg := range legs {
+		if leg.Side == types.SideLay {
+			return nil, errors.New("parlay legs must be back bets")
+		}
+		if leg.TaxOnWinnings != legs[0].TaxOnWinnings || leg.StakeExcise != legs[0].StakeExcise {
+			return nil, errors.New("parlay legs are one bet slip, so they must share a winnings tax and stake excise")
+		}
+	}
+	// Tax and excise are charged on each line as a whole, not on its legs.
+	slip := types.Option{TaxOnWinnings: legs[0].TaxOnWinnings, StakeExcise: legs[0].StakeExcise}
+	sizes, system, err := SystemSizes(input.System, len(legs))
+	if err != nil {
+		return nil, err
+	}
+
+	probs := make([]float64, len(legs))
+	for i, leg := range legs {
+		probs[i] = leg.Probability
+		if probs[i] == 0 {
+			probs[i] = impliedProbability(leg.Odds)
+		}
+	}
+
+	var lines [][]int
+	for _, k := range sizes {
+		lines = append(lines, combinations(len(legs), k)...)
+	}
+	unitAmount := input.TotalStake / money.Amount(len(lines))
+	if unitAmount <= 0 {
+		return nil, fmt.Errorf("a total of %s is less than a cent on each of %d lines", input.TotalStake, len(lines))
+	}
+	unit, total := unitAmount.Float64(), (unitAmount * money.Amount(len(lines))).Float64()
+
+	result := &types.CalculationResult{
+		Method:     types.MethodParlay,
+		TotalStake: money.FromFloat(total),
+		Currency:   input.Currency,
+		Options:    make([]types.Option, len(lines)),
+	}
+
+	lineOdds := make([]float64, len(lines))
+	var maxReturn, expectedReturn float64
+	for i, line := range lines {
+		odds, prob := 1.0, 1.0
+		labels := make([]string, len(line))
+		for j, leg := range line {
+			odds *= legs[leg].Odds
+			prob *= probs[leg]
+			labels[j] = strings.TrimPrefix(types.OptionLabel(leg), "Option ")
+		}
+		bet := slip
+		bet.Odds = odds
+		net := bet.EffectiveOdds()
+		lineOdds[i] = net
+		maxReturn += unit * net
+		expectedReturn += unit * net * prob
+
+		result.Options[i] = types.Option{
+			Name:               strings.Join(labels, "+"),
+			Odds:               round(odds, 4),
+			ImpliedProbability: impliedProbability(odds),
+			Probability:        round(prob, 6),
+			TaxOnWinnings:      slip.TaxOnWinnings,
+			StakeExcise:        slip.StakeExcise,
+			Stake:              money.FromFloat(unit),
+			ReturnIfWins:       money.FromFloat(unit * net),
+			GrossReturn:        grossReturn(bet, unit),
+			ProfitIfWins:       money.FromFloat(unit * (net - 1.0)),
+			ROI:                round(net-1.0, 4),
+			Legs:               line,
+		}
+	}
+
+	// Every leg may lose, so nothing is guaranteed.
+	minProfit, maxProfit := -total, maxReturn-total
+	kelly := parlayKelly(lines, lineOdds, probs)
+	result.Summary = types.Summary{
+		MinProfit:     money.FromFloat(minProfit),
+		MaxProfit:     money.FromFloat(maxProfit),
+		ExpectedValue: money.FromFloat(expectedReturn - total),
+		MinROI:        round(minProfit/total, 4),
+		MaxROI:        round(maxProfit/total, 4),
+		Parlay: &types.ParlaySummary{
+			System:        system,
+			Selections:    make([]types.Option, len(legs)),
+			Lines:         len(lines),
+			UnitStake:     money.FromFloat(unit),
+			KellyFraction: round(kelly, 4),
+			KellyStake:    money.FromFloat(input.TotalStake.Float64() * kelly),
+		},
+	}
+	for i, leg := range legs {
+		result.Summary.Parlay.Selections[i] = types.Option{
+			Name: leg.Name, Odds: leg.Odds, ImpliedProbability: impliedProbability(leg.Odds),
+			Probability: leg.Probability,
+		}
+	}
+	if result.TotalStake != input.TotalStake {
+		result.Summary.RequestedTotal = input.TotalStake
+	}
+	return result, nil
+}
+
+// parlayKelly returns the fraction of a bankroll to stake on each line that
+// maximizes expected log growth. Every way the legs can finish is weighed
+// by its probability; the growth rate is concave in the fraction, so its
+// derivative is bisected for the root.
+func parlayKelly(lines [][]int, lineOdds, probs []float64) float64 {
+	masks := make([]int, len(lines))
+	for i, line := range lines {
+		for _, leg := range line {
+			masks[i] |= 1 << leg
+		}
+	}
+
+	outcomes := 1 << len(probs)
+	weights := make([]float64, outcomes)
+	returns := make([]float64, outcomes)
+	for won := 0; won < outcomes; won++ {
+		weights[won] = 1.0
+		for leg, p := range probs {
+			if won&(1<<leg) != 0 {
+				weights[won] *= p
+			} else {
+				weights[won] *= 1.0 - p
+			}
+		}
+		for i, mask := range masks {
+			if mask&won == mask {
+				returns[won] += lineOdds[i]
+			}
+		}
+	}
+
+	n := float64(len(lines))
+	slope := func(f float64) float64 {
+		var d float64
+		for o, w := range weights {
+			d += w * (returns[o] - n) / (1.0 - n*f + f*returns[o])
+		}
+		return d
+	}
+	if slope(0) <= 0 {
+		return 0
+	}
+
+	lo, hi := 0.0, 1.0/n
+	for i := 0; i < 100; i++ {
+		mid := (lo + hi) / 2
+		if slope(mid) > 0 {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return math.Max(0, lo)
+}
@@ -0,0 +1,242 @@
+package calculator
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/codehakase/kelly/pkg/money"
+	"github.com/codehakase/kelly/pkg/types"
+)
+
+func TestSystemSizes(t *testing.T) {
+	tests := []struct {
+		name      string
+		system    string
+		legs      int
+		wantSizes []int
+		wantName  string
+		wantErr   bool
+	}{
+		{"default accumulator", "", 4, []int{4}, "accumulator", false},
+		{"acca alias", "ACCA", 3, []int{3}, "accumulator", false},
+		{"trixie", "trixie", 3, []int{2, 3}, "trixie", false},
+		{"lucky 15", "lucky15", 4, []int{1, 2, 3, 4}, "lucky15", false},
+		{"goliath", "goliath", 8, []int{2, 3, 4, 5, 6, 7, 8}, "goliath", false},
+		{"round robin doubles", "2", 5, []int{2}, "2", false},
+		{"sizes sorted and deduplicated", "3, 2,3", 4, []int{2, 3}, "3, 2,3", false},
+		{"yankee needs four legs", "yankee", 5, nil, "", true},
+		{"size above legs", "2,5", 4, nil, "", true},
+		{"unknown system", "flag", 4, nil, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sizes, name, err := SystemSizes(tt.system, tt.legs)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("SystemSizes(%q, %d) expected error", tt.system, tt.legs)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("SystemSizes(%q, %d) error: %v", tt.system, tt.legs, err)
+			}
+			if !reflect.DeepEqual(sizes, tt.wantSizes) || name != tt.wantName {
+				t.Errorf("SystemSizes(%q, %d) = %v %q, want %v %q", tt.system, tt.legs, sizes, name, tt.wantSizes, tt.wantName)
+			}
+		})
+	}
+}
+
+func TestParlayCalculator_Lines(t *testing.T) {
+	tests := []struct {
+		system string
+		legs   int
+		lines  int
+	}{
+		{"", 4, 1},
+		{"trixie", 3, 4},
+		{"patent", 3, 7},
+		{"yankee", 4, 11},
+		{"lucky15", 4, 15},
+		{"heinz", 6, 57},
+		{"goliath", 8, 247},
+		{"2", 5, 10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.system, func(t *testing.T) {
+			legs := make([]types.Option, tt.legs)
+			for i := range legs {
+				legs[i] = types.Option{Odds: 2.0}
+			}
+			result, err := (&ParlayCalculator{}).Calculate(&types.CalculationInput{
+				Method: types.MethodParlay, Options: legs, TotalStake: money.FromFloat(100), System: tt.system,
+			})
+			if err != nil {
+				t.Fatalf("Calculate() error: %v", err)
+			}
+			if len(result.Options) != tt.lines || result.Summary.Parlay.Lines != tt.lines {
+				t.Errorf("got %d lines, want %d", len(result.Options), tt.lines)
+			}
+		})
+	}
+}
+
+func TestParlayCalculator_Accumulator(t *testing.T) {
+	// A double at 2.0 × 2.0 = 4.0 with a 0.36 chance of landing:
+	// Kelly = (0.36 × 3 - 0.64) / 3 = 0.1467.
+	result, err := (&ParlayCalculator{}).Calculate(&types.CalculationInput{
+		Method: types.MethodParlay,
+		Options: []types.Option{
+			{Name: "Arsenal", Odds: 2.0, Probability: 0.6},
+			{Name: "Chelsea", Odds: 2.0, Probability: 0.6},
+		},
+		TotalStake: money.FromFloat(100),
+	})
+	if err != nil {
+		t.Fatalf("Calculate() error: %v", err)
+	}
+
+	line := result.Options[0]
+	if line.Name != "A+B" || line.Odds != 4.0 || line.Probability != 0.36 || !reflect.DeepEqual(line.Legs, []int{0, 1}) {
+		t.Errorf("line = %+v, want A+B at 4.0 with probability 0.36", line)
+	}
+	if line.ReturnIfWins != money.FromFloat(400) || result.Summary.MaxProfit != money.FromFloat(300) || result.Summary.MinProfit != money.FromFloat(-100) {
+		t.Errorf("return %.2f, profit range %.2f to %.2f, want 400 and -100 to 300",
+			line.ReturnIfWins.Float64(), result.Summary.MinProfit.Float64(), result.Summary.MaxProfit.Float64())
+	}
+	if result.Summary.ExpectedValue != money.FromFloat(44) {
+		t.Errorf("ExpectedValue = %.2f, want 44", result.Summary.ExpectedValue.Float64())
+	}
+	parlay := result.Summary.Parlay
+	if !floatAlmostEqual(parlay.KellyFraction, 0.1467, 0.0001) {
+		t.Errorf("KellyFraction = %.4f, want 0.1467", parlay.KellyFraction)
+	}
+	// Sized from the total like the other Kelly stakes.
+	if !floatAlmostEqual(parlay.KellyStake.Float64(), 14.67, 0.01) {
+		t.Errorf("KellyStake = %.2f, want 14.67 of the 100 total", parlay.KellyStake.Float64())
+	}
+	if parlay.System != "accumulator" || len(parlay.Selections) != 2 || parlay.Selections[0].Name != "Arsenal" {
+		t.Errorf("Parlay = %+v", parlay)
+	}
+}
+
+func TestParlayCalculator_Trixie(t *testing.T) {
+	// Four lines of 2.50: three doubles at 4.0 and a treble at 8.0.
+	result, err := (&ParlayCalculator{}).Calculate(&types.CalculationInput{
+		Method:     types.MethodParlay,
+		Options:    []types.Option{{Odds: 2.0, Probability: 0.5}, {Odds: 2.0, Probability: 0.5}, {Odds: 2.0, Probability: 0.5}},
+		TotalStake: money.FromFloat(10),
+		System:     "trixie",
+	})
+	if err != nil {
+		t.Fatalf("Calculate() error: %v", err)
+	}
+
+	names := make([]string, len(result.Options))
+	for i, opt := range result.Options {
+		names[i] = opt.Name
+	}
+	if want := []string{"A+B", "A+C", "B+C", "A+B+C"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("lines = %v, want %v", names, want)
+	}
+	if result.Summary.Parlay.UnitStake != money.FromFloat(2.5) {
+		t.Errorf("UnitStake = %.2f, want 2.5", result.Summary.Parlay.UnitStake.Float64())
+	}
+	// All three win: 3 × 10 + 20 = 50 back on 10.
+	if result.Summary.MaxProfit != money.FromFloat(40) {
+		t.Errorf("MaxProfit = %.2f, want 40", result.Summary.MaxProfit.Float64())
+	}
+	// Fair odds leave no edge: EV is zero and Kelly stakes nothing.
+	if result.Summary.ExpectedValue != 0 || result.Summary.Parlay.KellyFraction != 0 || result.Summary.Parlay.KellyStake != 0 {
+		t.Errorf("EV = %.2f, Kelly = %.4f, want 0 and 0", result.Summary.ExpectedValue.Float64(), result.Summary.Parlay.KellyFraction)
+	}
+}
+
+func TestParlayCalculator_WholeCentUnits(t *testing.T) {
+	// 10 over a patent's 7 lines is 1.428...: each line takes 1.42, and the
+	// 0.06 that does not divide evenly is left unstaked.
+	result, err := (&ParlayCalculator{}).Calculate(&types.CalculationInput{
+		Method:     types.MethodParlay,
+		Options:    []types.Option{{Odds: 2.0}, {Odds: 2.0}, {Odds: 2.0}},
+		TotalStake: money.FromFloat(10),
+		System:     "patent",
+	})
+	if err != nil {
+		t.Fatalf("Calculate() error: %v", err)
+	}
+	if result.Summary.Parlay.UnitStake != money.FromFloat(1.42) || result.TotalStake != money.FromFloat(9.94) || result.Summary.RequestedTotal != money.FromFloat(10) {
+		t.Errorf("unit %.2f, total %.2f (requested %.2f), want 1.42, 9.94 and 10",
+			result.Summary.Parlay.UnitStake.Float64(), result.TotalStake.Float64(), result.Summary.RequestedTotal.Float64())
+	}
+	if result.Summary.MinProfit != money.FromFloat(-9.94) {
+		t.Errorf("MinProfit = %.2f, want -9.94", result.Summary.MinProfit.Float64())
+	}
+
+	_, err = (&ParlayCalculator{}).Calculate(&types.CalculationInput{
+		Method:     types.MethodParlay,
+		Options:    []types.Option{{Odds: 2.0}, {Odds: 2.0}, {Odds: 2.0}},
+		TotalStake: money.FromFloat(0.05),
+		System:     "patent",
+	})
+	if err == nil {
+		t.Error("Calculate() expected an error for less than a cent a line")
+	}
+}
+
+func TestParlayCalculator_Taxed(t *testing.T) {
+	// The double at 4.0 is taxed as one bet: 10 + 30 × 0.8 = 34.
+	result, err := (&ParlayCalculator{}).Calculate(&types.CalculationInput{
+		Method:     types.MethodParlay,
+		Options:    []types.Option{{Odds: 2.0, TaxOnWinnings: 0.2}, {Odds: 2.0, TaxOnWinnings: 0.2}},
+		TotalStake: money.FromFloat(10),
+	})
+	if err != nil {
+		t.Fatalf("Calculate() error: %v", err)
+	}
+	line := result.Options[0]
+	if line.ReturnIfWins != money.FromFloat(34) || line.GrossReturn != money.FromFloat(40) || result.Summary.MaxProfit != money.FromFloat(24) {
+		t.Errorf("return = %.2f (gross %.2f), max profit %.2f, want 34 (40) and 24",
+			line.ReturnIfWins.Float64(), line.GrossReturn.Float64(), result.Summary.MaxProfit.Float64())
+	}
+}
+
+func TestParlayCalculator_ImpliedProbabilities(t *testing.T) {
+	result, err := (&ParlayCalculator{}).Calculate(&types.CalculationInput{
+		Method:     types.MethodParlay,
+		Options:    []types.Option{{Odds: 1.9}, {Odds: 1.9}},
+		TotalStake: money.FromFloat(100),
+	})
+	if err != nil {
+		t.Fatalf("Calculate() error: %v", err)
+	}
+	if !floatAlmostEqual(result.Options[0].Probability, 1/3.61, 1e-6) || result.Summary.ExpectedValue != 0 {
+		t.Errorf("implied legs should price the line at its odds, got p %.6f, EV %.2f",
+			result.Options[0].Probability, result.Summary.ExpectedValue.Float64())
+	}
+}
+
+func TestParlayCalculator_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		options []types.Option
+		system  string
+	}{
+		{"single leg", []types.Option{{Odds: 2.0}}, ""},
+		{"lay leg", []types.Option{{Odds: 2.0}, {Odds: 2.0, Side: types.SideLay}}, ""},
+		{"bad system", []types.Option{{Odds: 2.0}, {Odds: 2.0}}, "yankee"},
+		{"legs taxed differently", []types.Option{{Odds: 2.0, TaxOnWinnings: 0.2}, {Odds: 2.0}}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := (&ParlayCalculator{}).Calculate(&types.CalculationInput{
+				Method: types.MethodParlay, Options: tt.options, TotalStake: money.FromFloat(10), System: tt.system,
+			})
+			if err == nil {
+				t.Error("Calculate() expected an error")
+			}
+		})
+	}
+}
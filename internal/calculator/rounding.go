@@ -0,0 +1,222 @@
+package calculator
+
+import (
+	"math"
+
+	"github.com/codehakase/kelly/pkg/money"
+	"github.com/codehakase/kelly/pkg/types"
+)
+
+// withRounding builds the result for stakes and, when the input sets a
+// rounding policy, rebuilds it with rounded stakes and records the cost.
+//
+// When allocate is set the method spends the whole total (arbitrage):
+// rounding then searches the rounded amounts either side of
+// each stake for the combination with the highest guaranteed ROI,
+// re-solves lay legs against it, and the total becomes the amount actually
+// staked. Otherwise each stake is simply rounded and the total, a bankroll,
+// is left alone. The cost is measured in minimum profit for allocating
+// methods and in expected value for the others.
+func withRounding(input *types.CalculationInput, options []types.Option, stakes []float64, allocate bool,
+	build func(input *types.CalculationInput, stakes []float64) *types.CalculationResult) *types.CalculationResult {
+
+	exact := build(input, stakes)
+	if !input.Rounding() {
+		return exact
+	}
+
+	rounded := *input
+	var roundedStakes []float64
+	if allocate {
+		roundedStakes = searchStakes(input, options, stakes, nil)
+		rounded.TotalStake = money.Sum(roundedStakes...)
+	} else {
+		roundedStakes = make([]float64, len(stakes))
+		for i, s := range stakes {
+			roundedStakes[i] = roundStake(input, s)
+			if _, hi := capitalLimits(options[i]); hi > 0 && roundedStakes[i] > hi {
+				roundedStakes[i] = stakeCandidates(input, s)[0]
+			}
+		}
+	}
+
+	result := build(&rounded, roundedStakes)
+	result.Summary.RoundTo = input.RoundTo
+	result.Summary.RoundMode = roundMode(input)
+	if allocate {
+		result.Summary.RoundingCost = exact.Summary.MinProfit - result.Summary.MinProfit
+	} else {
+		result.Summary.RoundingCost = exact.Summary.ExpectedValue - result.Summary.ExpectedValue
+	}
+	return result
+}
+
+func roundMode(input *types.CalculationInput) types.RoundMode {
+	if input.RoundMode == "" {
+		return types.RoundNearest
+	}
+	return input.RoundMode
+}
+
+// roundingStep returns the multiple a stake is rounded to. Stealth steps
+// are half of a tenth of the stake's order of magnitude, and never smaller
+// than RoundTo.
+func roundingStep(input *types.CalculationInput, stake float64) float64 {
+	step := input.RoundTo
+	if roundMode(input) == types.RoundStealth && stake >= 1 {
+		magnitude := math.Pow(10, math.Floor(math.Log10(stake))-1) / 2
+		step = math.Max(step, math.Max(magnitude, 1))
+	}
+	if step <= 0 {
+		step = 1
+	}
+	return step
+}
+
+func roundStake(input *types.CalculationInput, stake float64) float64 {
+	step := roundingStep(input, stake)
+	if roundMode(input) == types.RoundDown {
+		return money.Round(math.Floor(stake/step+1e-9) * step)
+	}
+	return money.Round(math.Round(stake/step) * step)
+}
+
+// stakeCandidates returns the rounded amounts a stake may move to: the
+// multiples either side of it, or for down rounding the one below and the
+// one below that.
+func stakeCandidates(input *types.CalculationInput, stake float64) []float64 {
+	if stake <= 0 {
+		return []float64{0}
+	}
+	step := roundingStep(input, stake)
+	floor := math.Floor(stake/step+1e-9) * step
+
+	var candidates []float64
+	if roundMode(input) == types.RoundDown {
+		candidates = []float64{floor, floor - step}
+	} else {
+		candidates = []float64{floor, floor + step}
+	}
+
+	var valid []float64
+	for _, c := range candidates {
+		if c >= 0 {
+			valid = append(valid, money.Round(c))
+		}
+	}
+	return valid
+}
+
+// searchStakes picks, for every back leg, one of its rounded candidates so
+// that the guaranteed (minimum) return on the amount staked is as high as
+// it can be found. Lay legs are re-solved to return as much as the weakest
+// back leg. Candidates above a leg's maximum stake are never picked. Ties
+// go to the stakes whose total stays closest to the requested one.
+//
+// Stakes in another currency than the total are rounded in their own:
+// prices holds what one unit of each costs in the total's currency, and
+// options' odds are then odds in the total's currency. A nil prices means
+// every stake is in it.
+//
+// The search is a coordinate ascent rather than a walk over every
+// combination, which would grow as 2^n in the legs: it starts from each
+// stake rounded on its own and moves the one leg whose move improves the
+// stakes most, until no single move does.
+func searchStakes(input *types.CalculationInput, options []types.Option, stakes, prices []float64) []float64 {
+	candidates := make([][]float64, len(options))
+	choice := make([]int, len(options))
+	for i, opt := range options {
+		if opt.Side == types.SideLay {
+			candidates[i] = []float64{0}
+			continue
+		}
+		_, hi := capitalLimits(opt)
+		for _, c := range stakeCandidates(input, stakes[i]) {
+			if hi == 0 || c <= hi {
+				candidates[i] = append(candidates[i], c)
+			}
+		}
+		if len(candidates[i]) == 0 {
+			step := roundingStep(input, stakes[i])
+			candidates[i] = []float64{money.Round(math.Floor(hi/step+1e-9) * step)}
+		}
+		start := roundStake(input, stakes[i])
+		for j, c := range candidates[i] {
+			if math.Abs(c-start) < math.Abs(candidates[i][choice[i]]-start) {
+				choice[i] = j
+			}
+		}
+	}
+
+	current := make([]float64, len(options))
+	best := make([]float64, len(options))
+	bestROI, bestGap := evaluateStakes(input, options, candidates, choice, prices, best)
+
+	// Every move strictly improves the stakes, so the search ends; the
+	// bound only guards against float noise.
+	for moves := 0; moves < 4*len(options); moves++ {
+		moveLeg, moveTo := -1, 0
+		for i := range options {
+			from := choice[i]
+			for j := range candidates[i] {
+				if j == from {
+					continue
+				}
+				choice[i] = j
+				roi, gap := evaluateStakes(input, options, candidates, choice, prices, current)
+				if roi > bestROI+1e-9 || (math.Abs(roi-bestROI) <= 1e-9 && gap < bestGap-1e-9) {
+					bestROI, bestGap = roi, gap
+					moveLeg, moveTo = i, j
+				}
+			}
+			choice[i] = from
+		}
+		if moveLeg < 0 {
+			break
+		}
+		choice[moveLeg] = moveTo
+	}
+
+	evaluateStakes(input, options, candidates, choice, prices, best)
+	return best
+}
+
+// evaluateStakes fills stakes with the chosen candidates, re-solving lay
+// legs, and returns their guaranteed ROI and how far their total strays
+// from the requested one, both in the total's currency at prices. Stakes
+// of nothing have no ROI.
+func evaluateStakes(input *types.CalculationInput, options []types.Option, candidates [][]float64,
+	choice []int, prices, stakes []float64) (roi, gap float64) {
+
+	price := func(i int) float64 {
+		if prices == nil {
+			return 1
+		}
+		return prices[i]
+	}
+
+	minBack := math.Inf(1)
+	for i, opt := range options {
+		stakes[i] = candidates[i][choice[i]]
+		if opt.Side != types.SideLay {
+			minBack = math.Min(minBack, stakes[i]*price(i)*opt.EffectiveOdds())
+		}
+	}
+
+	var total float64
+	for i, opt := range options {
+		if opt.Side == types.SideLay {
+			stakes[i] = money.Round(minBack / opt.EffectiveOdds())
+		}
+		total += stakes[i] * price(i)
+	}
+	if total <= 0 {
+		return math.Inf(-1), math.Inf(1)
+	}
+
+	roi = math.Inf(1)
+	for i, opt := range options {
+		roi = math.Min(roi, (stakes[i]*price(i)*opt.EffectiveOdds()-total)/total)
+	}
+	return roi, math.Abs(total - input.TotalStake.Float64())
+}
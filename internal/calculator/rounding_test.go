@@ -0,0 +1,232 @@
+package calculator
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/codehakase/kelly/pkg/money"
+	"github.com/codehakase/kelly/pkg/types"
+)
+
+func isMultiple(v, step float64) bool {
+	return math.Abs(v/step-math.Round(v/step)) < 1e-6
+}
+
+func TestRounding_Arbitrage(t *testing.T) {
+	tests := []struct {
+		name string
+		mode types.RoundMode
+	}{
+		{"nearest", types.RoundNearest},
+		{"default mode", ""},
+		{"down", types.RoundDown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := &types.CalculationInput{
+				Method: types.MethodArbitrage, OddsA: 2.56, OddsB: 3.85, TotalStake: money.FromFloat(10000),
+				RoundTo: 5, RoundMode: tt.mode,
+			}
+			exact, _ := NewCalculator(types.MethodArbitrage).Calculate(&types.CalculationInput{
+				OddsA: 2.56, OddsB: 3.85, TotalStake: money.FromFloat(10000),
+			})
+			result, err := NewCalculator(types.MethodArbitrage).Calculate(input)
+			if err != nil {
+				t.Fatalf("Calculate() error: %v", err)
+			}
+
+			var total float64
+			for _, opt := range result.Options {
+				if !isMultiple(opt.Stake.Float64(), 5) {
+					t.Errorf("%s stake %.2f is not a multiple of 5", opt.Name, opt.Stake.Float64())
+				}
+				total += opt.Stake.Float64()
+			}
+			if result.TotalStake.Float64() != total {
+				t.Errorf("TotalStake = %.2f, want the rounded stakes' sum %.2f", result.TotalStake.Float64(), total)
+			}
+			if math.Abs(total-10000) > 10 {
+				t.Errorf("rounded total %.2f strays too far from 10000", total)
+			}
+			if tt.mode == types.RoundDown && total > 10000 {
+				t.Errorf("down rounding total %.2f exceeds 10000", total)
+			}
+
+			cost := exact.Summary.MinProfit - result.Summary.MinProfit
+			if !floatAlmostEqual(result.Summary.RoundingCost.Float64(), cost.Float64(), 0.01) {
+				t.Errorf("RoundingCost = %.2f, want %.2f", result.Summary.RoundingCost.Float64(), cost.Float64())
+			}
+			if result.Summary.RoundMode == "" || result.Summary.RoundTo != 5 {
+				t.Errorf("Summary should record the rounding policy, got %q / %.0f", result.Summary.RoundMode, result.Summary.RoundTo)
+			}
+		})
+	}
+}
+
+func TestRounding_MaximizesGuaranteedROI(t *testing.T) {
+	// Exact stakes are 511.01 / 488.99. Of the candidates 500|600 × 400|500,
+	// only 500 / 500 keeps a guaranteed profit: min(1050, 1075) - 1000.
+	input := &types.CalculationInput{
+		Method: types.MethodArbitrage, OddsA: 2.10, OddsB: 2.15, TotalStake: money.FromFloat(1000), RoundTo: 100,
+	}
+	result, err := NewCalculator(types.MethodArbitrage).Calculate(input)
+	if err != nil {
+		t.Fatalf("Calculate() error: %v", err)
+	}
+
+	if result.Options[0].Stake != money.FromFloat(500) || result.Options[1].Stake != money.FromFloat(500) {
+		t.Errorf("stakes = %.2f / %.2f, want 500 / 500", result.Options[0].Stake.Float64(), result.Options[1].Stake.Float64())
+	}
+	if result.Summary.MinProfit != money.FromFloat(50) {
+		t.Errorf("MinProfit = %.2f, want 50", result.Summary.MinProfit.Float64())
+	}
+}
+
+func TestRounding_HedgeResolvesLay(t *testing.T) {
+	input := &types.CalculationInput{
+		Options: []types.Option{
+			{Name: "Back", Odds: 3.2},
+			{Name: "Lay", Odds: 3.1, Side: types.SideLay, Commission: 0.02},
+		},
+		TotalStake: money.FromFloat(1000), RoundTo: 10,
+	}
+	result, err := NewCalculator(types.MethodArbitrage).Calculate(input)
+	if err != nil {
+		t.Fatalf("Calculate() error: %v", err)
+	}
+
+	back, lay := result.Options[0], result.Options[1]
+	if !isMultiple(back.Stake.Float64(), 10) {
+		t.Errorf("back stake %.2f is not a multiple of 10", back.Stake.Float64())
+	}
+	if !floatAlmostEqual(back.ProfitIfWins.Float64(), lay.ProfitIfWins.Float64(), 0.05) {
+		t.Errorf("profits %.2f / %.2f should stay equal after re-solving the lay", back.ProfitIfWins.Float64(), lay.ProfitIfWins.Float64())
+	}
+}
+
+func TestRounding_Kelly(t *testing.T) {
+	input := &types.CalculationInput{
+		Method: types.MethodKelly, OddsA: 2.1, OddsB: 3.5, ProbA: 0.55, ProbB: 0.40,
+		TotalStake: money.FromFloat(1000), RoundTo: 5, RoundMode: types.RoundDown,
+	}
+	exact, _ := NewCalculator(types.MethodKelly).Calculate(&types.CalculationInput{
+		Method: types.MethodKelly, OddsA: 2.1, OddsB: 3.5, ProbA: 0.55, ProbB: 0.40, TotalStake: money.FromFloat(1000),
+	})
+	result, err := NewCalculator(types.MethodKelly).Calculate(input)
+	if err != nil {
+		t.Fatalf("Calculate() error: %v", err)
+	}
+
+	if result.TotalStake != money.FromFloat(1000) {
+		t.Errorf("TotalStake = %.2f, the bankroll should not change", result.TotalStake.Float64())
+	}
+	for i, opt := range result.Options {
+		if !isMultiple(opt.Stake.Float64(), 5) || opt.Stake > exact.Options[i].Stake {
+			t.Errorf("%s stake %.2f should be %.2f rounded down to 5", opt.Name, opt.Stake.Float64(), exact.Options[i].Stake.Float64())
+		}
+	}
+	wantCost := exact.Summary.ExpectedValue - result.Summary.ExpectedValue
+	if !floatAlmostEqual(result.Summary.RoundingCost.Float64(), wantCost.Float64(), 0.01) {
+		t.Errorf("RoundingCost = %.2f, want %.2f", result.Summary.RoundingCost.Float64(), wantCost.Float64())
+	}
+}
+
+func TestRounding_SimultaneousKelly(t *testing.T) {
+	// Fractions, growth and the cash reserve describe the rounded stakes,
+	// not the ones before rounding.
+	input := threeWayInput()
+	input.RoundTo = 25
+	input.RoundMode = types.RoundDown
+	result, err := NewCalculator(types.MethodKellySimultaneous).Calculate(input)
+	if err != nil {
+		t.Fatalf("Calculate() error: %v", err)
+	}
+
+	reserve := 1.0
+	for _, opt := range result.Options {
+		if !isMultiple(opt.Stake.Float64(), 25) {
+			t.Errorf("%s stake %.2f should be a multiple of 25", opt.Name, opt.Stake.Float64())
+		}
+		f := opt.Stake.Float64() / input.TotalStake.Float64()
+		if !floatAlmostEqual(opt.Fraction, f, 0.0001) {
+			t.Errorf("%s Fraction = %.4f, want %.4f from the rounded stake", opt.Name, opt.Fraction, f)
+		}
+		reserve -= f
+	}
+	if !floatAlmostEqual(result.Summary.CashReserve.Float64(), input.TotalStake.Float64()*reserve, 0.01) {
+		t.Errorf("CashReserve = %.2f, want %.2f", result.Summary.CashReserve.Float64(), input.TotalStake.Float64()*reserve)
+	}
+
+	var growth float64
+	for _, opt := range result.Options {
+		growth += opt.Probability * math.Log(reserve+opt.Stake.Float64()/input.TotalStake.Float64()*opt.Odds)
+	}
+	if !floatAlmostEqual(result.Summary.GrowthRate, growth, 0.000001) {
+		t.Errorf("GrowthRate = %.6f, want %.6f", result.Summary.GrowthRate, growth)
+	}
+}
+
+func TestRoundStake_Stealth(t *testing.T) {
+	input := &types.CalculationInput{RoundMode: types.RoundStealth}
+	tests := []struct {
+		stake, want float64
+	}{
+		{6453.27, 6450},
+		{347.12, 345},
+		{23.4, 23},
+		{0.4, 0},
+	}
+	for _, tt := range tests {
+		if got := roundStake(input, tt.stake); got != tt.want {
+			t.Errorf("roundStake(%.2f) = %.2f, want %.2f", tt.stake, got, tt.want)
+		}
+	}
+}
+
+func TestRounding_Off(t *testing.T) {
+	result, err := NewCalculator(types.MethodArbitrage).Calculate(&types.CalculationInput{
+		OddsA: 2.56, OddsB: 3.85, TotalStake: money.FromFloat(10000),
+	})
+	if err != nil {
+		t.Fatalf("Calculate() error: %v", err)
+	}
+	if result.Summary.RoundMode != "" || result.Summary.RoundingCost != 0 {
+		t.Error("no rounding policy should leave the summary untouched")
+	}
+}
+
+func TestRounding_ManyLegs(t *testing.T) {
+	// An exhaustive search over 2^24 combinations took many seconds; the
+	// coordinate ascent should not notice the legs.
+	for _, method := range []types.CalculationMethod{types.MethodArbitrage, types.MethodProportional} {
+		t.Run(string(method), func(t *testing.T) {
+			options := make([]types.Option, 24)
+			for i := range options {
+				options[i] = types.Option{Odds: 28 + float64(i)/4}
+			}
+			input := &types.CalculationInput{Method: method, Options: options, TotalStake: money.FromFloat(10000), RoundTo: 5}
+
+			start := time.Now()
+			result, err := NewCalculator(method).Calculate(input)
+			if err != nil {
+				t.Fatalf("Calculate() error: %v", err)
+			}
+			if elapsed := time.Since(start); elapsed > time.Second {
+				t.Errorf("rounding 24 legs took %v", elapsed)
+			}
+
+			var total float64
+			for _, opt := range result.Options {
+				if !isMultiple(opt.Stake.Float64(), 5) {
+					t.Errorf("%s stake %.2f is not a multiple of 5", opt.Name, opt.Stake.Float64())
+				}
+				total += opt.Stake.Float64()
+			}
+			if math.Abs(total-10000) > 5*24 {
+				t.Errorf("rounded total %.2f strays too far from 10000", total)
+			}
+		})
+	}
+}
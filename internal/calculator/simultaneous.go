@@ -0,0 +1,131 @@
+package calculator
+
+import (
+	"math"
+	"sort"
+
+	"github.com/codehakase/kelly/pkg/money"
+	"github.com/codehakase/kelly/pkg/types"
+)
+
+// SimultaneousKellyCalculator maximizes expected log-wealth over a set of
+// mutually exclusive outcomes using the Smoczynski–Tomkins algorithm.
+type SimultaneousKellyCalculator struct{}
+
+func (c *SimultaneousKellyCalculator) Calculate(input *types.CalculationInput) (*types.CalculationResult, error) {
+	options, err := probabilityOptions(input)
+	if err != nil {
+		return nil, err
+	}
+	net, err := netOptions(options)
+	if err != nil {
+		return nil, err
+	}
+
+	fractions, _ := simultaneousKelly(net, input.MinEdge)
+	constraints := applyKellyLimits(input, kellyEdges(net), fractions)
+
+	stakes := make([]float64, len(options))
+	for i, f := range fractions {
+		stakes[i] = money.Round(input.TotalStake.Float64() * f)
+	}
+	limited, stakes, limits, err := withLimits(input, options, stakes, false)
+	if err != nil {
+		return nil, err
+	}
+
+	build := func(input *types.CalculationInput, stakes []float64) *types.CalculationResult {
+		result := newResult(types.MethodKellySimultaneous, input, options, stakes)
+
+		// The fractions and reserve follow the stakes actually placed, so a
+		// leg held to a bookmaker limit or rounded down keeps the rest as
+		// cash.
+		total := input.TotalStake.Float64()
+		fractions := make([]float64, len(stakes))
+		reserve := 1.0
+		for i := range stakes {
+			fractions[i] = stakes[i] / total
+			reserve -= fractions[i]
+		}
+
+		var expectedValue, probSum, growth float64
+		for i, opt := range net {
+			result.Options[i].Probability = opt.Probability
+			result.Options[i].Fraction = round(fractions[i], 4)
+			result.Options[i].BindingConstraint = constraints[i]
+			expectedValue += opt.Probability * (stakes[i]*opt.Odds - total)
+			growth += opt.Probability * math.Log(reserve+fractions[i]*opt.Odds)
+			probSum += opt.Probability
+		}
+		if probSum < 1.0 {
+			expectedValue += (1.0 - probSum) * (-total)
+			growth += (1.0 - probSum) * math.Log(reserve)
+		}
+
+		result.Summary.ExpectedValue = money.FromFloat(expectedValue)
+		result.Summary.GrowthRate = round(growth, 6)
+		result.Summary.CashReserve = money.FromFloat(total * reserve)
+		if missingProbability(input.Outcomes()) {
+			result.Summary.FairFrom = input.FairFrom
+		}
+		return result
+	}
+
+	result := withRounding(limited, options, stakes, false, build)
+	return markLimits(result, input, limited, limits), nil
+}
+
+// simultaneousKelly returns the growth-optimal bankroll fraction for each
+// option and the fraction held back as cash. Options whose edge
+// (p × odds - 1) is below minEdge are never bet.
+//
+// Options are considered in decreasing order of expected return p×odds and
+// added to the betting set S while p×odds exceeds the reserve rate
+// R(S) = (1 - Σp) / (1 - Σ1/odds). Each option in S then receives
+// f = p - R/odds, and R is the fraction left unbet.
+func simultaneousKelly(options []types.Option, minEdge float64) ([]float64, float64) {
+	order := make([]int, len(options))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		oa, ob := options[order[a]], options[order[b]]
+		return oa.Probability*oa.Odds > ob.Probability*ob.Odds
+	})
+
+	reserve := 1.0
+	var probSum, impliedSum float64
+	var selected []int
+	for _, i := range order {
+		opt := options[i]
+		if opt.Probability*opt.Odds <= reserve {
+			break
+		}
+		if minEdge > 0 && opt.Probability*opt.Odds-1.0 < minEdge {
+			break
+		}
+
+		numerator := 1.0 - (probSum + opt.Probability)
+		denominator := 1.0 - (impliedSum + 1.0/opt.Odds)
+		if denominator <= 0 {
+			// The selected odds form an arbitrage. Betting everything is
+			// only safe when they also cover every outcome.
+			if numerator <= 0 {
+				selected = append(selected, i)
+				reserve = 0
+			}
+			break
+		}
+
+		selected = append(selected, i)
+		probSum += opt.Probability
+		impliedSum += 1.0 / opt.Odds
+		reserve = numerator / denominator
+	}
+
+	fractions := make([]float64, len(options))
+	for _, i := range selected {
+		fractions[i] = math.Max(0, options[i].Probability-reserve/options[i].Odds)
+	}
+	return fractions, reserve
+}
@@ -0,0 +1,121 @@
+package calculator
+
+import (
+	"math"
+	"testing"
+
+	"github.com/codehakase/kelly/pkg/money"
+	"github.com/codehakase/kelly/pkg/types"
+)
+
+func threeWayInput() *types.CalculationInput {
+	return &types.CalculationInput{
+		Method: types.MethodKellySimultaneous,
+		Options: []types.Option{
+			{Name: "Home", Odds: 2.5, Probability: 0.45},
+			{Name: "Away", Odds: 4.2, Probability: 0.26},
+			{Name: "Draw", Odds: 2.6, Probability: 0.29},
+		},
+		TotalStake: money.FromFloat(1000),
+		Currency:   "$",
+	}
+}
+
+func TestSimultaneousKellyCalculator_Calculate(t *testing.T) {
+	calc := &SimultaneousKellyCalculator{}
+	result, err := calc.Calculate(threeWayInput())
+	if err != nil {
+		t.Fatalf("Calculate() error: %v", err)
+	}
+
+	// R = (1 - 0.45 - 0.26) / (1 - 1/2.5 - 1/4.2) ≈ 0.8013
+	reserve := 0.29 / (1 - 1/2.5 - 1/4.2)
+	wantFractions := []float64{0.45 - reserve/2.5, 0.26 - reserve/4.2, 0}
+
+	for i, want := range wantFractions {
+		if !floatAlmostEqual(result.Options[i].Fraction, want, 0.0001) {
+			t.Errorf("Options[%d].Fraction = %.4f, want %.4f", i, result.Options[i].Fraction, want)
+		}
+		if !floatAlmostEqual(result.Options[i].Stake.Float64(), want*1000, 0.01) {
+			t.Errorf("Options[%d].Stake = %.2f, want %.2f", i, result.Options[i].Stake.Float64(), want*1000)
+		}
+	}
+
+	if !floatAlmostEqual(result.Summary.CashReserve.Float64(), reserve*1000, 0.01) {
+		t.Errorf("CashReserve = %.2f, want %.2f", result.Summary.CashReserve.Float64(), reserve*1000)
+	}
+	if result.Summary.GrowthRate <= 0 {
+		t.Errorf("GrowthRate = %.6f, want positive growth with an edge", result.Summary.GrowthRate)
+	}
+	if result.Method != types.MethodKellySimultaneous {
+		t.Errorf("Method = %v, want %v", result.Method, types.MethodKellySimultaneous)
+	}
+}
+
+func TestSimultaneousKelly_IsGrowthOptimal(t *testing.T) {
+	input := threeWayInput()
+	options := input.Outcomes()
+	fractions, reserve := simultaneousKelly(options, 0)
+
+	growth := func(f []float64) float64 {
+		cash := 1.0
+		for _, x := range f {
+			cash -= x
+		}
+		var g float64
+		for i, opt := range options {
+			g += opt.Probability * math.Log(cash+f[i]*opt.Odds)
+		}
+		return g
+	}
+
+	if !floatAlmostEqual(1-fractions[0]-fractions[1]-fractions[2], reserve, 1e-9) {
+		t.Errorf("reserve = %.6f, want 1 - Σf", reserve)
+	}
+
+	best := growth(fractions)
+	for i := range fractions {
+		for _, delta := range []float64{-0.01, 0.01} {
+			perturbed := append([]float64(nil), fractions...)
+			perturbed[i] = math.Max(0, perturbed[i]+delta)
+			if g := growth(perturbed); g > best+1e-12 {
+				t.Errorf("perturbing option %d by %.2f improves growth: %.8f > %.8f", i, delta, g, best)
+			}
+		}
+	}
+}
+
+func TestSimultaneousKelly_NoEdge(t *testing.T) {
+	calc := &SimultaneousKellyCalculator{}
+	input := &types.CalculationInput{
+		Method:     types.MethodKellySimultaneous,
+		OddsA:      1.9,
+		OddsB:      1.9,
+		ProbA:      0.5,
+		ProbB:      0.5,
+		TotalStake: money.FromFloat(1000),
+	}
+
+	result, err := calc.Calculate(input)
+	if err != nil {
+		t.Fatalf("Calculate() error: %v", err)
+	}
+	for i, opt := range result.Options {
+		if opt.Stake != 0 {
+			t.Errorf("Options[%d].Stake = %.2f, want 0 with no edge", i, opt.Stake.Float64())
+		}
+	}
+	if result.Summary.CashReserve != money.FromFloat(1000) {
+		t.Errorf("CashReserve = %.2f, want 1000", result.Summary.CashReserve.Float64())
+	}
+}
+
+func TestSimultaneousKelly_RequiresProbabilities(t *testing.T) {
+	calc := &SimultaneousKellyCalculator{}
+	input := threeWayInput()
+	input.Options[2].Probability = 0
+
+	if _, err := calc.Calculate(input); err == nil {
+		t.Error("Calculate() expected error without probabilities, got nil")
+	}
+}
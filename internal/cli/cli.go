@@ -0,0 +1,155 @@
+// Package cli runs kelly's subcommands: it parses each command's flags,
+// prints its help, and generates shell completion scripts from the list of
+// commands.
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Command is a subcommand of the program.
+type Command struct {
+	Name string
+	// Usage is the synopsis after the program name, e.g. "convert [flags]
+	// <odds>...". Summary is the one line shown in the list of commands.
+	Usage   string
+	Summary string
+	// Help writes the text shown above the flags by -h.
+	Help func(w io.Writer)
+	// Flags defines the command's flags on fs and returns the function that
+	// runs the command with the arguments left after them.
+	Flags func(fs *flag.FlagSet) func(args []string)
+	// Subcommands are the words the command takes next, offered by shell
+	// completion.
+	Subcommands []string
+}
+
+// Run parses args with the command's flags and runs it. -h prints the
+// command's help.
+func (c *Command) Run(args []string) {
+	fs := flag.NewFlagSet(c.Name, flag.ExitOnError)
+	run := c.Flags(fs)
+	fs.Usage = func() { c.PrintHelp(fs) }
+	fs.Parse(args)
+	run(fs.Args())
+}
+
+// PrintHelp writes the command's help and flags to the flag set's output.
+func (c *Command) PrintHelp(fs *flag.FlagSet) {
+	if c.Help != nil {
+		c.Help(fs.Output())
+	}
+	if len(FlagGroups(fs)) > 0 {
+		fmt.Fprintln(fs.Output(), "FLAGS:")
+		PrintFlags(fs)
+	}
+}
+
+// FlagSet returns a flag set with the command's flags defined, for
+// completion and help.
+func (c *Command) FlagSet() *flag.FlagSet {
+	fs := flag.NewFlagSet(c.Name, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	c.Flags(fs)
+	return fs
+}
+
+// Find returns the command with the given name, or nil.
+func Find(commands []*Command, name string) *Command {
+	for _, c := range commands {
+		if c.Name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// Alias registers short as another name of the long flag on fs, sharing
+// its value and usage.
+func Alias(fs *flag.FlagSet, short, long string) {
+	f := fs.Lookup(long)
+	if f == nil {
+		panic("cli: alias of undefined flag --" + long)
+	}
+	fs.Var(f.Value, short, f.Usage)
+}
+
+// IsSet reports whether the named flag, under any of its names, was given
+// on the command line.
+func IsSet(fs *flag.FlagSet, name string) bool {
+	target := fs.Lookup(name)
+	if target == nil {
+		return false
+	}
+	set := false
+	fs.Visit(func(f *flag.Flag) {
+		if f.Value == target.Value {
+			set = true
+		}
+	})
+	return set
+}
+
+// FlagGroups returns the names of fs's flags, each flag's aliases grouped
+// shortest first, in order of their longest name.
+func FlagGroups(fs *flag.FlagSet) [][]string {
+	byValue := make(map[flag.Value][]string)
+	var order []flag.Value
+	fs.VisitAll(func(f *flag.Flag) {
+		if _, ok := byValue[f.Value]; !ok {
+			order = append(order, f.Value)
+		}
+		byValue[f.Value] = append(byValue[f.Value], f.Name)
+	})
+
+	groups := make([][]string, len(order))
+	for i, v := range order {
+		names := byValue[v]
+		sort.SliceStable(names, func(a, b int) bool { return len(names[a]) < len(names[b]) })
+		groups[i] = names
+	}
+	sort.SliceStable(groups, func(a, b int) bool {
+		return groups[a][len(groups[a])-1] < groups[b][len(groups[b])-1]
+	})
+	return groups
+}
+
+// Dashed returns a flag name as typed: one dash for short names of up to
+// two letters, two for long names.
+func Dashed(name string) string {
+	if len(name) <= 2 {
+		return "-" + name
+	}
+	return "--" + name
+}
+
+// PrintFlags writes fs's flags like flag.PrintDefaults, with each flag's
+// names on one line.
+func PrintFlags(fs *flag.FlagSet) {
+	for _, names := range FlagGroups(fs) {
+		f := fs.Lookup(names[len(names)-1])
+		dashed := make([]string, len(names))
+		for i, name := range names {
+			dashed[i] = Dashed(name)
+		}
+
+		var sb strings.Builder
+		sb.WriteString("  " + strings.Join(dashed, ", "))
+		typ, usage := flag.UnquoteUsage(f)
+		if typ != "" {
+			sb.WriteString(" " + typ)
+		}
+		sb.WriteString("\n    \t" + strings.ReplaceAll(usage, "\n", "\n    \t"))
+		if def := f.DefValue; def != "" && def != "0" && def != "false" {
+			if typ == "string" {
+				def = fmt.Sprintf("%q", def)
+			}
+			sb.WriteString(fmt.Sprintf(" (default %s)", def))
+		}
+		fmt.Fprintln(fs.Output(), sb.String())
+	}
+}
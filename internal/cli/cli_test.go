@@ -0,0 +1,79 @@
+package cli
+
+import (
+	"bytes"
+	"flag"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func testFlags() *flag.FlagSet {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.String("total", "", "Total amount to allocate")
+	fs.String("currency", "₦", "Currency symbol")
+	fs.Bool("record", false, "Record the result")
+	Alias(fs, "t", "total")
+	Alias(fs, "c", "currency")
+	return fs
+}
+
+func TestAlias(t *testing.T) {
+	fs := testFlags()
+	if err := fs.Parse([]string{"-t", "500"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := fs.Lookup("total").Value.String(); got != "500" {
+		t.Errorf("--total = %q after -t 500, want 500", got)
+	}
+	if !IsSet(fs, "total") || !IsSet(fs, "t") {
+		t.Error("IsSet() = false for a flag set under its alias")
+	}
+	if IsSet(fs, "currency") || IsSet(fs, "missing") {
+		t.Error("IsSet() = true for a flag not given")
+	}
+}
+
+func TestAlias_UndefinedPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Alias() of an undefined flag did not panic")
+		}
+	}()
+	Alias(flag.NewFlagSet("test", flag.ContinueOnError), "x", "missing")
+}
+
+func TestFlagGroups(t *testing.T) {
+	// Aliases are grouped, in order of the longest name.
+	want := [][]string{{"c", "currency"}, {"record"}, {"t", "total"}}
+	if got := FlagGroups(testFlags()); !reflect.DeepEqual(got, want) {
+		t.Errorf("FlagGroups() = %v, want %v", got, want)
+	}
+}
+
+func TestPrintFlags(t *testing.T) {
+	fs := testFlags()
+	var buf bytes.Buffer
+	fs.SetOutput(&buf)
+	PrintFlags(fs)
+
+	out := buf.String()
+	for _, want := range []string{
+		"  -c, --currency string\n    \tCurrency symbol (default \"₦\")\n",
+		"  --record\n    \tRecord the result\n",
+		"  -t, --total string\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("PrintFlags() output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestDashed(t *testing.T) {
+	tests := map[string]string{"t": "-t", "pa": "-pa", "total": "--total"}
+	for name, want := range tests {
+		if got := Dashed(name); got != want {
+			t.Errorf("Dashed(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package cli
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Shells lists the shells Completion writes scripts for.
+var Shells = []string{"bash", "zsh", "fish"}
+
+// Completion returns a script that completes the program's commands, their
+// subcommands and their flags in the given shell.
+func Completion(shell, program string, commands []*Command) (string, error) {
+	switch shell {
+	case "bash":
+		return bashCompletion(program, commands), nil
+	case "zsh":
+		return zshCompletion(program, commands), nil
+	case "fish":
+		return fishCompletion(program, commands), nil
+	}
+	return "", fmt.Errorf("unknown shell '%s' (use %s)", shell, strings.Join(Shells, ", "))
+}
+
+// words returns what may follow a command: its subcommands, then each of
+// its flags under every name.
+func words(c *Command) []string {
+	words := append([]string(nil), c.Subcommands...)
+	for _, names := range FlagGroups(c.FlagSet()) {
+		for _, name := range names {
+			words = append(words, Dashed(name))
+		}
+	}
+	return words
+}
+
+func commandNames(commands []*Command) []string {
+	names := make([]string, len(commands))
+	for i, c := range commands {
+		names[i] = c.Name
+	}
+	sort.Strings(names)
+	return names
+}
+
+func bashCompletion(program string, commands []*Command) string {
+	fn := "_" + program
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# bash completion for %s\n", program)
+	fmt.Fprintf(&sb, "%s() {\n", fn)
+	sb.WriteString("    local cur=${COMP_WORDS[COMP_CWORD]} words\n")
+	sb.WriteString("    if [[ $COMP_CWORD -eq 1 ]]; then\n")
+	fmt.Fprintf(&sb, "        words=%q\n", strings.Join(commandNames(commands), " "))
+	sb.WriteString("    else\n")
+	sb.WriteString("        case ${COMP_WORDS[1]} in\n")
+	for _, c := range commands {
+		fmt.Fprintf(&sb, "            %s) words=%q ;;\n", c.Name, strings.Join(words(c), " "))
+	}
+	sb.WriteString("        esac\n")
+	sb.WriteString("    fi\n")
+	sb.WriteString("    COMPREPLY=($(compgen -W \"$words\" -- \"$cur\"))\n")
+	sb.WriteString("}\n")
+	fmt.Fprintf(&sb, "complete -o default -F %s %s\n", fn, program)
+	return sb.String()
+}
+
+func zshCompletion(program string, commands []*Command) string {
+	fn := "_" + program
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "#compdef %s\n\n", program)
+	fmt.Fprintf(&sb, "%s() {\n", fn)
+	sb.WriteString("    local -a candidates\n")
+	sb.WriteString("    if (( CURRENT == 2 )); then\n")
+	fmt.Fprintf(&sb, "        candidates=(%s)\n", strings.Join(commandNames(commands), " "))
+	sb.WriteString("    else\n")
+	sb.WriteString("        case ${words[2]} in\n")
+	for _, c := range commands {
+		fmt.Fprintf(&sb, "            %s) candidates=(%s) ;;\n", c.Name, strings.Join(words(c), " "))
+	}
+	sb.WriteString("        esac\n")
+	sb.WriteString("    fi\n")
+	sb.WriteString("    compadd -- $candidates || _files\n")
+	sb.WriteString("}\n\n")
+	fmt.Fprintf(&sb, "compdef %s %s\n", fn, program)
+	return sb.String()
+}
+
+func fishCompletion(program string, commands []*Command) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# fish completion for %s\n", program)
+	for _, c := range commands {
+		fmt.Fprintf(&sb, "complete -c %s -n __fish_use_subcommand -f -a %s -d %s\n",
+			program, c.Name, fishQuote(c.Summary))
+	}
+	for _, c := range commands {
+		cond := fishQuote("__fish_seen_subcommand_from " + c.Name)
+		for _, sub := range c.Subcommands {
+			fmt.Fprintf(&sb, "complete -c %s -n %s -f -a %s\n", program, cond, sub)
+		}
+		fs := c.FlagSet()
+		for _, names := range FlagGroups(fs) {
+			var opts []string
+			for _, name := range names {
+				switch {
+				case len(name) == 1:
+					opts = append(opts, "-s "+name)
+				case len(name) == 2:
+					opts = append(opts, "-o "+name)
+				default:
+					opts = append(opts, "-l "+name)
+				}
+			}
+			usage := fs.Lookup(names[len(names)-1]).Usage
+			fmt.Fprintf(&sb, "complete -c %s -n %s %s -d %s\n",
+				program, cond, strings.Join(opts, " "), fishQuote(usage))
+		}
+	}
+	return sb.String()
+}
+
+// fishQuote quotes s for fish, in single quotes.
+func fishQuote(s string) string {
+	s = strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s)
+	return "'" + s + "'"
+}
@@ -0,0 +1,60 @@
+package cli
+
+import (
+	"flag"
+	"strings"
+	"testing"
+)
+
+func testCommands() []*Command {
+	return []*Command{
+		{
+			Name:    "scan",
+			Summary: "Find arbitrages",
+			Flags: func(fs *flag.FlagSet) func(args []string) {
+				fs.Float64("total", 0, "Total amount")
+				Alias(fs, "t", "total")
+				return nil
+			},
+		},
+		{
+			Name:        "bet",
+			Summary:     "Manage the bet journal",
+			Flags:       func(*flag.FlagSet) func(args []string) { return nil },
+			Subcommands: []string{"add", "settle"},
+		},
+	}
+}
+
+func TestCompletion(t *testing.T) {
+	tests := []struct {
+		shell string
+		want  []string
+	}{
+		{"bash", []string{"complete -o default -F _kelly kelly", `words="bet scan"`, `scan) words="-t --total"`, `bet) words="add settle"`}},
+		{"zsh", []string{"#compdef kelly", "candidates=(bet scan)", "scan) candidates=(-t --total)", "compdef _kelly kelly"}},
+		{"fish", []string{
+			"complete -c kelly -n __fish_use_subcommand -f -a scan -d 'Find arbitrages'",
+			"complete -c kelly -n '__fish_seen_subcommand_from scan' -s t -l total -d 'Total amount'",
+			"complete -c kelly -n '__fish_seen_subcommand_from bet' -f -a settle",
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.shell, func(t *testing.T) {
+			script, err := Completion(tt.shell, "kelly", testCommands())
+			if err != nil {
+				t.Fatalf("Completion() error: %v", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(script, want) {
+					t.Errorf("script missing %q:\n%s", want, script)
+				}
+			}
+		})
+	}
+
+	if _, err := Completion("tcsh", "kelly", testCommands()); err == nil {
+		t.Error("Completion(tcsh) expected an error")
+	}
+}
@@ -0,0 +1,317 @@
+// Package config reads kelly's config file, which holds default values for
+// command flags and named profiles of them, and applies them to flag sets.
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/codehakase/kelly/internal/cli"
+)
+
+// DefaultPath returns $KELLY_CONFIG, or config.toml in the kelly directory
+// under the user's config dir.
+func DefaultPath() (string, error) {
+	if path := os.Getenv("KELLY_CONFIG"); path != "" {
+		return path, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("locating config dir: %w", err)
+	}
+	return filepath.Join(dir, "kelly", "config.toml"), nil
+}
+
+// Config holds settings keyed by long flag name, such as "currency" or
+// "kelly-fraction", with their values as they would be typed after the
+// flag.
+type Config struct {
+	Path string
+	// Settings apply to every command with the flag. A "profile" setting
+	// names the profile used when none is picked.
+	Settings map[string]string
+	// Profiles are named sets of settings picked with --profile or
+	// $KELLY_PROFILE, applied over Settings.
+	Profiles map[string]map[string]string
+}
+
+// Setting is a resolved value and where it came from: "default",
+// "config", "profile <name>" or "env <VAR>".
+type Setting struct {
+	Value, Source string
+}
+
+// Load reads a config file written in a subset of TOML:
+//
+//	currency = "£"
+//	kelly-fraction = 0.5
+//
+//	[profiles.exchange-uk]
+//	commission = 0.02
+//	round-to = 5
+//
+// Values are strings, numbers, booleans or arrays of them, which are joined
+// with commas as list flags expect. A missing file is an empty config.
+func Load(path string) (*Config, error) {
+	c := &Config{Path: path, Settings: map[string]string{}, Profiles: map[string]map[string]string{}}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	settings := c.Settings
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(stripComment(line))
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			inner, closed := strings.CutSuffix(line[1:], "]")
+			name, ok := strings.CutPrefix(strings.TrimSpace(inner), "profiles.")
+			if !closed || !ok || !isKey(name) {
+				return nil, fmt.Errorf("%s:%d: unknown table %s (use [profiles.<name>])", path, i+1, line)
+			}
+			if _, ok := c.Profiles[name]; ok {
+				return nil, fmt.Errorf("%s:%d: profile '%s' defined twice", path, i+1, name)
+			}
+			settings = map[string]string{}
+			c.Profiles[name] = settings
+			continue
+		}
+
+		key, raw, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !ok || !isKey(key) {
+			return nil, fmt.Errorf("%s:%d: expected key = value", path, i+1)
+		}
+		if _, ok := settings[key]; ok {
+			return nil, fmt.Errorf("%s:%d: '%s' set twice", path, i+1, key)
+		}
+		value, err := parseValue(strings.TrimSpace(raw))
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %s: %w", path, i+1, key, err)
+		}
+		settings[key] = value
+	}
+	return c, nil
+}
+
+// stripComment removes a # comment that is not inside a string.
+func stripComment(line string) string {
+	var quote rune
+	for i, r := range line {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '#':
+			return line[:i]
+		}
+	}
+	return line
+}
+
+func isKey(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_') {
+			return false
+		}
+	}
+	return true
+}
+
+// parseValue returns a TOML value as flag text.
+func parseValue(raw string) (string, error) {
+	if inner, ok := strings.CutPrefix(raw, "["); ok {
+		inner, ok = strings.CutSuffix(inner, "]")
+		if !ok {
+			return "", errors.New("unterminated array")
+		}
+		var parts []string
+		for _, item := range splitArray(inner) {
+			if item = strings.TrimSpace(item); item == "" {
+				continue
+			}
+			v, err := parseValue(item)
+			if err != nil {
+				return "", err
+			}
+			parts = append(parts, v)
+		}
+		return strings.Join(parts, ","), nil
+	}
+
+	switch {
+	case strings.HasPrefix(raw, `"`):
+		v, err := strconv.Unquote(raw)
+		if err != nil {
+			return "", fmt.Errorf("invalid string %s", raw)
+		}
+		return v, nil
+	case strings.HasPrefix(raw, "'"):
+		if len(raw) < 2 || !strings.HasSuffix(raw, "'") || strings.Contains(raw[1:len(raw)-1], "'") {
+			return "", fmt.Errorf("invalid string %s", raw)
+		}
+		return raw[1 : len(raw)-1], nil
+	case raw == "true" || raw == "false":
+		return raw, nil
+	}
+	if _, err := strconv.ParseFloat(raw, 64); err != nil {
+		return "", fmt.Errorf("invalid value %s (quote strings)", raw)
+	}
+	return raw, nil
+}
+
+// splitArray splits the items of an array at commas outside strings.
+func splitArray(s string) []string {
+	var items []string
+	var quote rune
+	start := 0
+	for i, r := range s {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == ',':
+			items = append(items, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(items, s[start:])
+}
+
+// EnvName returns the environment variable that sets a flag: KELLY_ and the
+// flag's long name in upper case, with dashes as underscores.
+func EnvName(name string) string {
+	return "KELLY_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+}
+
+// Profile returns the profile in use: the given one, else $KELLY_PROFILE,
+// else the config's profile setting. It fails for a profile the config
+// does not define.
+func (c *Config) Profile(name string, getenv func(string) string) (string, error) {
+	if name == "" {
+		name = getenv("KELLY_PROFILE")
+	}
+	if name == "" {
+		name = c.Settings["profile"]
+	}
+	if name == "" {
+		return "", nil
+	}
+	if _, ok := c.Profiles[name]; !ok {
+		return "", fmt.Errorf("unknown profile '%s' (%s defines %s)", name, c.Path, c.profileList())
+	}
+	return name, nil
+}
+
+func (c *Config) profileList() string {
+	if len(c.Profiles) == 0 {
+		return "none"
+	}
+	names := make([]string, 0, len(c.Profiles))
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// Lookup returns the setting for a flag under profile: its environment
+// variable, else the profile's value, else the config's.
+func (c *Config) Lookup(name, profile string, getenv func(string) string) (Setting, bool) {
+	if v := getenv(EnvName(name)); v != "" {
+		return Setting{Value: v, Source: "env " + EnvName(name)}, true
+	}
+	if v, ok := c.Profiles[profile][name]; ok && profile != "" {
+		return Setting{Value: v, Source: "profile " + profile}, true
+	}
+	if v, ok := c.Settings[name]; ok && Settable(name) {
+		return Setting{Value: v, Source: "config"}, true
+	}
+	return Setting{}, false
+}
+
+// unsettable are flags that pick settings or act rather than set values.
+var unsettable = map[string]bool{"profile": true, "interactive": true, "version": true}
+
+// Settable reports whether a flag can be set by the config.
+func Settable(name string) bool {
+	return !unsettable[name]
+}
+
+// applied holds the values of the flags Apply has set. A flag's aliases
+// share its value, so they share the entry.
+var applied = make(map[flag.Value]bool)
+
+// Apply sets the flags of fs that were not given on the command line from
+// the config under profile, by their long names. The values become the
+// flags' defaults, so cli.IsSet still reports only the command line; IsSet
+// reports the flags set either way.
+func (c *Config) Apply(fs *flag.FlagSet, profile string, getenv func(string) string) error {
+	for _, names := range cli.FlagGroups(fs) {
+		name := names[len(names)-1]
+		if !Settable(name) || cli.IsSet(fs, name) {
+			continue
+		}
+		s, ok := c.Lookup(name, profile, getenv)
+		if !ok {
+			continue
+		}
+		f := fs.Lookup(name)
+		if err := f.Value.Set(s.Value); err != nil {
+			return fmt.Errorf("%s: --%s %s: %w", s.Source, name, s.Value, err)
+		}
+		applied[f.Value] = true
+	}
+	return nil
+}
+
+// IsSet reports whether a flag of fs, by any of its names, was given on the
+// command line or set by Apply from the environment, a profile or the
+// config file, rather than left at its default.
+func IsSet(fs *flag.FlagSet, name string) bool {
+	if cli.IsSet(fs, name) {
+		return true
+	}
+	f := fs.Lookup(name)
+	return f != nil && applied[f.Value]
+}
+
+// Unknown returns the settings, as "name" or "profile/name", that name
+// none of the given flags.
+func (c *Config) Unknown(flags map[string]bool) []string {
+	var unknown []string
+	for name := range c.Settings {
+		if name != "profile" && !flags[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	for profile, settings := range c.Profiles {
+		for name := range settings {
+			if !flags[name] {
+				unknown = append(unknown, profile+"/"+name)
+			}
+		}
+	}
+	sort.Strings(unknown)
+	return unknown
+}
@@ -0,0 +1,196 @@
+package config
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/codehakase/kelly/internal/cli"
+)
+
+func writeConfig(t *testing.T, data string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func env(vars map[string]string) func(string) string {
+	return func(name string) string { return vars[name] }
+}
+
+const testConfig = `
+# Defaults for every command
+currency = "£"    # pounds
+kelly-fraction = 0.25
+verbose = true
+names = ['Home', "Draw", 'Away']
+
+[profiles.exchange-uk]
+commission = [0, 0.02]
+round-to = 5
+kelly-fraction = 0.5
+`
+
+func TestLoad(t *testing.T) {
+	c, err := Load(writeConfig(t, testConfig))
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	want := map[string]string{"currency": "£", "kelly-fraction": "0.25", "verbose": "true", "names": "Home,Draw,Away"}
+	if !reflect.DeepEqual(c.Settings, want) {
+		t.Errorf("Settings = %v, want %v", c.Settings, want)
+	}
+	wantProfile := map[string]string{"commission": "0,0.02", "round-to": "5", "kelly-fraction": "0.5"}
+	if !reflect.DeepEqual(c.Profiles["exchange-uk"], wantProfile) {
+		t.Errorf("exchange-uk = %v, want %v", c.Profiles["exchange-uk"], wantProfile)
+	}
+}
+
+func TestLoad_Missing(t *testing.T) {
+	c, err := Load(filepath.Join(t.TempDir(), "config.toml"))
+	if err != nil || len(c.Settings) != 0 || len(c.Profiles) != 0 {
+		t.Errorf("Load() of a missing file = %+v, %v; want an empty config", c, err)
+	}
+}
+
+func TestLoad_Errors(t *testing.T) {
+	tests := map[string]string{
+		"unquoted string":  `currency = pounds`,
+		"no value":         `currency`,
+		"set twice":        "round-to = 5\nround-to = 10",
+		"unknown table":    `[defaults]`,
+		"profile twice":    "[profiles.a]\n[profiles.a]",
+		"unclosed string":  `currency = "£`,
+		"unclosed array":   `commission = [0, 0.02`,
+		"unclosed table":   `[profiles.a`,
+		"inline table key": `odds.a = 2.1`,
+	}
+
+	for name, data := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := Load(writeConfig(t, data)); err == nil {
+				t.Error("Load() expected an error")
+			}
+		})
+	}
+}
+
+func TestProfile(t *testing.T) {
+	c := &Config{
+		Settings: map[string]string{"profile": "home"},
+		Profiles: map[string]map[string]string{"home": {}, "exchange-uk": {}},
+	}
+
+	tests := []struct {
+		flag, env, want string
+	}{
+		{"", "", "home"},
+		{"", "exchange-uk", "exchange-uk"},
+		{"home", "exchange-uk", "home"},
+	}
+	for _, tt := range tests {
+		got, err := c.Profile(tt.flag, env(map[string]string{"KELLY_PROFILE": tt.env}))
+		if err != nil || got != tt.want {
+			t.Errorf("Profile(%q) with KELLY_PROFILE=%q = %q, %v; want %q", tt.flag, tt.env, got, err, tt.want)
+		}
+	}
+	if _, err := c.Profile("away", env(nil)); err == nil {
+		t.Error("Profile(away) expected an error for an undefined profile")
+	}
+}
+
+func TestApply(t *testing.T) {
+	c, err := Load(writeConfig(t, testConfig))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fs := flag.NewFlagSet("calc", flag.ContinueOnError)
+	var (
+		currency   = fs.String("currency", "₦", "")
+		fraction   = fs.Float64("kelly-fraction", 0, "")
+		commission = fs.String("commission", "", "")
+		roundTo    = fs.Float64("round-to", 0, "")
+		verbose    = fs.Bool("verbose", false, "")
+		method     = fs.String("method", "arbitrage", "")
+	)
+	fs.String("profile", "", "")
+	cli.Alias(fs, "c", "currency")
+	if err := fs.Parse([]string{"-c", "$"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Flags beat the environment, which beats the profile, which beats the
+	// top-level settings.
+	vars := map[string]string{"KELLY_ROUND_TO": "10", "KELLY_CURRENCY": "€"}
+	if err := c.Apply(fs, "exchange-uk", env(vars)); err != nil {
+		t.Fatalf("Apply() error: %v", err)
+	}
+	if *currency != "$" || *roundTo != 10 || *fraction != 0.5 || *commission != "0,0.02" || !*verbose || *method != "arbitrage" {
+		t.Errorf("flags = %s %v %v %s %v %s, want $ 10 0.5 0,0.02 true arbitrage",
+			*currency, *roundTo, *fraction, *commission, *verbose, *method)
+	}
+	if cli.IsSet(fs, "round-to") {
+		t.Error("IsSet(round-to) = true for a flag set by the config")
+	}
+}
+
+func TestIsSet(t *testing.T) {
+	// A profile's currency must count as chosen, so kelly calc --bankroll
+	// keeps it rather than taking the bankroll's.
+	c := &Config{
+		Settings: map[string]string{},
+		Profiles: map[string]map[string]string{"uk": {"currency": "£"}},
+	}
+	fs := flag.NewFlagSet("calc", flag.ContinueOnError)
+	currency := fs.String("currency", "₦", "")
+	fs.String("bankroll", "", "")
+	fs.Float64("round-to", 0, "")
+	cli.Alias(fs, "c", "currency")
+	if err := fs.Parse([]string{"--bankroll", "main"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Apply(fs, "uk", env(nil)); err != nil {
+		t.Fatalf("Apply() error: %v", err)
+	}
+
+	if *currency != "£" || cli.IsSet(fs, "currency") {
+		t.Fatalf("currency = %s, set on the command line = %v; want £ from the profile only", *currency, cli.IsSet(fs, "currency"))
+	}
+	for name, want := range map[string]bool{"currency": true, "c": true, "bankroll": true, "round-to": false, "missing": false} {
+		if got := IsSet(fs, name); got != want {
+			t.Errorf("IsSet(%s) = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestApply_InvalidValue(t *testing.T) {
+	c := &Config{Settings: map[string]string{"round-to": "five"}}
+	fs := flag.NewFlagSet("calc", flag.ContinueOnError)
+	fs.Float64("round-to", 0, "")
+	if err := c.Apply(fs, "", env(nil)); err == nil {
+		t.Error("Apply() expected an error for a value the flag rejects")
+	}
+}
+
+func TestUnknown(t *testing.T) {
+	c := &Config{
+		Settings: map[string]string{"profile": "a", "currency": "£", "curency": "£"},
+		Profiles: map[string]map[string]string{"a": {"round-to": "5", "rounding": "5"}},
+	}
+	got := c.Unknown(map[string]bool{"currency": true, "round-to": true})
+	if want := []string{"a/rounding", "curency"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Unknown() = %v, want %v", got, want)
+	}
+}
+
+func TestEnvName(t *testing.T) {
+	if got := EnvName("kelly-fraction"); got != "KELLY_KELLY_FRACTION" {
+		t.Errorf("EnvName(kelly-fraction) = %q, want KELLY_KELLY_FRACTION", got)
+	}
+}
@@ -0,0 +1,108 @@
+package formatter
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/codehakase/kelly/pkg/types"
+)
+
+func FormatBacktestTable(result *types.BacktestResult) string {
+	var sb strings.Builder
+	cur := result.Currency
+
+	sb.WriteString(fmt.Sprintf("KELLY • Backtest of %s staking\n", strings.Title(string(result.Method))))
+	sb.WriteString(fmt.Sprintf("%d rows: %d bets, %d winners, %d skipped\n\n",
+		result.Rows, result.Bets, result.Winners, result.Skipped))
+
+	sb.WriteString(fmt.Sprintf("Bankroll:      %s%.2f → %s%.2f\n", cur, result.StartingBankroll.Float64(), cur, result.FinalBankroll.Float64()))
+	sb.WriteString(fmt.Sprintf("Profit:        %s%+.2f\n", cur, result.Profit.Float64()))
+	sb.WriteString(fmt.Sprintf("Total staked:  %s%.2f\n", cur, result.TotalStaked.Float64()))
+	sb.WriteString(fmt.Sprintf("ROI:           %.2f%%\n", result.ROI*100))
+	sb.WriteString(fmt.Sprintf("Yield:         %.2f%%\n", result.Yield*100))
+	sb.WriteString(fmt.Sprintf("Max drawdown:  %.2f%%\n", result.MaxDrawdown*100))
+	sb.WriteString(fmt.Sprintf("Sharpe/bet:    %.3f\n", result.Sharpe))
+	sb.WriteString(fmt.Sprintf("Sortino/bet:   %.3f\n", result.Sortino))
+
+	return sb.String()
+}
+
+func FormatBacktestJSON(result *types.BacktestResult) (string, error) {
+	bytes, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(bytes), nil
+}
+
+// FormatBacktestCSV writes one summary metric per row; the ledger is
+// exported separately with FormatLedgerCSV.
+func FormatBacktestCSV(result *types.BacktestResult) (string, error) {
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+
+	rows := [][]string{
+		{"Metric", "Value"},
+		{"method", string(result.Method)},
+		{"rows", fmt.Sprintf("%d", result.Rows)},
+		{"bets", fmt.Sprintf("%d", result.Bets)},
+		{"winners", fmt.Sprintf("%d", result.Winners)},
+		{"skipped", fmt.Sprintf("%d", result.Skipped)},
+		{"starting_bankroll", fmt.Sprintf("%.2f", result.StartingBankroll.Float64())},
+		{"final_bankroll", fmt.Sprintf("%.2f", result.FinalBankroll.Float64())},
+		{"profit", fmt.Sprintf("%.2f", result.Profit.Float64())},
+		{"total_staked", fmt.Sprintf("%.2f", result.TotalStaked.Float64())},
+		{"roi", fmt.Sprintf("%.4f", result.ROI)},
+		{"yield", fmt.Sprintf("%.4f", result.Yield)},
+		{"max_drawdown", fmt.Sprintf("%.4f", result.MaxDrawdown)},
+		{"sharpe", fmt.Sprintf("%.4f", result.Sharpe)},
+		{"sortino", fmt.Sprintf("%.4f", result.Sortino)},
+	}
+
+	if err := writer.WriteAll(rows); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// FormatLedgerCSV writes one row per replayed market with the stake on each
+// option joined by semicolons.
+func FormatLedgerCSV(ledger []types.LedgerEntry) (string, error) {
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+
+	header := []string{"Line", "Date", "Event", "Stakes", "Staked", "Winner", "PnL", "Bankroll", "Skipped"}
+	if err := writer.Write(header); err != nil {
+		return "", err
+	}
+
+	for _, e := range ledger {
+		stakes := make([]string, len(e.Stakes))
+		for i, s := range e.Stakes {
+			stakes[i] = fmt.Sprintf("%.2f", s.Float64())
+		}
+		row := []string{
+			fmt.Sprintf("%d", e.Line),
+			e.Date,
+			e.Event,
+			strings.Join(stakes, ";"),
+			fmt.Sprintf("%.2f", e.Staked.Float64()),
+			e.Winner,
+			fmt.Sprintf("%.2f", e.PnL.Float64()),
+			fmt.Sprintf("%.2f", e.Bankroll.Float64()),
+			e.Skipped,
+		}
+		if err := writer.Write(row); err != nil {
+			return "", err
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,56 @@
+package formatter
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/codehakase/kelly/pkg/types"
+)
+
+// BatchCSVHeader heads the CSV streamed by kelly batch, which has one row
+// per option of every market calculated.
+var BatchCSVHeader = []string{
+	"Line", "Method", "Option", "Odds", "Probability", "Stake", "Return", "Profit", "ROI",
+	"Side", "Liability", "Currency", "Expected_Value", "Guaranteed",
+}
+
+// FormatBatchCSV returns the CSV rows of the market read from the given
+// input line.
+func FormatBatchCSV(line int, result *types.CalculationResult) [][]string {
+	rows := make([][]string, len(result.Options))
+	for i, opt := range result.Options {
+		currency := opt.Currency
+		if currency == "" {
+			currency = result.Currency
+		}
+		rows[i] = []string{
+			fmt.Sprint(line),
+			string(result.Method),
+			opt.Name,
+			fmt.Sprintf("%.2f", opt.Odds),
+			fmt.Sprintf("%.4f", opt.Probability),
+			fmt.Sprintf("%.2f", opt.Stake.Float64()),
+			fmt.Sprintf("%.2f", opt.ReturnIfWins.Float64()),
+			fmt.Sprintf("%.2f", opt.ProfitIfWins.Float64()),
+			fmt.Sprintf("%.2f%%", opt.ROI*100),
+			string(sideOf(opt)),
+			fmt.Sprintf("%.2f", opt.Liability.Float64()),
+			currency,
+			fmt.Sprintf("%.2f", result.Summary.ExpectedValue.Float64()),
+			fmt.Sprint(result.Summary.GuaranteedProfit),
+		}
+	}
+	return rows
+}
+
+// FormatBatchJSON returns the result of the market read from the given
+// input line as one line of JSON, with the line number added as "line".
+func FormatBatchJSON(line int, result *types.CalculationResult) (string, error) {
+	// The result marshals itself, so an embedding struct would lose the
+	// line; it goes at the front of the result's object instead.
+	bytes, err := json.Marshal(result)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf(`{"line":%d,%s`, line, bytes[1:]), nil
+}
@@ -0,0 +1,100 @@
+package formatter
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/codehakase/kelly/internal/journal"
+	"github.com/codehakase/kelly/pkg/money"
+	"github.com/codehakase/kelly/pkg/types"
+)
+
+// FormatBetsTable renders journal entries one per line with a realized P&L
+// total for settled bets.
+func FormatBetsTable(bets []types.Bet) string {
+	if len(bets) == 0 {
+		return "No bets recorded.\n"
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%-4s %-8s %-16s %-18s %-24s %10s %10s\n",
+		"ID", "Status", "Placed", "Method", "Options", "Staked", "P&L"))
+
+	var pnl money.Amount
+	var currency string
+	for _, bet := range bets {
+		currency = bet.Result.Currency
+		profit := "-"
+		if bet.Status != types.BetPending {
+			profit = fmt.Sprintf("%s%+.2f", currency, bet.PnL.Float64())
+			pnl += bet.PnL
+		}
+		sb.WriteString(fmt.Sprintf("%-4d %-8s %-16s %-18s %-24s %10s %10s\n",
+			bet.ID, bet.Status, bet.PlacedAt.Local().Format("2006-01-02 15:04"),
+			bet.Result.Method, truncate(betOptions(bet), 24),
+			currency+journal.Staked(bet).String(), profit))
+	}
+	sb.WriteString(fmt.Sprintf("\nRealized P&L: %s%+.2f\n", currency, pnl.Float64()))
+	return sb.String()
+}
+
+func FormatBetsJSON(bets []types.Bet) (string, error) {
+	if bets == nil {
+		bets = []types.Bet{}
+	}
+	bytes, err := json.MarshalIndent(bets, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(bytes), nil
+}
+
+func FormatBetsCSV(bets []types.Bet) (string, error) {
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+
+	header := []string{"ID", "Status", "Placed_At", "Settled_At", "Method", "Options", "Staked", "Winner", "PnL"}
+	if err := writer.Write(header); err != nil {
+		return "", err
+	}
+
+	for _, bet := range bets {
+		settled := ""
+		if bet.SettledAt != nil {
+			settled = bet.SettledAt.Format(time.RFC3339)
+		}
+		row := []string{
+			fmt.Sprintf("%d", bet.ID),
+			string(bet.Status),
+			bet.PlacedAt.Format(time.RFC3339),
+			settled,
+			string(bet.Result.Method),
+			betOptions(bet),
+			journal.Staked(bet).String(),
+			bet.Winner,
+			fmt.Sprintf("%.2f", bet.PnL.Float64()),
+		}
+		if err := writer.Write(row); err != nil {
+			return "", err
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+func betOptions(bet types.Bet) string {
+	names := make([]string, len(bet.Result.Options))
+	for i, opt := range bet.Result.Options {
+		names[i] = opt.Name
+	}
+	return strings.Join(names, " / ")
+}
@@ -5,6 +5,8 @@ import (
 	"encoding/csv"
 	"encoding/json"
 	"fmt"
+	"math"
+	"sort"
 	"strings"
 
 	"github.com/codehakase/kelly/pkg/types"
@@ -21,22 +23,31 @@ func FormatTable(result *types.CalculationResult, verbose bool) string {
 
 	sb.WriteString("├─────────────────────────────────────────────────────────┤\n")
 
-	sb.WriteString(fmt.Sprintf("│ %-10s │ Odds: %.2f │ Stake: %s%-6.0f │ +%s%-6.0f │\n",
-		truncate(result.OptionA.Name, 10), result.OptionA.Odds,
-		result.Currency, result.OptionA.Stake,
-		result.Currency, result.OptionA.ProfitIfWins))
-
-	sb.WriteString(fmt.Sprintf("│ %-10s │ Odds: %.2f │ Stake: %s%-6.0f │ +%s%-6.0f │\n",
-		truncate(result.OptionB.Name, 10), result.OptionB.Odds,
-		result.Currency, result.OptionB.Stake,
-		result.Currency, result.OptionB.ProfitIfWins))
+	for _, opt := range result.Options {
+		if opt.Side == types.SideLay {
+			sb.WriteString(fmt.Sprintf("│ %-10s │ Lay:  %-4s │ Liab:  %s%-6.0f │ +%s%-6.0f │\n",
+				truncate(opt.Name, 10), displayOdds(result, opt.Odds),
+				result.Currency, opt.Liability.Float64(),
+				result.Currency, opt.ProfitIfWins.Float64()))
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("│ %-10s │ Odds: %-4s │ Stake: %s%-6.0f │ +%s%-6.0f │\n",
+			truncate(opt.Name, 10), displayOdds(result, opt.Odds),
+			stakeCurrency(result, opt), opt.Stake.Float64(),
+			result.Currency, opt.ProfitIfWins.Float64()))
+		if opt.GrossReturn > 0 {
+			sb.WriteString(fmt.Sprintf("│ %-10s │ Gross: %s%-7.0f │ Net: %s%-7.0f │\n",
+				"", stakeCurrency(result, opt), opt.GrossReturn.Float64(),
+				stakeCurrency(result, opt), opt.ReturnIfWins.Float64()))
+		}
+	}
 
 	sb.WriteString("├─────────────────────────────────────────────────────────┤\n")
 
-	sb.WriteString(fmt.Sprintf("│ Total: %s%-5.0f │ Profit: %s%-5.0f-%s%-5.0f │ ROI: %.0f-%.0f%% │\n",
-		result.Currency, result.TotalStake,
-		result.Currency, result.Summary.MinProfit,
-		result.Currency, result.Summary.MaxProfit,
+	sb.WriteString(fmt.Sprintf("│ Total: %s%-5.2f │ Profit: %s%-5.2f-%s%-5.2f │ ROI: %.0f-%.0f%% │\n",
+		result.Currency, result.TotalStake.Float64(),
+		result.Currency, result.Summary.MinProfit.Float64(),
+		result.Currency, result.Summary.MaxProfit.Float64(),
 		result.Summary.MinROI*100, result.Summary.MaxROI*100))
 
 	sb.WriteString("╰─────────────────────────────────────────────────────────╯\n")
@@ -60,25 +71,245 @@ func formatVerbose(result *types.CalculationResult) string {
 	case types.MethodKelly:
 		sb.WriteString("Kelly Criterion (Growth Optimization)\n")
 		sb.WriteString("  Maximizes long-term growth based on probability estimates.\n")
-	case types.MethodProportional:
-		sb.WriteString("Proportional (Simple Allocation)\n")
-		sb.WriteString("  Allocates stakes inversely to odds.\n")
+	case types.MethodKellySimultaneous:
+		sb.WriteString("Simultaneous Kelly (Joint Growth Optimization)\n")
+		sb.WriteString("  Maximizes expected log-wealth across all outcomes together.\n")
+	case types.MethodMatched:
+		sb.WriteString("Matched Betting (Back/Lay Conversion)\n")
+		sb.WriteString("  Lays the bookmaker bet on an exchange to lock in the same result either way.\n")
+	case types.MethodHedge:
+		sb.WriteString("Hedge (Cash Out)\n")
+		sb.WriteString("  Bets against an open position to lock in profit or cut losses.\n")
+	case types.MethodEachWay:
+		sb.WriteString("Each-Way (Win and Place)\n")
+		sb.WriteString("  Splits the stake between a win bet and a place bet at a fraction of the odds.\n")
+	case types.MethodParlay:
+		sb.WriteString("Parlay (Accumulator / System)\n")
+		sb.WriteString("  Combines independent legs into lines that win only if every leg wins.\n")
 	}
 
 	sb.WriteString("\nℹ Allocation:\n")
-	sb.WriteString(fmt.Sprintf("  - %s: %.2f%%\n", result.OptionA.Name, (result.OptionA.Stake/result.TotalStake)*100))
-	sb.WriteString(fmt.Sprintf("  - %s: %.2f%%\n", result.OptionB.Name, (result.OptionB.Stake/result.TotalStake)*100))
+	for _, opt := range result.Options {
+		if opt.Side == types.SideLay {
+			sb.WriteString(fmt.Sprintf("  - %s: lay %s%.2f at %s, liability %s%.2f (%.2f%%)\n",
+				opt.Name, result.Currency, opt.Stake.Float64(), displayOdds(result, opt.Odds),
+				result.Currency, opt.Liability.Float64(), (opt.Liability.Float64()/result.TotalStake.Float64())*100))
+		} else if fx := result.Summary.FX; fx != nil && opt.Currency != fx.Base {
+			cost := opt.Stake.Float64() * fx.Rates[opt.Currency].Buy
+			sb.WriteString(fmt.Sprintf("  - %s: %s%.2f, costing %s%.2f (%.2f%%)\n", opt.Name,
+				stakeCurrency(result, opt), opt.Stake.Float64(), result.Currency, cost, (cost/result.TotalStake.Float64())*100))
+		} else {
+			sb.WriteString(fmt.Sprintf("  - %s: %.2f%%\n", opt.Name, (opt.Stake.Float64()/result.TotalStake.Float64())*100))
+		}
+		if opt.Commission > 0 {
+			sb.WriteString(fmt.Sprintf("    %.2f%% commission, net profit if it wins: %s%.2f\n",
+				opt.Commission*100, result.Currency, opt.ProfitIfWins.Float64()))
+		}
+		if opt.GrossReturn > 0 {
+			sb.WriteString(fmt.Sprintf("    Returns %s%.2f gross, %s%.2f net of %s\n",
+				stakeCurrency(result, opt), opt.GrossReturn.Float64(),
+				stakeCurrency(result, opt), opt.ReturnIfWins.Float64(), taxDescription(opt)))
+		}
+	}
+
+	if result.Method == types.MethodMatched {
+		switch result.Summary.BetType {
+		case types.BetFreeSNR, types.BetFreeSR:
+			sb.WriteString(fmt.Sprintf("  - Free bet (%s) extraction: %s%.2f (%.2f%% of the free bet)\n",
+				result.Summary.BetType, result.Currency, result.Summary.MinProfit.Float64(), result.Summary.ExtractionRate*100))
+		default:
+			sb.WriteString(fmt.Sprintf("  - Qualifying loss: %s%.2f\n", result.Currency, -result.Summary.MinProfit.Float64()))
+		}
+	}
+	if result.Method == types.MethodHedge {
+		sb.WriteString(fmt.Sprintf("  - Hedge target: %s\n", hedgeTargetDescription(result.Summary.HedgeTarget)))
+	}
+	if ew := result.Summary.EachWay; ew != nil {
+		sb.WriteString(fmt.Sprintf("  - Each-way on %s: %s odds, %d places (place odds %s)\n",
+			ew.Selection, placeTerms(ew.PlaceFraction), ew.Places, displayOdds(result, ew.PlaceOdds)))
+		sb.WriteString(fmt.Sprintf("  - If it wins: %s%.2f, places: %s%.2f, loses: %s%.2f\n",
+			result.Currency, ew.IfWins.Float64(), result.Currency, ew.IfPlaces.Float64(), result.Currency, ew.IfLoses.Float64()))
+	}
+	if p := result.Summary.Parlay; p != nil {
+		lines := "lines"
+		if p.Lines == 1 {
+			lines = "line"
+		}
+		sb.WriteString(fmt.Sprintf("  - System: %s, %d %s of %s%.2f\n",
+			p.System, p.Lines, lines, result.Currency, p.UnitStake.Float64()))
+		for i, leg := range p.Selections {
+			label := strings.TrimPrefix(types.OptionLabel(i), "Option ")
+			sb.WriteString(fmt.Sprintf("    %s. %s at %s\n", label, leg.Name, displayOdds(result, leg.Odds)))
+		}
+		if p.KellyFraction > 0 {
+			sb.WriteString(fmt.Sprintf("  - Kelly-optimal unit stake: %s%.2f per line (%.2f%% of bankroll)\n",
+				result.Currency, p.KellyStake.Float64(), p.KellyFraction*100))
+		} else {
+			sb.WriteString("  - Kelly-optimal unit stake: none, the lines have no edge\n")
+		}
+	}
+	if a := result.Summary.Asian; a != nil {
+		if a.Market == types.AsianTotal {
+			sb.WriteString(fmt.Sprintf("  - Goal total %g: %s over, %s under\n",
+				a.Line, result.Options[0].Name, result.Options[1].Name))
+		} else {
+			sb.WriteString(fmt.Sprintf("  - Asian handicap: %s %+g, %s %+g\n",
+				result.Options[0].Name, a.Line, result.Options[1].Name, -a.Line))
+		}
+		outcomes := fmt.Sprintf("  - If A clears the line: %s%.2f, B clears it: %s%.2f",
+			result.Currency, a.IfAWins.Float64(), result.Currency, a.IfBWins.Float64())
+		if a.Split {
+			outcomes += fmt.Sprintf(", lands on it (A %s, B %s): %s%.2f",
+				a.SplitA, a.SplitB, result.Currency, a.IfSplit.Float64())
+		}
+		sb.WriteString(outcomes + "\n")
+	}
+	if fx := result.Summary.FX; fx != nil {
+		codes := make([]string, 0, len(fx.Rates))
+		for code := range fx.Rates {
+			if code != fx.Base {
+				codes = append(codes, code)
+			}
+		}
+		sort.Strings(codes)
+		for _, code := range codes {
+			rate := fx.Rates[code]
+			sb.WriteString(fmt.Sprintf("  - %s: buying costs %s%.6g, selling fetches %s%.6g\n",
+				code, result.Currency, rate.Buy, result.Currency, rate.Sell))
+		}
+		sb.WriteString(fmt.Sprintf("  - Profits in %s, after %s%.2f of FX spread against mid rates\n",
+			fx.Base, result.Currency, fx.SpreadCost.Float64()))
+	}
+	if result.Summary.FairFrom != "" {
+		sb.WriteString(fmt.Sprintf("  - Probabilities: fair, %s margin removal\n", result.Summary.FairFrom))
+	}
+	if result.Method == types.MethodKellySimultaneous {
+		sb.WriteString(fmt.Sprintf("  - Cash reserve: %s%.2f\n", result.Currency, result.Summary.CashReserve.Float64()))
+		sb.WriteString(fmt.Sprintf("  - Expected log growth: %.6f per bet\n", result.Summary.GrowthRate))
+	}
+	if result.Summary.RoundMode != "" {
+		measure := "guaranteed profit"
+		if result.Method.RequiresProbabilities() {
+			measure = "expected value"
+		}
+		policy := fmt.Sprintf("%s to %s%g", result.Summary.RoundMode, result.Currency, result.Summary.RoundTo)
+		if result.Summary.FX != nil {
+			policy = fmt.Sprintf("%s to %g in each book's currency", result.Summary.RoundMode, result.Summary.RoundTo)
+		}
+		if result.Summary.RoundMode == types.RoundStealth {
+			policy = "to stealth amounts"
+		}
+		effect := "costing"
+		if result.Summary.RoundingCost < 0 {
+			effect = "gaining"
+		}
+		sb.WriteString(fmt.Sprintf("  - Stakes rounded %s, %s %s%.2f of %s\n",
+			policy, effect, result.Currency, math.Abs(result.Summary.RoundingCost.Float64()), measure))
+	}
+
+	var bound []string
+	for _, opt := range result.Options {
+		switch opt.BindingConstraint {
+		case "":
+		case types.ConstraintBookMax:
+			bound = append(bound, fmt.Sprintf("  - %s: capped at its maximum stake of %s%.2f\n",
+				opt.Name, stakeCurrency(result, opt), opt.MaxStake.Float64()))
+		case types.ConstraintBookMin:
+			bound = append(bound, fmt.Sprintf("  - %s: skipped, stake below its minimum of %s%.2f\n",
+				opt.Name, stakeCurrency(result, opt), opt.MinStake.Float64()))
+		default:
+			bound = append(bound, fmt.Sprintf("  - %s: %s\n", opt.Name, constraintDescription(opt.BindingConstraint)))
+		}
+	}
+	if result.Summary.RequestedTotal > 0 {
+		bound = append(bound, fmt.Sprintf("  - Position scaled down from %s%.2f to %s%.2f to fit\n",
+			result.Currency, result.Summary.RequestedTotal.Float64(), result.Currency, result.TotalStake.Float64()))
+	}
+	if len(bound) > 0 {
+		sb.WriteString("\nℹ Binding constraints:\n")
+		sb.WriteString(strings.Join(bound, ""))
+	}
 
 	sb.WriteString("\n⚠ Risk:\n")
+	risk := "No guaranteed profit"
 	if result.Summary.GuaranteedProfit {
-		sb.WriteString(fmt.Sprintf("  - Guaranteed profit (efficiency: %.2f%%)\n", result.Summary.MarketEfficiency*100))
-	} else {
-		sb.WriteString(fmt.Sprintf("  - No guaranteed profit (efficiency: %.2f%%)\n", result.Summary.MarketEfficiency*100))
+		risk = "Guaranteed profit"
+	}
+	if result.Summary.MarketEfficiency > 0 {
+		risk += fmt.Sprintf(" (efficiency: %.2f%%)", result.Summary.MarketEfficiency*100)
 	}
+	sb.WriteString("  - " + risk + "\n")
 
 	return sb.String()
 }
 
+// placeTerms formats a place fraction as the usual "1/4", falling back to
+// a decimal for terms that are not one over a whole number.
+func placeTerms(fraction float64) string {
+	if n := 1 / fraction; math.Abs(n-math.Round(n)) < 1e-9 {
+		return fmt.Sprintf("1/%.0f", n)
+	}
+	return fmt.Sprintf("%g", fraction)
+}
+
+// taxDescription names the winnings tax and stake excise an option's book
+// charges.
+func taxDescription(opt types.Option) string {
+	var charges []string
+	if opt.TaxOnWinnings > 0 {
+		charges = append(charges, fmt.Sprintf("%.2f%% winnings tax", opt.TaxOnWinnings*100))
+	}
+	if opt.StakeExcise > 0 {
+		charges = append(charges, fmt.Sprintf("%.2f%% stake excise", opt.StakeExcise*100))
+	}
+	return strings.Join(charges, " and ")
+}
+
+func hedgeTargetDescription(target types.HedgeTarget) string {
+	switch target {
+	case types.HedgeFreeBet:
+		return "free bet on the position, stake recovered if the hedge wins"
+	case types.HedgeBreakEven:
+		return "break even if the position wins, profit on the hedge"
+	default:
+		return "equal profit either way"
+	}
+}
+
+func constraintDescription(c types.Constraint) string {
+	switch c {
+	case types.ConstraintMinEdge:
+		return "skipped, edge below minimum"
+	case types.ConstraintKellyFraction:
+		return "scaled by Kelly fraction"
+	case types.ConstraintMaxStake:
+		return "capped at maximum stake"
+	case types.ConstraintBankroll:
+		return "scaled down to fit the total"
+	default:
+		return string(c)
+	}
+}
+
+// hasExchangeTerms reports whether some option is a lay or pays
+// commission, which the CSV shows in its Side, Liability and Commission
+// columns.
+func hasExchangeTerms(result *types.CalculationResult) bool {
+	for _, opt := range result.Options {
+		if opt.Side == types.SideLay || opt.Commission > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func sideOf(opt types.Option) types.Side {
+	if opt.Side == "" {
+		return types.SideBack
+	}
+	return opt.Side
+}
+
 func truncate(s string, maxLen int) string {
 	if len(s) <= maxLen {
 		return s
@@ -89,6 +320,15 @@ func truncate(s string, maxLen int) string {
 	return s[:maxLen-3] + "..."
 }
 
+// stakeCurrency returns the symbol of the currency an option's stake is in:
+// its book's in a market across currencies, the result's otherwise.
+func stakeCurrency(result *types.CalculationResult, opt types.Option) string {
+	if result.Summary.FX != nil && opt.Currency != "" {
+		return types.CurrencySymbol(opt.Currency)
+	}
+	return result.Currency
+}
+
 func FormatJSON(result *types.CalculationResult) (string, error) {
 	bytes, err := json.MarshalIndent(result, "", "  ")
 	if err != nil {
@@ -101,35 +341,39 @@ func FormatCSV(result *types.CalculationResult) (string, error) {
 	var buf bytes.Buffer
 	writer := csv.NewWriter(&buf)
 
+	exchange := hasExchangeTerms(result)
 	header := []string{"Option", "Odds", "Implied_Prob", "Stake", "Return", "Profit", "ROI"}
-	if err := writer.Write(header); err != nil {
-		return "", err
+	if exchange {
+		header = append(header, "Side", "Liability", "Commission")
 	}
-
-	rowA := []string{
-		result.OptionA.Name,
-		fmt.Sprintf("%.2f", result.OptionA.Odds),
-		fmt.Sprintf("%.2f%%", result.OptionA.ImpliedProbability*100),
-		fmt.Sprintf("%.0f", result.OptionA.Stake),
-		fmt.Sprintf("%.0f", result.OptionA.ReturnIfWins),
-		fmt.Sprintf("%.0f", result.OptionA.ProfitIfWins),
-		fmt.Sprintf("%.2f%%", result.OptionA.ROI*100),
+	if result.Summary.FX != nil {
+		header = append(header, "Currency")
 	}
-	if err := writer.Write(rowA); err != nil {
+	if err := writer.Write(header); err != nil {
 		return "", err
 	}
 
-	rowB := []string{
-		result.OptionB.Name,
-		fmt.Sprintf("%.2f", result.OptionB.Odds),
-		fmt.Sprintf("%.2f%%", result.OptionB.ImpliedProbability*100),
-		fmt.Sprintf("%.0f", result.OptionB.Stake),
-		fmt.Sprintf("%.0f", result.OptionB.ReturnIfWins),
-		fmt.Sprintf("%.0f", result.OptionB.ProfitIfWins),
-		fmt.Sprintf("%.2f%%", result.OptionB.ROI*100),
-	}
-	if err := writer.Write(rowB); err != nil {
-		return "", err
+	for _, opt := range result.Options {
+		row := []string{
+			opt.Name,
+			displayOdds(result, opt.Odds),
+			fmt.Sprintf("%.2f%%", opt.ImpliedProbability*100),
+			fmt.Sprintf("%.2f", opt.Stake.Float64()),
+			fmt.Sprintf("%.2f", opt.ReturnIfWins.Float64()),
+			fmt.Sprintf("%.2f", opt.ProfitIfWins.Float64()),
+			fmt.Sprintf("%.2f%%", opt.ROI*100),
+		}
+		if exchange {
+			row = append(row, string(sideOf(opt)),
+				fmt.Sprintf("%.2f", opt.Liability.Float64()),
+				fmt.Sprintf("%.2f%%", opt.Commission*100))
+		}
+		if result.Summary.FX != nil {
+			row = append(row, opt.Currency)
+		}
+		if err := writer.Write(row); err != nil {
+			return "", err
+		}
 	}
 
 	writer.Flush()
@@ -4,7 +4,9 @@ import (
 	"encoding/json"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/codehakase/kelly/pkg/money"
 	"github.com/codehakase/kelly/pkg/types"
 )
 
@@ -12,31 +14,33 @@ import (
 func sampleResult() *types.CalculationResult {
 	return &types.CalculationResult{
 		Method:     types.MethodArbitrage,
-		TotalStake: 10000,
+		TotalStake: money.FromFloat(10000),
 		Currency:   "₦",
-		OptionA: types.Option{
-			Name:               "Davido - With You",
-			Odds:               2.56,
-			ImpliedProbability: 0.3906,
-			Stake:              6463,
-			ReturnIfWins:       16545,
-			ProfitIfWins:       6545,
-			ROI:                0.6545,
-		},
-		OptionB: types.Option{
-			Name:               "Tyla - PUSH 2 START",
-			Odds:               3.85,
-			ImpliedProbability: 0.2597,
-			Stake:              3537,
-			ReturnIfWins:       13617,
-			ProfitIfWins:       3617,
-			ROI:                0.3617,
+		Options: []types.Option{
+			{
+				Name:               "Davido - With You",
+				Odds:               2.56,
+				ImpliedProbability: 0.3906,
+				Stake:              money.FromFloat(6463),
+				ReturnIfWins:       money.FromFloat(16545),
+				ProfitIfWins:       money.FromFloat(6545),
+				ROI:                0.6545,
+			},
+			{
+				Name:               "Tyla - PUSH 2 START",
+				Odds:               3.85,
+				ImpliedProbability: 0.2597,
+				Stake:              money.FromFloat(3537),
+				ReturnIfWins:       money.FromFloat(13617),
+				ProfitIfWins:       money.FromFloat(3617),
+				ROI:                0.3617,
+			},
 		},
 		Summary: types.Summary{
 			GuaranteedProfit: true,
-			MinProfit:        3617,
-			MaxProfit:        6545,
-			ExpectedValue:    5081,
+			MinProfit:        money.FromFloat(3617),
+			MaxProfit:        money.FromFloat(6545),
+			ExpectedValue:    money.FromFloat(5081),
 			MinROI:           0.3617,
 			MaxROI:           0.6545,
 			MarketEfficiency: 0.6503,
@@ -113,17 +117,42 @@ func TestFormatJSON(t *testing.T) {
 	}
 
 	if parsed.TotalStake != result.TotalStake {
-		t.Errorf("TotalStake = %.2f, want %.2f", parsed.TotalStake, result.TotalStake)
+		t.Errorf("TotalStake = %.2f, want %.2f", parsed.TotalStake.Float64(), result.TotalStake.Float64())
 	}
 
-	if parsed.OptionA.Name != result.OptionA.Name {
-		t.Errorf("OptionA.Name = %s, want %s", parsed.OptionA.Name, result.OptionA.Name)
+	if parsed.Options[0].Name != result.Options[0].Name {
+		t.Errorf("OptionA.Name = %s, want %s", parsed.Options[0].Name, result.Options[0].Name)
 	}
 
 	// Check formatting (should have indentation)
 	if !strings.Contains(jsonStr, "  ") {
 		t.Error("JSON should be indented")
 	}
+
+	// Two options are also written under the original option_a and
+	// option_b keys
+	var legacy struct {
+		OptionA types.Option `json:"option_a"`
+		OptionB types.Option `json:"option_b"`
+	}
+	if err := json.Unmarshal([]byte(jsonStr), &legacy); err != nil {
+		t.Fatalf("FormatJSON() produced invalid JSON: %v", err)
+	}
+	if legacy.OptionA.Name != result.Options[0].Name || legacy.OptionB.Stake != result.Options[1].Stake {
+		t.Errorf("option_a, option_b = %+v, %+v; want the two options", legacy.OptionA, legacy.OptionB)
+	}
+	if err := json.Unmarshal([]byte(`{"option_a": {"name": "Home"}, "option_b": {"name": "Away"}}`), &parsed); err != nil {
+		t.Fatalf("Unmarshal() error: %v", err)
+	}
+	if len(parsed.Options) != 2 || parsed.Options[1].Name != "Away" {
+		t.Errorf("Unmarshal() of option_a and option_b = %+v, want two options", parsed.Options)
+	}
+
+	three := sampleResult()
+	three.Options = append(three.Options, types.Option{Name: "Draw"})
+	if jsonStr, _ := FormatJSON(three); strings.Contains(jsonStr, "option_a") {
+		t.Error("FormatJSON() should only write option_a and option_b for two options")
+	}
 }
 
 func TestFormatCSV(t *testing.T) {
@@ -160,12 +189,68 @@ func TestFormatCSV(t *testing.T) {
 
 	// Check that data rows contain option names
 	csvContent := strings.Join(lines, "\n")
-	if !strings.Contains(csvContent, result.OptionA.Name) {
-		t.Errorf("CSV should contain Option A name: %s", result.OptionA.Name)
+	if !strings.Contains(csvContent, result.Options[0].Name) {
+		t.Errorf("CSV should contain Option A name: %s", result.Options[0].Name)
+	}
+
+	if !strings.Contains(csvContent, result.Options[1].Name) {
+		t.Errorf("CSV should contain Option B name: %s", result.Options[1].Name)
 	}
 
-	if !strings.Contains(csvContent, result.OptionB.Name) {
-		t.Errorf("CSV should contain Option B name: %s", result.OptionB.Name)
+	// Exchange columns only appear when some option is a lay or pays commission
+	if lines[0] != "Option,Odds,Implied_Prob,Stake,Return,Profit,ROI" {
+		t.Errorf("CSV header = %s, want the seven back-bet columns", lines[0])
+	}
+
+	// Money columns keep their cents
+	if !strings.Contains(lines[1], ",6463.00,16545.00,6545.00,") {
+		t.Errorf("CSV should show stake, return and profit to the cent, got: %s", lines[1])
+	}
+}
+
+func TestFormatCSV_ThreeOptions(t *testing.T) {
+	result := sampleResult()
+	result.Options = append(result.Options, types.Option{Name: "Draw", Odds: 3.4, Stake: money.FromFloat(1000)})
+
+	csvStr, err := FormatCSV(result)
+	if err != nil {
+		t.Fatalf("FormatCSV() error: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(csvStr), "\n")
+	if len(lines) != 4 {
+		t.Errorf("CSV should have 4 lines, got %d", len(lines))
+	}
+	if !strings.Contains(lines[3], "Draw") {
+		t.Errorf("last CSV row should be the third option, got %q", lines[3])
+	}
+
+	table := FormatTable(result, false)
+	if !strings.Contains(table, "Draw") {
+		t.Error("Table should contain a row for the third option")
+	}
+}
+
+func TestFormat_LayOption(t *testing.T) {
+	result := sampleResult()
+	result.Options[1].Side = types.SideLay
+	result.Options[1].Commission = 0.02
+	result.Options[1].Liability = money.FromFloat(9999)
+
+	table := FormatTable(result, true)
+	if !strings.Contains(table, "Lay:") || !strings.Contains(table, "9999") {
+		t.Error("Table should show lay odds and liability for a lay option")
+	}
+	if !strings.Contains(table, "2.00% commission") {
+		t.Error("Verbose output should show commission")
+	}
+
+	csvStr, err := FormatCSV(result)
+	if err != nil {
+		t.Fatalf("FormatCSV() error: %v", err)
+	}
+	if !strings.Contains(csvStr, "lay,9999.00,2.00%") {
+		t.Errorf("CSV should contain side, liability and commission, got:\n%s", csvStr)
 	}
 }
 
@@ -213,3 +298,358 @@ func TestTruncate(t *testing.T) {
 		})
 	}
 }
+
+func TestFormatBets(t *testing.T) {
+	placed := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	bets := []types.Bet{
+		{ID: 1, Status: types.BetSettled, Result: *sampleResult(), Winner: "A", PnL: money.FromFloat(6545), PlacedAt: placed, SettledAt: &placed},
+		{ID: 2, Status: types.BetPending, Result: *sampleResult(), PlacedAt: placed},
+	}
+
+	table := FormatBetsTable(bets)
+	if !strings.Contains(table, "settled") || !strings.Contains(table, "pending") {
+		t.Error("Bet table should show each bet's status")
+	}
+	if !strings.Contains(table, "Realized P&L: ₦+6545.00") {
+		t.Errorf("Bet table should total realized P&L of settled bets, got:\n%s", table)
+	}
+	if !strings.Contains(FormatBetsTable(nil), "No bets") {
+		t.Error("Empty bet table should say there are no bets")
+	}
+
+	csvStr, err := FormatBetsCSV(bets)
+	if err != nil {
+		t.Fatalf("FormatBetsCSV() error: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(csvStr), "\n")
+	if len(lines) != 3 {
+		t.Errorf("CSV should have 3 lines, got %d", len(lines))
+	}
+	if !strings.Contains(lines[1], "10000.00,A,6545.00") {
+		t.Errorf("CSV row should contain staked, winner and P&L, got %q", lines[1])
+	}
+
+	jsonStr, err := FormatBetsJSON(bets)
+	if err != nil {
+		t.Fatalf("FormatBetsJSON() error: %v", err)
+	}
+	var parsed []types.Bet
+	if err := json.Unmarshal([]byte(jsonStr), &parsed); err != nil || len(parsed) != 2 {
+		t.Fatalf("FormatBetsJSON() produced invalid JSON: %v", err)
+	}
+}
+
+func TestFormatSimulation(t *testing.T) {
+	result := &types.SimulationResult{
+		Method: types.MethodKelly, Currency: "$", StartingBankroll: money.FromFloat(1000),
+		Runs: 100, Bets: 50, Seed: 42, RuinThreshold: 0.01,
+		FinalBankroll:   types.Distribution{Mean: 1100, P5: 800, P25: 950, Median: 1080, P75: 1200, P95: 1500},
+		MaxDrawdown:     types.Distribution{Mean: 0.2, P5: 0.1, P25: 0.15, Median: 0.18, P75: 0.25, P95: 0.4},
+		RuinProbability: 0.03,
+	}
+
+	table := FormatSimulationTable(result)
+	if !strings.Contains(table, "1080.00") || !strings.Contains(table, "Probability of ruin:   3.00%") {
+		t.Errorf("Simulation table should show median bankroll and ruin, got:\n%s", table)
+	}
+
+	csvStr, err := FormatSimulationCSV(result)
+	if err != nil {
+		t.Fatalf("FormatSimulationCSV() error: %v", err)
+	}
+	if !strings.Contains(csvStr, "final_bankroll_median,1080.00") || !strings.Contains(csvStr, "ruin_probability,0.0300") {
+		t.Errorf("Simulation CSV missing metrics, got:\n%s", csvStr)
+	}
+
+	jsonStr, err := FormatSimulationJSON(result)
+	if err != nil {
+		t.Fatalf("FormatSimulationJSON() error: %v", err)
+	}
+	var parsed types.SimulationResult
+	if err := json.Unmarshal([]byte(jsonStr), &parsed); err != nil || parsed.Seed != 42 {
+		t.Fatalf("FormatSimulationJSON() produced invalid JSON: %v", err)
+	}
+}
+
+func TestFormatBacktest(t *testing.T) {
+	result := &types.BacktestResult{
+		Method: types.MethodKelly, Currency: "$", StartingBankroll: money.FromFloat(1000), FinalBankroll: money.FromFloat(960),
+		Rows: 3, Bets: 2, Winners: 1, Skipped: 1, Profit: money.FromFloat(-40), TotalStaked: money.FromFloat(440), ROI: -0.04, Yield: -0.0909,
+		Ledger: []types.LedgerEntry{
+			{Line: 2, Date: "2024-01-06", Event: "Arsenal v Spurs", Stakes: []money.Amount{money.FromFloat(200), 0}, Staked: money.FromFloat(200), Winner: "A", PnL: money.FromFloat(200), Bankroll: money.FromFloat(1200)},
+			{Line: 3, Bankroll: money.FromFloat(1200), Skipped: "odds: invalid"},
+		},
+	}
+
+	table := FormatBacktestTable(result)
+	if !strings.Contains(table, "$1000.00 → $960.00") || !strings.Contains(table, "Yield:         -9.09%") {
+		t.Errorf("Backtest table missing bankroll or yield, got:\n%s", table)
+	}
+
+	csvStr, err := FormatBacktestCSV(result)
+	if err != nil {
+		t.Fatalf("FormatBacktestCSV() error: %v", err)
+	}
+	if !strings.Contains(csvStr, "profit,-40.00") {
+		t.Errorf("Backtest CSV missing profit, got:\n%s", csvStr)
+	}
+
+	ledger, err := FormatLedgerCSV(result.Ledger)
+	if err != nil {
+		t.Fatalf("FormatLedgerCSV() error: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(ledger), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Ledger CSV should have 3 lines, got %d", len(lines))
+	}
+	if lines[1] != "2,2024-01-06,Arsenal v Spurs,200.00;0.00,200.00,A,200.00,1200.00," {
+		t.Errorf("Ledger row = %q", lines[1])
+	}
+	if !strings.HasSuffix(lines[2], "odds: invalid") {
+		t.Errorf("Ledger should record why a row was skipped, got %q", lines[2])
+	}
+}
+
+func TestFormatScan(t *testing.T) {
+	arb := sampleResult()
+	arb.Options[0].Bookmaker = "bet9ja"
+	arb.Options[1].Bookmaker = "sportybet"
+	result := &types.ScanResult{
+		Books: 2, Events: 5,
+		Arbitrages: []types.ScanEvent{{Event: "Headies 2025", Result: *arb}},
+	}
+
+	table := FormatScanTable(result, false)
+	if !strings.Contains(table, "Scanned 5 events across 2 books: 1 arbitrage") {
+		t.Errorf("Scan table missing summary, got:\n%s", table)
+	}
+	if !strings.Contains(table, "Headies 2025") || !strings.Contains(table, "with sportybet") {
+		t.Error("Scan table should show each event and the bookmaker of each leg")
+	}
+
+	csvStr, err := FormatScanCSV(result)
+	if err != nil {
+		t.Fatalf("FormatScanCSV() error: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(csvStr), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[1], "Headies 2025,Davido - With You,bet9ja,2.56") {
+		t.Errorf("Scan CSV = %q", lines)
+	}
+}
+
+func TestFormatVerbose_Rounding(t *testing.T) {
+	result := sampleResult()
+	result.Summary.RoundTo = 5
+	result.Summary.RoundMode = types.RoundNearest
+	result.Summary.RoundingCost = money.FromFloat(1.25)
+
+	table := FormatTable(result, true)
+	if !strings.Contains(table, "Stakes rounded nearest to ₦5, costing ₦1.25 of guaranteed profit") {
+		t.Errorf("Verbose output should report the rounding cost, got:\n%s", table)
+	}
+
+	result.Summary.RoundMode = types.RoundStealth
+	result.Summary.RoundingCost = money.FromFloat(-2)
+	table = FormatTable(result, true)
+	if !strings.Contains(table, "Stakes rounded to stealth amounts, gaining ₦2.00") {
+		t.Errorf("Verbose output should report a rounding gain, got:\n%s", table)
+	}
+}
+
+func TestFormatVerbose_StakeLimits(t *testing.T) {
+	result := sampleResult()
+	result.Options[0].MaxStake = money.FromFloat(500)
+	result.Options[0].BindingConstraint = types.ConstraintBookMax
+	result.Summary.RequestedTotal = money.FromFloat(10000)
+	result.TotalStake = money.FromFloat(1242.50)
+
+	table := FormatTable(result, true)
+	for _, want := range []string{
+		"capped at its maximum stake of ₦500.00",
+		"Position scaled down from ₦10000.00 to ₦1242.50 to fit",
+	} {
+		if !strings.Contains(table, want) {
+			t.Errorf("Verbose output should contain %q, got:\n%s", want, table)
+		}
+	}
+}
+
+func TestFormatVerbose_Hedge(t *testing.T) {
+	result := sampleResult()
+	result.Method = types.MethodHedge
+	result.Summary.HedgeTarget = types.HedgeFreeBet
+
+	table := FormatTable(result, true)
+	for _, want := range []string{"Hedge (Cash Out)", "Hedge target: free bet on the position"} {
+		if !strings.Contains(table, want) {
+			t.Errorf("Verbose output should contain %q, got:\n%s", want, table)
+		}
+	}
+}
+
+func TestFormatVerbose_EachWay(t *testing.T) {
+	result := sampleResult()
+	result.Method = types.MethodEachWay
+	result.Summary.MarketEfficiency = 0
+	result.Summary.GuaranteedProfit = false
+	result.Summary.EachWay = &types.EachWayOutcome{
+		Selection: "Frankel", PlaceFraction: 0.2, Places: 4, PlaceOdds: 2.6,
+		IfWins: money.FromFloat(56), IfPlaces: money.FromFloat(-4), IfLoses: money.FromFloat(-20),
+	}
+
+	table := FormatTable(result, true)
+	for _, want := range []string{
+		"Each-Way (Win and Place)",
+		"Each-way on Frankel: 1/5 odds, 4 places (place odds 2.60)",
+		"If it wins: ₦56.00, places: ₦-4.00, loses: ₦-20.00",
+		"  - No guaranteed profit\n",
+	} {
+		if !strings.Contains(table, want) {
+			t.Errorf("Verbose output should contain %q, got:\n%s", want, table)
+		}
+	}
+}
+
+func TestFormatVerbose_Parlay(t *testing.T) {
+	result := sampleResult()
+	result.Method = types.MethodParlay
+	result.Summary.MarketEfficiency = 0
+	result.Summary.GuaranteedProfit = false
+	result.Summary.Parlay = &types.ParlaySummary{
+		System: "trixie", Lines: 4, UnitStake: money.FromFloat(2.5), KellyFraction: 0.0123, KellyStake: money.FromFloat(12.3),
+		Selections: []types.Option{{Name: "Arsenal", Odds: 1.8}, {Name: "Chelsea", Odds: 2.1}, {Name: "Spurs", Odds: 1.9}},
+	}
+
+	table := FormatTable(result, true)
+	for _, want := range []string{
+		"Parlay (Accumulator / System)",
+		"System: trixie, 4 lines of ₦2.50",
+		"B. Chelsea at 2.10",
+		"Kelly-optimal unit stake: ₦12.30 per line (1.23% of bankroll)",
+	} {
+		if !strings.Contains(table, want) {
+			t.Errorf("Verbose output should contain %q, got:\n%s", want, table)
+		}
+	}
+
+	result.Summary.Parlay.KellyFraction = 0
+	if table := FormatTable(result, true); !strings.Contains(table, "none, the lines have no edge") {
+		t.Errorf("Verbose output should say there is no Kelly stake, got:\n%s", table)
+	}
+}
+
+func TestFormatVerbose_Asian(t *testing.T) {
+	result := sampleResult()
+	result.Summary.Asian = &types.AsianOutcome{
+		Market: types.AsianHandicap, Line: -0.25, Split: true,
+		SplitA: types.LineHalfLoss, SplitB: types.LineHalfWin,
+		IfAWins: money.FromFloat(7.31), IfSplit: money.FromFloat(7.32), IfBWins: money.FromFloat(14.64),
+	}
+
+	table := FormatTable(result, true)
+	for _, want := range []string{
+		"Asian handicap: Davido - With You -0.25, Tyla - PUSH 2 START +0.25",
+		"If A clears the line: ₦7.31, B clears it: ₦14.64, lands on it (A half-loss, B half-win): ₦7.32",
+	} {
+		if !strings.Contains(table, want) {
+			t.Errorf("Verbose output should contain %q, got:\n%s", want, table)
+		}
+	}
+
+	result.Summary.Asian = &types.AsianOutcome{Market: types.AsianTotal, Line: 2.5, IfAWins: money.FromFloat(17.44), IfBWins: money.FromFloat(17.45)}
+	table = FormatTable(result, true)
+	if !strings.Contains(table, "Goal total 2.5: Davido - With You over, Tyla - PUSH 2 START under") || strings.Contains(table, "lands on it") {
+		t.Errorf("Verbose output should describe a half-goal total, got:\n%s", table)
+	}
+}
+
+func TestFormat_GrossAndNetReturns(t *testing.T) {
+	result := sampleResult()
+	opt := &result.Options[0]
+	opt.TaxOnWinnings, opt.StakeExcise = 0.2, 0.125
+	opt.GrossReturn, opt.ReturnIfWins = money.FromFloat(16545), money.FromFloat(12670.04)
+
+	output := FormatTable(result, true)
+	for _, want := range []string{
+		"Gross: ₦16545",
+		"Net: ₦12670",
+		"Returns ₦16545.00 gross, ₦12670.04 net of 20.00% winnings tax and 12.50% stake excise",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Output should contain %q, got:\n%s", want, output)
+		}
+	}
+	if strings.Count(output, "Gross:") != 1 {
+		t.Errorf("Only the taxed option should show gross and net returns, got:\n%s", output)
+	}
+}
+
+func TestFormat_Currencies(t *testing.T) {
+	result := sampleResult()
+	result.Options[0].Currency = "NGN"
+	result.Options[1].Currency = "USD"
+	result.Options[1].Stake = money.FromFloat(2.28)
+	result.Summary.FX = &types.FXSummary{
+		Base:       "NGN",
+		Rates:      map[string]types.FXRate{"NGN": {Buy: 1, Sell: 1}, "USD": {Buy: 1550, Sell: 1500}},
+		SpreadCost: money.FromFloat(57),
+	}
+
+	table := FormatTable(result, true)
+	for _, want := range []string{
+		"Stake: $2 ",
+		"Tyla - PUSH 2 START: $2.28, costing ₦3534.00",
+		"USD: buying costs ₦1550, selling fetches ₦1500",
+		"Profits in NGN, after ₦57.00 of FX spread",
+	} {
+		if !strings.Contains(table, want) {
+			t.Errorf("Verbose output should contain %q, got:\n%s", want, table)
+		}
+	}
+
+	csv, err := FormatCSV(result)
+	if err != nil {
+		t.Fatalf("FormatCSV() error: %v", err)
+	}
+	if !strings.Contains(csv, ",Currency\n") || !strings.HasSuffix(strings.Split(csv, "\n")[2], ",USD") {
+		t.Errorf("CSV should end each row with the stake's currency, got:\n%s", csv)
+	}
+}
+
+func TestFormatBatch(t *testing.T) {
+	result := sampleResult()
+
+	rows := FormatBatchCSV(7, result)
+	if len(rows) != 2 {
+		t.Fatalf("FormatBatchCSV() = %d rows, want one per option", len(rows))
+	}
+	for _, row := range rows {
+		if len(row) != len(BatchCSVHeader) {
+			t.Errorf("row has %d fields, header has %d", len(row), len(BatchCSVHeader))
+		}
+		if row[0] != "7" || row[1] != "arbitrage" || row[11] != "₦" || row[13] != "true" {
+			t.Errorf("row = %v, want line 7, arbitrage, ₦ and guaranteed", row)
+		}
+	}
+	if rows[0][2] != "Davido - With You" || rows[0][5] != "6463.00" {
+		t.Errorf("first row = %v, want Davido staked 6463.00", rows[0])
+	}
+
+	output, err := FormatBatchJSON(7, result)
+	if err != nil {
+		t.Fatalf("FormatBatchJSON() error: %v", err)
+	}
+	if strings.Contains(output, "\n") {
+		t.Error("FormatBatchJSON() should be a single line")
+	}
+	var parsed struct {
+		Line       int     `json:"line"`
+		TotalStake float64 `json:"total_stake"`
+	}
+	if err := json.Unmarshal([]byte(output), &parsed); err != nil {
+		t.Fatalf("FormatBatchJSON() is not valid JSON: %v", err)
+	}
+	if parsed.Line != 7 || parsed.TotalStake != 10000 {
+		t.Errorf("FormatBatchJSON() = %s, want line 7 and total_stake 10000", output)
+	}
+}
@@ -0,0 +1,143 @@
+package formatter
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/codehakase/kelly/pkg/types"
+)
+
+// oddsFormats lists every odds format in the order Conversions reports them.
+var oddsFormats = []types.OddsFormat{
+	types.FormatDecimal, types.FormatFractional, types.FormatAmerican, types.FormatPercentage,
+	types.FormatHongKong, types.FormatMalay, types.FormatIndonesian,
+}
+
+// fractionLadder is the ladder of fractional prices bookmakers quote, as
+// numerator and denominator, in increasing order.
+var fractionLadder = [][2]int{
+	{1, 10}, {1, 9}, {1, 8}, {1, 7}, {1, 6}, {1, 5}, {2, 9}, {1, 4}, {2, 7}, {3, 10},
+	{1, 3}, {4, 11}, {2, 5}, {4, 9}, {1, 2}, {8, 15}, {4, 7}, {8, 13}, {4, 6}, {8, 11},
+	{4, 5}, {5, 6}, {10, 11}, {1, 1}, {21, 20}, {11, 10}, {6, 5}, {5, 4}, {11, 8}, {6, 4},
+	{13, 8}, {7, 4}, {15, 8}, {2, 1}, {9, 4}, {5, 2}, {11, 4}, {3, 1}, {10, 3}, {7, 2},
+	{4, 1}, {9, 2}, {5, 1}, {11, 2}, {6, 1}, {13, 2}, {7, 1}, {15, 2}, {8, 1}, {17, 2},
+	{9, 1}, {10, 1}, {11, 1}, {12, 1}, {14, 1}, {16, 1}, {18, 1}, {20, 1}, {25, 1}, {33, 1},
+	{40, 1}, {50, 1}, {66, 1}, {80, 1}, {100, 1},
+}
+
+// OddsName returns the display name of an odds format.
+func OddsName(format types.OddsFormat) string {
+	switch format {
+	case types.FormatFractional:
+		return "Fractional"
+	case types.FormatAmerican:
+		return "American"
+	case types.FormatPercentage:
+		return "Percentage"
+	case types.FormatHongKong:
+		return "Hong Kong"
+	case types.FormatMalay:
+		return "Malay"
+	case types.FormatIndonesian:
+		return "Indonesian"
+	default:
+		return "Decimal"
+	}
+}
+
+// FormatOdds renders decimal odds in the given format, decimal when it is
+// empty. Fractional odds are the nearest price on the bookmakers' ladder.
+func FormatOdds(odds float64, format types.OddsFormat) string {
+	net := odds - 1.0
+	switch format {
+	case types.FormatFractional:
+		return nearestFraction(net)
+	case types.FormatAmerican:
+		if net >= 1 {
+			return fmt.Sprintf("%+.0f", net*100)
+		}
+		return fmt.Sprintf("%+.0f", -100/net)
+	case types.FormatPercentage:
+		return fmt.Sprintf("%.2f%%", 100/odds)
+	case types.FormatHongKong:
+		return fmt.Sprintf("%.2f", net)
+	case types.FormatMalay:
+		if net <= 1 {
+			return fmt.Sprintf("%.2f", net)
+		}
+		return fmt.Sprintf("%.2f", -1/net)
+	case types.FormatIndonesian:
+		if net >= 1 {
+			return fmt.Sprintf("%+.2f", net)
+		}
+		return fmt.Sprintf("%+.2f", -1/net)
+	default:
+		return fmt.Sprintf("%.2f", odds)
+	}
+}
+
+// nearestFraction returns the fraction on the ladder closest to the net
+// odds. Longer or shorter prices than the ladder covers are rounded to a
+// whole number to one, or one to a whole number.
+func nearestFraction(net float64) string {
+	first, last := fractionLadder[0], fractionLadder[len(fractionLadder)-1]
+	switch {
+	case net <= 0:
+		return "0/1"
+	case net < float64(first[0])/float64(first[1]):
+		return fmt.Sprintf("1/%.0f", math.Round(1/net))
+	case net > float64(last[0])/float64(last[1]):
+		return fmt.Sprintf("%.0f/1", math.Round(net))
+	}
+
+	best := first
+	for _, f := range fractionLadder {
+		if math.Abs(float64(f[0])/float64(f[1])-net) < math.Abs(float64(best[0])/float64(best[1])-net) {
+			best = f
+		}
+	}
+	return fmt.Sprintf("%d/%d", best[0], best[1])
+}
+
+// Conversion is decimal odds rendered in one format.
+type Conversion struct {
+	Format types.OddsFormat
+	Odds   string
+}
+
+// Conversions renders decimal odds in every format.
+func Conversions(odds float64) []Conversion {
+	conversions := make([]Conversion, len(oddsFormats))
+	for i, format := range oddsFormats {
+		conversions[i] = Conversion{Format: format, Odds: FormatOdds(odds, format)}
+	}
+	return conversions
+}
+
+// FormatConversions renders decimal odds in every format, one per line.
+func FormatConversions(odds float64) string {
+	var sb strings.Builder
+	for _, c := range Conversions(odds) {
+		sb.WriteString(fmt.Sprintf("%-12s %s\n", OddsName(c.Format), c.Odds))
+	}
+	return sb.String()
+}
+
+// SetDisplayOdds records the format odds are displayed in on the result and
+// renders each option's odds in it for JSON output. The table and CSV
+// formatters read the format from the result.
+func SetDisplayOdds(result *types.CalculationResult, format types.OddsFormat) {
+	result.OddsFormat = format
+	for i := range result.Options {
+		result.Options[i].DisplayOdds = ""
+		if format != "" && format != types.FormatDecimal {
+			result.Options[i].DisplayOdds = FormatOdds(result.Options[i].Odds, format)
+		}
+	}
+}
+
+// displayOdds renders odds in the result's display format.
+func displayOdds(result *types.CalculationResult, odds float64) string {
+	return FormatOdds(odds, result.OddsFormat)
+}
@@ -0,0 +1,87 @@
+package formatter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/codehakase/kelly/pkg/types"
+)
+
+func TestFormatOdds(t *testing.T) {
+	tests := []struct {
+		odds   float64
+		format types.OddsFormat
+		want   string
+	}{
+		{3.5, "", "3.50"},
+		{3.5, types.FormatDecimal, "3.50"},
+		{3.5, types.FormatFractional, "5/2"},
+		{3.5, types.FormatAmerican, "+250"},
+		{3.5, types.FormatPercentage, "28.57%"},
+		{3.5, types.FormatHongKong, "2.50"},
+		{3.5, types.FormatMalay, "-0.40"},
+		{3.5, types.FormatIndonesian, "+2.50"},
+		{1.5, types.FormatFractional, "1/2"},
+		{1.5, types.FormatAmerican, "-200"},
+		{1.5, types.FormatMalay, "0.50"},
+		{1.5, types.FormatIndonesian, "-2.00"},
+		{2.0, types.FormatFractional, "1/1"},
+		{2.0, types.FormatAmerican, "+100"},
+		{2.45, types.FormatFractional, "6/4"},
+		{1.67, types.FormatFractional, "4/6"},
+		{3.1, types.FormatFractional, "2/1"},
+		{1.02, types.FormatFractional, "1/50"},
+		{251, types.FormatFractional, "250/1"},
+	}
+
+	for _, tt := range tests {
+		if got := FormatOdds(tt.odds, tt.format); got != tt.want {
+			t.Errorf("FormatOdds(%.2f, %q) = %q, want %q", tt.odds, tt.format, got, tt.want)
+		}
+	}
+}
+
+func TestFormatConversions(t *testing.T) {
+	out := FormatConversions(3.5)
+	lines := strings.Split(strings.TrimRight(out, "\n"), "\n")
+	if len(lines) != len(oddsFormats) {
+		t.Fatalf("got %d lines, want one per format:\n%s", len(lines), out)
+	}
+	for _, want := range []string{"Decimal      3.50", "Fractional   5/2", "American     +250", "Malay        -0.40"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("conversions should contain %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestSetDisplayOdds(t *testing.T) {
+	result := sampleResult()
+	SetDisplayOdds(result, types.FormatAmerican)
+
+	if result.OddsFormat != types.FormatAmerican || result.Options[0].DisplayOdds != "+156" {
+		t.Errorf("OddsFormat %q, display odds %q, want american and +156", result.OddsFormat, result.Options[0].DisplayOdds)
+	}
+	if table := FormatTable(result, false); !strings.Contains(table, "Odds: +285") {
+		t.Errorf("table should show American odds:\n%s", table)
+	}
+	csv, err := FormatCSV(result)
+	if err != nil {
+		t.Fatalf("FormatCSV() error: %v", err)
+	}
+	if !strings.Contains(csv, "Davido - With You,+156,") {
+		t.Errorf("CSV should show American odds:\n%s", csv)
+	}
+	json, err := FormatJSON(result)
+	if err != nil {
+		t.Fatalf("FormatJSON() error: %v", err)
+	}
+	if !strings.Contains(json, `"display_odds": "+156"`) || !strings.Contains(json, `"odds": 2.56`) {
+		t.Errorf("JSON should keep decimal odds and add display odds:\n%s", json)
+	}
+
+	// Decimal is the default and adds nothing.
+	SetDisplayOdds(result, types.FormatDecimal)
+	if result.Options[0].DisplayOdds != "" {
+		t.Errorf("DisplayOdds = %q, want empty for decimal", result.Options[0].DisplayOdds)
+	}
+}
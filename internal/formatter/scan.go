@@ -0,0 +1,74 @@
+package formatter
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/codehakase/kelly/pkg/types"
+)
+
+// FormatScanTable renders each arbitrage as an allocation table followed by
+// the bookmaker to place each leg with.
+func FormatScanTable(result *types.ScanResult, verbose bool) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("KELLY • Scanned %d events across %d books: %d arbitrage(s)\n",
+		result.Events, result.Books, len(result.Arbitrages)))
+
+	for _, ev := range result.Arbitrages {
+		summary := ev.Result.Summary
+		sb.WriteString(fmt.Sprintf("\n─── %s (market %.2f%%, ROI %.2f%%) ───\n",
+			ev.Event, summary.MarketEfficiency*100, summary.MinROI*100))
+		sb.WriteString(FormatTable(&ev.Result, verbose))
+		for _, opt := range ev.Result.Options {
+			sb.WriteString(fmt.Sprintf("  %s @ %.2f with %s\n", opt.Name, opt.Odds, opt.Bookmaker))
+		}
+	}
+	return sb.String()
+}
+
+func FormatScanJSON(result *types.ScanResult) (string, error) {
+	bytes, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(bytes), nil
+}
+
+// FormatScanCSV writes one row per leg of every arbitrage.
+func FormatScanCSV(result *types.ScanResult) (string, error) {
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+
+	header := []string{"Event", "Option", "Bookmaker", "Odds", "Stake", "Return", "Profit", "ROI", "Market_Efficiency"}
+	if err := writer.Write(header); err != nil {
+		return "", err
+	}
+
+	for _, ev := range result.Arbitrages {
+		for _, opt := range ev.Result.Options {
+			row := []string{
+				ev.Event,
+				opt.Name,
+				opt.Bookmaker,
+				fmt.Sprintf("%.2f", opt.Odds),
+				fmt.Sprintf("%.2f", opt.Stake.Float64()),
+				fmt.Sprintf("%.2f", opt.ReturnIfWins.Float64()),
+				fmt.Sprintf("%.2f", opt.ProfitIfWins.Float64()),
+				fmt.Sprintf("%.2f%%", opt.ROI*100),
+				fmt.Sprintf("%.2f%%", ev.Result.Summary.MarketEfficiency*100),
+			}
+			if err := writer.Write(row); err != nil {
+				return "", err
+			}
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,86 @@
+package formatter
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/codehakase/kelly/pkg/types"
+)
+
+func FormatSimulationTable(result *types.SimulationResult) string {
+	var sb strings.Builder
+	cur := result.Currency
+
+	sb.WriteString(fmt.Sprintf("KELLY • Simulation of %s staking\n", strings.Title(string(result.Method))))
+	sb.WriteString(fmt.Sprintf("%d runs × %d bets from %s%.2f (seed %d)\n\n",
+		result.Runs, result.Bets, cur, result.StartingBankroll.Float64(), result.Seed))
+
+	sb.WriteString(fmt.Sprintf("%-16s %12s %12s %12s %12s %12s %12s\n",
+		"", "Mean", "P5", "P25", "Median", "P75", "P95"))
+	fb := result.FinalBankroll
+	sb.WriteString(fmt.Sprintf("%-16s %12.2f %12.2f %12.2f %12.2f %12.2f %12.2f\n",
+		"Final bankroll", fb.Mean, fb.P5, fb.P25, fb.Median, fb.P75, fb.P95))
+	dd := result.MaxDrawdown
+	sb.WriteString(fmt.Sprintf("%-16s %11.1f%% %11.1f%% %11.1f%% %11.1f%% %11.1f%% %11.1f%%\n\n",
+		"Max drawdown", dd.Mean*100, dd.P5*100, dd.P25*100, dd.Median*100, dd.P75*100, dd.P95*100))
+
+	sb.WriteString(fmt.Sprintf("Probability of ruin:   %.2f%% (bankroll at or below %.0f%% of start)\n",
+		result.RuinProbability*100, result.RuinThreshold*100))
+	sb.WriteString(fmt.Sprintf("Median growth per bet: %.4f%%\n", result.MedianGrowthRate*100))
+	sb.WriteString(fmt.Sprintf("Expected growth/bet:   %.4f%%\n", result.ExpectedGrowthRate*100))
+
+	return sb.String()
+}
+
+func FormatSimulationJSON(result *types.SimulationResult) (string, error) {
+	bytes, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(bytes), nil
+}
+
+// FormatSimulationCSV writes one metric per row.
+func FormatSimulationCSV(result *types.SimulationResult) (string, error) {
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+
+	rows := [][]string{
+		{"Metric", "Value"},
+		{"method", string(result.Method)},
+		{"starting_bankroll", fmt.Sprintf("%.2f", result.StartingBankroll.Float64())},
+		{"runs", fmt.Sprintf("%d", result.Runs)},
+		{"bets", fmt.Sprintf("%d", result.Bets)},
+		{"seed", fmt.Sprintf("%d", result.Seed)},
+	}
+	for _, d := range []struct {
+		name   string
+		dist   types.Distribution
+		format string
+	}{
+		{"final_bankroll", result.FinalBankroll, "%.2f"},
+		{"max_drawdown", result.MaxDrawdown, "%.4f"},
+	} {
+		rows = append(rows,
+			[]string{d.name + "_mean", fmt.Sprintf(d.format, d.dist.Mean)},
+			[]string{d.name + "_p5", fmt.Sprintf(d.format, d.dist.P5)},
+			[]string{d.name + "_p25", fmt.Sprintf(d.format, d.dist.P25)},
+			[]string{d.name + "_median", fmt.Sprintf(d.format, d.dist.Median)},
+			[]string{d.name + "_p75", fmt.Sprintf(d.format, d.dist.P75)},
+			[]string{d.name + "_p95", fmt.Sprintf(d.format, d.dist.P95)},
+		)
+	}
+	rows = append(rows,
+		[]string{"ruin_probability", fmt.Sprintf("%.4f", result.RuinProbability)},
+		[]string{"median_growth_rate", fmt.Sprintf("%.6f", result.MedianGrowthRate)},
+		[]string{"expected_growth_rate", fmt.Sprintf("%.6f", result.ExpectedGrowthRate)},
+	)
+
+	if err := writer.WriteAll(rows); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
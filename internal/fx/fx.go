@@ -0,0 +1,113 @@
+// Package fx loads a local table of exchange rates and converts between
+// the currencies in it.
+package fx
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/codehakase/kelly/pkg/types"
+)
+
+// DefaultPath returns $KELLY_RATES, or rates.json in the kelly directory
+// under the user's config dir.
+func DefaultPath() (string, error) {
+	if path := os.Getenv("KELLY_RATES"); path != "" {
+		return path, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("locating config dir: %w", err)
+	}
+	return filepath.Join(dir, "kelly", "rates.json"), nil
+}
+
+// Load reads a rate table from a JSON file such as
+//
+//	{"base": "NGN", "rates": {"USD": {"buy": 1550, "sell": 1500}, "GBP": 1950}}
+//
+// where each rate is the price of one unit of the currency in the base
+// currency, as buy and sell prices or a single price with no spread.
+func Load(path string) (*types.RateTable, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var raw struct {
+		Base  string                     `json:"base"`
+		Rates map[string]json.RawMessage `json:"rates"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	table, err := parse(raw.Base, raw.Rates)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return table, nil
+}
+
+func parse(base string, rates map[string]json.RawMessage) (*types.RateTable, error) {
+	base = Code(base)
+	if base == "" {
+		return nil, errors.New("rate table needs a base currency")
+	}
+
+	table := &types.RateTable{Base: base, Rates: make(map[string]types.FXRate, len(rates))}
+	for code, msg := range rates {
+		var rate types.FXRate
+		var price float64
+		if err := json.Unmarshal(msg, &price); err == nil {
+			rate = types.FXRate{Buy: price, Sell: price}
+		} else if err := json.Unmarshal(msg, &rate); err != nil {
+			return nil, fmt.Errorf("rate for %s: use a price or {\"buy\": ..., \"sell\": ...}", code)
+		}
+		if rate.Sell <= 0 || rate.Buy < rate.Sell {
+			return nil, fmt.Errorf("rate for %s: buy %.4f and sell %.4f must be positive, buy at least sell",
+				code, rate.Buy, rate.Sell)
+		}
+		table.Rates[Code(code)] = rate
+	}
+	return table, nil
+}
+
+// Code normalizes a currency code: trimmed and upper case.
+func Code(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// Rate returns the price of one unit of currency in base. Currencies are
+// converted through the table's base currency, so a cross rate pays both
+// spreads: buying costs the currency's buy price over base's sell price,
+// and selling fetches its sell price over base's buy price.
+func Rate(table *types.RateTable, currency, base string) (types.FXRate, error) {
+	currency, base = Code(currency), Code(base)
+	if currency == base {
+		return types.FXRate{Buy: 1, Sell: 1}, nil
+	}
+	from, err := lookup(table, currency)
+	if err != nil {
+		return types.FXRate{}, err
+	}
+	to, err := lookup(table, base)
+	if err != nil {
+		return types.FXRate{}, err
+	}
+	return types.FXRate{Buy: from.Buy / to.Sell, Sell: from.Sell / to.Buy}, nil
+}
+
+func lookup(table *types.RateTable, code string) (types.FXRate, error) {
+	if code == table.Base {
+		return types.FXRate{Buy: 1, Sell: 1}, nil
+	}
+	rate, ok := table.Rates[code]
+	if !ok {
+		return types.FXRate{}, fmt.Errorf("no exchange rate for %s against %s", code, table.Base)
+	}
+	return rate, nil
+}
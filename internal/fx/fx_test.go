@@ -0,0 +1,85 @@
+package fx
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/codehakase/kelly/pkg/types"
+)
+
+func writeRates(t *testing.T, data string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "rates.json")
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	table, err := Load(writeRates(t, `{"base": "ngn", "rates": {"usd": {"buy": 1550, "sell": 1500}, "GBP": 1950}}`))
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if table.Base != "NGN" {
+		t.Errorf("Base = %q, want NGN", table.Base)
+	}
+	if got := table.Rates["USD"]; got != (types.FXRate{Buy: 1550, Sell: 1500}) {
+		t.Errorf("USD = %+v, want buy 1550, sell 1500", got)
+	}
+	if got := table.Rates["GBP"]; got != (types.FXRate{Buy: 1950, Sell: 1950}) {
+		t.Errorf("GBP = %+v, want a single price of 1950", got)
+	}
+}
+
+func TestLoad_Errors(t *testing.T) {
+	tests := map[string]string{
+		"no base":       `{"rates": {"USD": 1500}}`,
+		"buy below sel": `{"base": "NGN", "rates": {"USD": {"buy": 1400, "sell": 1500}}}`,
+		"zero rate":     `{"base": "NGN", "rates": {"USD": 0}}`,
+		"not a rate":    `{"base": "NGN", "rates": {"USD": "1500"}}`,
+		"not json":      `base: NGN`,
+	}
+
+	for name, data := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := Load(writeRates(t, data)); err == nil {
+				t.Error("Load() expected an error")
+			}
+		})
+	}
+}
+
+func TestRate(t *testing.T) {
+	table := &types.RateTable{Base: "NGN", Rates: map[string]types.FXRate{
+		"USD": {Buy: 1550, Sell: 1500},
+		"GBP": {Buy: 1980, Sell: 1920},
+	}}
+
+	tests := []struct {
+		currency, base string
+		want           types.FXRate
+	}{
+		{"NGN", "NGN", types.FXRate{Buy: 1, Sell: 1}},
+		{"usd", "NGN", types.FXRate{Buy: 1550, Sell: 1500}},
+		{"NGN", "USD", types.FXRate{Buy: 1.0 / 1500, Sell: 1.0 / 1550}},
+		// A cross rate pays both spreads.
+		{"GBP", "USD", types.FXRate{Buy: 1980.0 / 1500, Sell: 1920.0 / 1550}},
+	}
+
+	for _, tt := range tests {
+		got, err := Rate(table, tt.currency, tt.base)
+		if err != nil {
+			t.Fatalf("Rate(%s, %s) error: %v", tt.currency, tt.base, err)
+		}
+		if math.Abs(got.Buy-tt.want.Buy) > 1e-12 || math.Abs(got.Sell-tt.want.Sell) > 1e-12 {
+			t.Errorf("Rate(%s, %s) = %+v, want %+v", tt.currency, tt.base, got, tt.want)
+		}
+	}
+
+	if _, err := Rate(table, "JPY", "NGN"); err == nil {
+		t.Error("Rate(JPY, NGN) expected an error for a currency without a rate")
+	}
+}
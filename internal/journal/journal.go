@@ -0,0 +1,405 @@
+package journal
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/codehakase/kelly/pkg/money"
+	"github.com/codehakase/kelly/pkg/types"
+)
+
+// Journal stores bets as JSON lines, one bet per line.
+type Journal struct {
+	path string
+}
+
+// DefaultPath returns $KELLY_JOURNAL, or journal.jsonl in the kelly
+// directory under the user's config dir.
+func DefaultPath() (string, error) {
+	if path := os.Getenv("KELLY_JOURNAL"); path != "" {
+		return path, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("locating config dir: %w", err)
+	}
+	return filepath.Join(dir, "kelly", "journal.jsonl"), nil
+}
+
+func Open(path string) *Journal {
+	return &Journal{path: path}
+}
+
+func (j *Journal) Path() string { return j.path }
+
+// List returns every bet in the journal, oldest first. A missing journal
+// file is treated as empty.
+func (j *Journal) List() ([]types.Bet, error) {
+	f, err := os.Open(j.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var bets []types.Bet
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		var bet types.Bet
+		if err := json.Unmarshal(scanner.Bytes(), &bet); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", j.path, line, err)
+		}
+		bets = append(bets, bet)
+	}
+	return bets, scanner.Err()
+}
+
+// Get returns the bet with the given ID.
+func (j *Journal) Get(id int) (types.Bet, error) {
+	bets, err := j.List()
+	if err != nil {
+		return types.Bet{}, err
+	}
+	for _, bet := range bets {
+		if bet.ID == id {
+			return bet, nil
+		}
+	}
+	return types.Bet{}, fmt.Errorf("bet %d not found", id)
+}
+
+// Pending returns the capital committed to the named bankroll's pending
+// bets: money already staked that its balance does not yet reflect.
+func (j *Journal) Pending(bankroll string) (money.Amount, error) {
+	bets, err := j.List()
+	if err != nil {
+		return 0, err
+	}
+	var pending money.Amount
+	for _, bet := range bets {
+		if bet.Status == types.BetPending && bet.Bankroll != "" && strings.EqualFold(bet.Bankroll, bankroll) {
+			pending += Staked(bet)
+		}
+	}
+	return pending, nil
+}
+
+// Add records a calculation result as a pending bet, staked from the named
+// bankroll (empty when the bet is not tied to one).
+func (j *Journal) Add(result *types.CalculationResult, bankroll string) (types.Bet, error) {
+	if result == nil || len(result.Options) == 0 {
+		return types.Bet{}, errors.New("cannot record an empty calculation result")
+	}
+
+	bets, err := j.List()
+	if err != nil {
+		return types.Bet{}, err
+	}
+
+	bet := types.Bet{
+		ID:       nextID(bets),
+		Status:   types.BetPending,
+		Bankroll: bankroll,
+		Result:   *result,
+		PlacedAt: time.Now().UTC(),
+	}
+
+	if err := os.MkdirAll(filepath.Dir(j.path), 0o755); err != nil {
+		return types.Bet{}, err
+	}
+	f, err := os.OpenFile(j.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return types.Bet{}, err
+	}
+	defer f.Close()
+
+	line, err := json.Marshal(bet)
+	if err != nil {
+		return types.Bet{}, err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return types.Bet{}, err
+	}
+	return bet, nil
+}
+
+// Settle marks a pending bet as settled with the given winning option and
+// records its realized profit or loss. winner is an option letter ("A"),
+// a 1-based index, an option name, or "none". A parlay is settled with a
+// comma-separated list of its winning legs instead.
+func (j *Journal) Settle(id int, winner string) (types.Bet, error) {
+	return j.update(id, func(bet *types.Bet) error {
+		if p := bet.Result.Summary.Parlay; p != nil {
+			won, label, err := ResolveLegs(p.Selections, winner)
+			if err != nil {
+				return err
+			}
+			bet.Status = types.BetSettled
+			bet.Winner = label
+			bet.PnL = ParlayPnL(&bet.Result, won)
+			return nil
+		}
+
+		idx, label, err := ResolveWinner(bet.Result.Options, winner)
+		if err != nil {
+			return err
+		}
+		bet.Status = types.BetSettled
+		bet.Winner = label
+		bet.PnL = SettlementPnL(&bet.Result, idx)
+		return nil
+	})
+}
+
+// Void marks a pending bet as void; all stakes are returned.
+func (j *Journal) Void(id int) (types.Bet, error) {
+	return j.update(id, func(bet *types.Bet) error {
+		bet.Status = types.BetVoid
+		bet.PnL = 0
+		return nil
+	})
+}
+
+func (j *Journal) update(id int, apply func(bet *types.Bet) error) (types.Bet, error) {
+	bets, err := j.List()
+	if err != nil {
+		return types.Bet{}, err
+	}
+
+	for i := range bets {
+		if bets[i].ID != id {
+			continue
+		}
+		if bets[i].Status != types.BetPending {
+			return types.Bet{}, fmt.Errorf("bet %d is already %s", id, bets[i].Status)
+		}
+		if err := apply(&bets[i]); err != nil {
+			return types.Bet{}, err
+		}
+		now := time.Now().UTC()
+		bets[i].SettledAt = &now
+		if err := j.save(bets); err != nil {
+			return types.Bet{}, err
+		}
+		return bets[i], nil
+	}
+	return types.Bet{}, fmt.Errorf("bet %d not found", id)
+}
+
+// save rewrites the journal through a temporary file so a failed write
+// never truncates it.
+func (j *Journal) save(bets []types.Bet) error {
+	tmp, err := os.CreateTemp(filepath.Dir(j.path), ".journal-*.jsonl")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	w := bufio.NewWriter(tmp)
+	enc := json.NewEncoder(w)
+	for _, bet := range bets {
+		if err := enc.Encode(bet); err != nil {
+			tmp.Close()
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), j.path)
+}
+
+// Staked is the capital committed to a bet: back stakes plus lay
+// liabilities, or its base-currency total across currencies.
+func Staked(bet types.Bet) money.Amount {
+	if bet.Result.Summary.FX != nil {
+		return bet.Result.TotalStake
+	}
+	var staked money.Amount
+	for _, opt := range bet.Result.Options {
+		if opt.Side == types.SideLay {
+			staked += opt.Liability
+		} else {
+			staked += opt.Stake
+		}
+	}
+	return staked
+}
+
+func nextID(bets []types.Bet) int {
+	id := 0
+	for _, bet := range bets {
+		if bet.ID > id {
+			id = bet.ID
+		}
+	}
+	return id + 1
+}
+
+// ResolveWinner returns the index and label of the winning option, or -1
+// and "none" when no option won. winner is an option name, an option letter
+// ("A"), a 1-based index, or "none".
+func ResolveWinner(options []types.Option, winner string) (int, string, error) {
+	winner = strings.TrimSpace(winner)
+	if winner == "" {
+		return 0, "", errors.New("winner is required")
+	}
+	if strings.EqualFold(winner, "none") {
+		return -1, "none", nil
+	}
+
+	// Names come first so a market named H/D/A settles "A" as the away side.
+	for i, opt := range options {
+		if strings.EqualFold(opt.Name, winner) {
+			return i, string(rune('A' + i)), nil
+		}
+	}
+	if len(winner) == 1 {
+		if idx := int(strings.ToUpper(winner)[0] - 'A'); idx >= 0 && idx < len(options) {
+			return idx, strings.ToUpper(winner), nil
+		}
+	}
+	if n, err := strconv.Atoi(winner); err == nil && n >= 1 && n <= len(options) {
+		return n - 1, string(rune('A' + n - 1)), nil
+	}
+	return 0, "", fmt.Errorf("unknown winner '%s'", winner)
+}
+
+// ResolveLegs returns the indices and labels of the winning legs of a
+// parlay, given as a comma-separated list of leg names, letters or 1-based
+// indices, or "none" when every leg lost.
+func ResolveLegs(legs []types.Option, winners string) ([]int, string, error) {
+	if strings.EqualFold(strings.TrimSpace(winners), "none") {
+		return nil, "none", nil
+	}
+
+	seen := make(map[int]bool)
+	var won []int
+	for _, part := range strings.Split(winners, ",") {
+		idx, _, err := ResolveWinner(legs, part)
+		if err != nil {
+			return nil, "", err
+		}
+		if idx < 0 {
+			return nil, "", errors.New("\"none\" cannot be combined with winning legs")
+		}
+		if !seen[idx] {
+			seen[idx] = true
+			won = append(won, idx)
+		}
+	}
+	sort.Ints(won)
+
+	labels := make([]string, len(won))
+	for i, idx := range won {
+		labels[i] = string(rune('A' + idx))
+	}
+	return won, strings.Join(labels, ","), nil
+}
+
+// ParlayPnL returns the realized profit of a parlay when the given legs
+// won: every line made only of winning legs pays its stake at its odds,
+// after any winnings tax and stake excise, and every other line loses.
+func ParlayPnL(result *types.CalculationResult, won []int) money.Amount {
+	winning := make(map[int]bool, len(won))
+	for _, leg := range won {
+		winning[leg] = true
+	}
+
+	pnl := -result.TotalStake
+	for _, line := range result.Options {
+		landed := true
+		for _, leg := range line.Legs {
+			landed = landed && winning[leg]
+		}
+		if landed {
+			pnl += money.FromFloat(line.Stake.Float64() * line.EffectiveOdds())
+		}
+	}
+	return pnl
+}
+
+// SettlementPnL returns the realized profit of a result when the option at
+// index winner wins (-1 when none of them does). Every other option's bet
+// loses: back bets lose their stake and lay bets pay their liability.
+//
+// An each-way result is settled by how its selection finished instead: the
+// win leg when it won, the place leg when it only placed, anything else
+// when it lost.
+//
+// An Asian line result is settled by which side cleared the line, winner
+// -1 meaning the result landed on it: a push or half result.
+//
+// A result across currencies is settled in its base currency, at the rates
+// it was calculated with.
+func SettlementPnL(result *types.CalculationResult, winner int) money.Amount {
+	if result.Summary.FX != nil {
+		if winner < 0 || winner >= len(result.Options) {
+			return -result.TotalStake
+		}
+		return result.Options[winner].ProfitIfWins
+	}
+	if a := result.Summary.Asian; a != nil {
+		switch {
+		case winner == 0:
+			return a.IfAWins
+		case winner == 1:
+			return a.IfBWins
+		case a.Split:
+			return a.IfSplit
+		}
+	}
+	if ew := result.Summary.EachWay; ew != nil {
+		switch winner {
+		case 0:
+			return ew.IfWins
+		case 1:
+			return ew.IfPlaces
+		default:
+			return ew.IfLoses
+		}
+	}
+
+	freeBet := result.Summary.BetType == types.BetFreeSNR || result.Summary.BetType == types.BetFreeSR
+
+	var pnl money.Amount
+	for i, opt := range result.Options {
+		stake := opt.Stake.Float64()
+		won := i == winner
+		switch {
+		case opt.Side == types.SideLay && won:
+			pnl += money.FromFloat(stake * (1.0 - opt.Commission))
+		case opt.Side == types.SideLay:
+			pnl -= opt.Liability
+		case freeBet && won && result.Summary.BetType == types.BetFreeSR:
+			pnl += money.FromFloat(stake * (1.0 + opt.NetWinnings(opt.Odds-1.0)))
+		case freeBet && won:
+			pnl += money.FromFloat(stake * opt.NetWinnings(opt.Odds-1.0))
+		case freeBet:
+			// A losing free bet costs nothing.
+		case won:
+			pnl += money.FromFloat(stake * (opt.EffectiveOdds() - 1.0))
+		default:
+			pnl -= opt.Stake
+		}
+	}
+	return pnl
+}
@@ -0,0 +1,297 @@
+package journal
+
+import (
+	"math"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/codehakase/kelly/pkg/money"
+	"github.com/codehakase/kelly/pkg/types"
+)
+
+func backResult() *types.CalculationResult {
+	return &types.CalculationResult{
+		Method:     types.MethodArbitrage,
+		TotalStake: money.FromFloat(1000),
+		Currency:   "$",
+		Options: []types.Option{
+			{Name: "Home", Odds: 2.5, Stake: money.FromFloat(600)},
+			{Name: "Away", Odds: 3.0, Stake: money.FromFloat(400)},
+		},
+	}
+}
+
+func TestJournal_Workflow(t *testing.T) {
+	j := Open(filepath.Join(t.TempDir(), "kelly", "journal.jsonl"))
+
+	bets, err := j.List()
+	if err != nil || len(bets) != 0 {
+		t.Fatalf("List() on a missing journal = %v, %v; want empty", bets, err)
+	}
+
+	first, err := j.Add(backResult(), "main")
+	if err != nil {
+		t.Fatalf("Add() error: %v", err)
+	}
+	second, err := j.Add(backResult(), "")
+	if err != nil {
+		t.Fatalf("Add() error: %v", err)
+	}
+	if first.ID != 1 || second.ID != 2 || first.Status != types.BetPending {
+		t.Errorf("Add() ids = %d, %d status %s; want 1, 2 pending", first.ID, second.ID, first.Status)
+	}
+	if first.Bankroll != "main" {
+		t.Errorf("Add() bankroll = %q, want main", first.Bankroll)
+	}
+	if first.PlacedAt.IsZero() {
+		t.Error("Add() should set PlacedAt")
+	}
+
+	settled, err := j.Settle(1, "home")
+	if err != nil {
+		t.Fatalf("Settle() error: %v", err)
+	}
+	if settled.Status != types.BetSettled || settled.Winner != "A" || settled.PnL != money.FromFloat(500) {
+		t.Errorf("Settle() = %s winner %s pnl %.2f; want settled A 500", settled.Status, settled.Winner, settled.PnL.Float64())
+	}
+	if settled.SettledAt == nil {
+		t.Error("Settle() should set SettledAt")
+	}
+
+	voided, err := j.Void(2)
+	if err != nil {
+		t.Fatalf("Void() error: %v", err)
+	}
+	if voided.Status != types.BetVoid || voided.PnL != 0 {
+		t.Errorf("Void() = %s pnl %.2f; want void 0", voided.Status, voided.PnL.Float64())
+	}
+
+	bets, err = j.List()
+	if err != nil || len(bets) != 2 {
+		t.Fatalf("List() = %d bets, %v; want 2", len(bets), err)
+	}
+	if bets[0].Status != types.BetSettled || bets[1].Status != types.BetVoid {
+		t.Errorf("List() statuses = %s, %s; want settled, void", bets[0].Status, bets[1].Status)
+	}
+
+	if _, err := j.Settle(1, "B"); err == nil {
+		t.Error("Settle() should reject an already settled bet")
+	}
+	if _, err := j.Void(3); err == nil {
+		t.Error("Void() should reject an unknown bet")
+	}
+	if _, err := j.Add(backResult(), ""); err != nil {
+		t.Fatalf("Add() error: %v", err)
+	}
+	if _, err := j.Settle(3, "Draw"); err == nil {
+		t.Error("Settle() should reject an unknown winner")
+	}
+}
+
+func TestJournal_Pending(t *testing.T) {
+	j := Open(filepath.Join(t.TempDir(), "journal.jsonl"))
+
+	lay := backResult()
+	lay.Options[1].Side = types.SideLay
+	lay.Options[1].Liability = money.FromFloat(800)
+	for _, bankroll := range []string{"main", "Main", "exchange", ""} {
+		if _, err := j.Add(lay, bankroll); err != nil {
+			t.Fatalf("Add() error: %v", err)
+		}
+	}
+	if _, err := j.Settle(1, "A"); err != nil {
+		t.Fatalf("Settle() error: %v", err)
+	}
+
+	// The lay commits its liability, not its stake: 600 + 800.
+	pending, err := j.Pending("MAIN")
+	if err != nil {
+		t.Fatalf("Pending() error: %v", err)
+	}
+	if pending != money.FromFloat(1400) {
+		t.Errorf("Pending(MAIN) = %s, want 1400.00 from the one bet still pending", pending)
+	}
+	if pending, _ := j.Pending("other"); pending != 0 {
+		t.Errorf("Pending(other) = %s, want 0", pending)
+	}
+}
+
+func TestSettlementPnL(t *testing.T) {
+	lay := &types.CalculationResult{
+		Options: []types.Option{
+			{Name: "Back", Odds: 3.2, Stake: money.FromFloat(100)},
+			{Name: "Lay", Odds: 3.1, Side: types.SideLay, Commission: 0.02, Stake: money.FromFloat(100), Liability: money.FromFloat(210)},
+		},
+	}
+	free := &types.CalculationResult{
+		Summary: types.Summary{BetType: types.BetFreeSNR},
+		Options: []types.Option{
+			{Name: "Back", Odds: 4.0, Stake: money.FromFloat(25)},
+			{Name: "Lay", Odds: 4.2, Side: types.SideLay, Commission: 0.02, Stake: money.FromFloat(17.97), Liability: money.FromFloat(57.5)},
+		},
+	}
+
+	eachWay := &types.CalculationResult{
+		Summary: types.Summary{EachWay: &types.EachWayOutcome{IfWins: money.FromFloat(96), IfPlaces: money.FromFloat(6), IfLoses: money.FromFloat(-20)}},
+		Options: []types.Option{
+			{Name: "Win", Odds: 9.0, Stake: money.FromFloat(10)},
+			{Name: "Place", Odds: 2.6, Stake: money.FromFloat(10)},
+		},
+	}
+
+	asian := &types.CalculationResult{
+		Summary: types.Summary{Asian: &types.AsianOutcome{Split: true, IfAWins: money.FromFloat(7.31), IfSplit: money.FromFloat(7.32), IfBWins: money.FromFloat(14.64)}},
+		Options: []types.Option{
+			{Name: "Home", Odds: 2.1, Stake: money.FromFloat(479.67), Line: -0.25},
+			{Name: "Away", Odds: 1.95, Stake: money.FromFloat(520.33), Line: 0.25},
+		},
+	}
+
+	// Across currencies the stakes are in each book's currency, and the
+	// profits in the base currency.
+	fx := &types.CalculationResult{
+		TotalStake: money.FromFloat(100004),
+		Summary:    types.Summary{FX: &types.FXSummary{Base: "NGN"}},
+		Options: []types.Option{
+			{Name: "Home", Odds: 2.1, Stake: money.FromFloat(49769), Currency: "NGN", ProfitIfWins: money.FromFloat(4510)},
+			{Name: "Away", Odds: 2.15, Stake: money.FromFloat(32.41), Currency: "USD", ProfitIfWins: money.FromFloat(4518)},
+		},
+	}
+
+	tests := []struct {
+		name   string
+		result *types.CalculationResult
+		winner int
+		want   float64
+	}{
+		{"back A wins", backResult(), 0, 500},
+		{"back B wins", backResult(), 1, 200},
+		{"back none wins", backResult(), -1, -1000},
+		{"back wins against lay", lay, 0, 10},
+		{"lay wins", lay, 1, -2},
+		{"free bet back wins", free, 0, 17.5},
+		{"free bet lay wins", free, 1, 17.61},
+		{"each-way wins", eachWay, 0, 96},
+		{"each-way places", eachWay, 1, 6},
+		{"each-way loses", eachWay, -1, -20},
+		{"Asian home clears the line", asian, 0, 7.31},
+		{"Asian away clears the line", asian, 1, 14.64},
+		{"Asian draw on a quarter line", asian, -1, 7.32},
+		{"across currencies, home wins", fx, 0, 4510},
+		{"across currencies, away wins", fx, 1, 4518},
+		{"across currencies, neither wins", fx, -1, -100004},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := SettlementPnL(tt.result, tt.winner)
+			if math.Abs(got.Float64()-tt.want) > 0.01 {
+				t.Errorf("SettlementPnL() = %.2f, want %.2f", got.Float64(), tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveWinner(t *testing.T) {
+	options := backResult().Options
+
+	tests := []struct {
+		winner    string
+		wantIdx   int
+		wantLabel string
+		wantErr   bool
+	}{
+		{"A", 0, "A", false},
+		{"b", 1, "B", false},
+		{"2", 1, "B", false},
+		{"Away", 1, "B", false},
+		{"none", -1, "none", false},
+		{"away", 1, "B", false},
+		{"C", 0, "", true},
+		{"", 0, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.winner, func(t *testing.T) {
+			idx, label, err := ResolveWinner(options, tt.winner)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ResolveWinner(%q) error = %v, wantErr %v", tt.winner, err, tt.wantErr)
+			}
+			if !tt.wantErr && (idx != tt.wantIdx || label != tt.wantLabel) {
+				t.Errorf("ResolveWinner(%q) = %d, %s; want %d, %s", tt.winner, idx, label, tt.wantIdx, tt.wantLabel)
+			}
+		})
+	}
+}
+
+func TestResolveLegs(t *testing.T) {
+	legs := []types.Option{{Name: "Arsenal"}, {Name: "Chelsea"}, {Name: "Spurs"}}
+
+	tests := []struct {
+		winners   string
+		wantLegs  []int
+		wantLabel string
+		wantErr   bool
+	}{
+		{"A,C", []int{0, 2}, "A,C", false},
+		{"spurs, 1", []int{0, 2}, "A,C", false},
+		{"B,B", []int{1}, "B", false},
+		{"none", nil, "none", false},
+		{"A,none", nil, "", true},
+		{"A,D", nil, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.winners, func(t *testing.T) {
+			won, label, err := ResolveLegs(legs, tt.winners)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ResolveLegs(%q) error = %v, wantErr %v", tt.winners, err, tt.wantErr)
+			}
+			if !tt.wantErr && (!reflect.DeepEqual(won, tt.wantLegs) || label != tt.wantLabel) {
+				t.Errorf("ResolveLegs(%q) = %v, %s; want %v, %s", tt.winners, won, label, tt.wantLegs, tt.wantLabel)
+			}
+		})
+	}
+}
+
+func TestParlayPnL(t *testing.T) {
+	// A trixie of 2.50 lines at 2.0 per leg: three doubles and a treble.
+	trixie := &types.CalculationResult{
+		TotalStake: money.FromFloat(10),
+		Options: []types.Option{
+			{Odds: 4, Stake: money.FromFloat(2.5), Legs: []int{0, 1}},
+			{Odds: 4, Stake: money.FromFloat(2.5), Legs: []int{0, 2}},
+			{Odds: 4, Stake: money.FromFloat(2.5), Legs: []int{1, 2}},
+			{Odds: 8, Stake: money.FromFloat(2.5), Legs: []int{0, 1, 2}},
+		},
+	}
+
+	tests := []struct {
+		name string
+		won  []int
+		want float64
+	}{
+		{"every leg wins", []int{0, 1, 2}, 40},
+		{"one double lands", []int{0, 2}, 0},
+		{"one leg wins", []int{1}, -10},
+		{"no leg wins", nil, -10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParlayPnL(trixie, tt.won); math.Abs(got.Float64()-tt.want) > 0.01 {
+				t.Errorf("ParlayPnL() = %.2f, want %.2f", got.Float64(), tt.want)
+			}
+		})
+	}
+
+	// 20% tax on the double's winnings of 30 leaves 24.
+	taxed := &types.CalculationResult{
+		TotalStake: money.FromFloat(10),
+		Options:    []types.Option{{Odds: 4, Stake: money.FromFloat(10), TaxOnWinnings: 0.2, Legs: []int{0, 1}}},
+	}
+	if got := ParlayPnL(taxed, []int{0, 1}); got != money.FromFloat(24) {
+		t.Errorf("ParlayPnL() = %.2f, want 24 after tax", got.Float64())
+	}
+}
@@ -0,0 +1,165 @@
+package margin
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/codehakase/kelly/pkg/types"
+)
+
+// ParseMethod converts a method name into a types.MarginMethod.
+func ParseMethod(name string) (types.MarginMethod, error) {
+	switch method := types.MarginMethod(name); method {
+	case types.MarginMultiplicative, types.MarginAdditive, types.MarginPower,
+		types.MarginShin, types.MarginOddsRatio:
+		return method, nil
+	default:
+		return "", fmt.Errorf("invalid margin method '%s'. Must be: multiplicative, additive, power, shin, or odds-ratio", name)
+	}
+}
+
+// Overround returns the sum of implied probabilities minus one.
+func Overround(odds []float64) float64 {
+	return booksum(odds) - 1.0
+}
+
+// FairProbabilities removes the bookmaker margin from a complete market of
+// decimal odds and returns probabilities that sum to one.
+func FairProbabilities(odds []float64, method types.MarginMethod) ([]float64, error) {
+	if len(odds) < 2 {
+		return nil, errors.New("margin removal requires at least 2 odds")
+	}
+	for _, o := range odds {
+		if o <= 1.0 {
+			return nil, fmt.Errorf("odds must be > 1.0, got: %.2f", o)
+		}
+	}
+
+	switch method {
+	case types.MarginMultiplicative:
+		return multiplicative(odds), nil
+	case types.MarginAdditive:
+		return additive(odds)
+	case types.MarginPower:
+		return power(odds), nil
+	case types.MarginShin:
+		return shin(odds)
+	case types.MarginOddsRatio:
+		return oddsRatio(odds), nil
+	default:
+		return nil, fmt.Errorf("invalid margin method: %s", method)
+	}
+}
+
+func booksum(odds []float64) float64 {
+	var sum float64
+	for _, o := range odds {
+		sum += 1.0 / o
+	}
+	return sum
+}
+
+// multiplicative scales every implied probability by the booksum.
+func multiplicative(odds []float64) []float64 {
+	sum := booksum(odds)
+	probs := make([]float64, len(odds))
+	for i, o := range odds {
+		probs[i] = (1.0 / o) / sum
+	}
+	return probs
+}
+
+// additive subtracts an equal share of the overround from every outcome.
+func additive(odds []float64) ([]float64, error) {
+	share := Overround(odds) / float64(len(odds))
+	probs := make([]float64, len(odds))
+	for i, o := range odds {
+		probs[i] = 1.0/o - share
+		if probs[i] <= 0 {
+			return nil, fmt.Errorf("additive method gives a non-positive probability for odds %.2f", o)
+		}
+	}
+	return probs, nil
+}
+
+// power raises implied probabilities to the exponent k that makes them sum
+// to one, shading longshots more than favourites.
+func power(odds []float64) []float64 {
+	probsAt := func(k float64) []float64 {
+		probs := make([]float64, len(odds))
+		for i, o := range odds {
+			probs[i] = math.Pow(1.0/o, k)
+		}
+		return probs
+	}
+	k := solveLog(func(k float64) float64 { return sum(probsAt(k)) - 1.0 })
+	return probsAt(k)
+}
+
+// shin applies Shin's model, which attributes the margin to the share z of
+// insider money in the market.
+func shin(odds []float64) ([]float64, error) {
+	total := booksum(odds)
+	if total <= 1.0 {
+		return nil, errors.New("shin method requires a book with a positive overround")
+	}
+
+	probsAt := func(z float64) []float64 {
+		probs := make([]float64, len(odds))
+		for i, o := range odds {
+			q := 1.0 / o
+			probs[i] = (math.Sqrt(z*z+4*(1-z)*q*q/total) - z) / (2 * (1 - z))
+		}
+		return probs
+	}
+
+	lo, hi := 0.0, 0.999999
+	for i := 0; i < 200; i++ {
+		mid := (lo + hi) / 2
+		if sum(probsAt(mid)) > 1.0 {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return probsAt((lo + hi) / 2), nil
+}
+
+// oddsRatio finds the constant c relating bookmaker and fair odds ratios,
+// q/(1-q) = c × p/(1-p).
+func oddsRatio(odds []float64) []float64 {
+	probsAt := func(c float64) []float64 {
+		probs := make([]float64, len(odds))
+		for i, o := range odds {
+			q := 1.0 / o
+			probs[i] = q / (c - c*q + q)
+		}
+		return probs
+	}
+	c := solveLog(func(c float64) float64 { return sum(probsAt(c)) - 1.0 })
+	return probsAt(c)
+}
+
+// solveLog finds the root of a decreasing function on (0, ∞) by bisecting
+// in log space.
+func solveLog(f func(float64) float64) float64 {
+	lo, hi := math.Log(1e-6), math.Log(1e6)
+	for i := 0; i < 200; i++ {
+		mid := (lo + hi) / 2
+		if f(math.Exp(mid)) > 0 {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return math.Exp((lo + hi) / 2)
+}
+
+func sum(values []float64) float64 {
+	var total float64
+	for _, v := range values {
+		total += v
+	}
+	return total
+}
@@ -0,0 +1,133 @@
+package margin
+
+import (
+	"math"
+	"testing"
+
+	"github.com/codehakase/kelly/pkg/types"
+)
+
+func TestFairProbabilities_SumToOne(t *testing.T) {
+	markets := map[string][]float64{
+		"two-way":        {1.91, 1.91},
+		"three-way":      {2.10, 3.40, 3.60},
+		"longshot field": {1.5, 4.0, 10.0, 25.0},
+	}
+	methods := []types.MarginMethod{
+		types.MarginMultiplicative, types.MarginAdditive, types.MarginPower,
+		types.MarginShin, types.MarginOddsRatio,
+	}
+
+	for name, odds := range markets {
+		for _, method := range methods {
+			t.Run(name+"/"+string(method), func(t *testing.T) {
+				probs, err := FairProbabilities(odds, method)
+				if err != nil {
+					t.Fatalf("FairProbabilities() error: %v", err)
+				}
+
+				var total float64
+				for i, p := range probs {
+					if p <= 0 || p >= 1 {
+						t.Errorf("probs[%d] = %.6f, want within (0, 1)", i, p)
+					}
+					if p > 1.0/odds[i]+1e-9 {
+						t.Errorf("probs[%d] = %.6f exceeds implied %.6f", i, p, 1.0/odds[i])
+					}
+					total += p
+				}
+				if !floatEquals(total, 1.0, 1e-6) {
+					t.Errorf("sum of probabilities = %.8f, want 1", total)
+				}
+			})
+		}
+	}
+}
+
+func TestFairProbabilities_Multiplicative(t *testing.T) {
+	probs, err := FairProbabilities([]float64{1.8, 2.0}, types.MarginMultiplicative)
+	if err != nil {
+		t.Fatalf("FairProbabilities() error: %v", err)
+	}
+
+	total := 1/1.8 + 1/2.0
+	want := []float64{(1 / 1.8) / total, (1 / 2.0) / total}
+	for i := range want {
+		if !floatEquals(probs[i], want[i], 1e-9) {
+			t.Errorf("probs[%d] = %.6f, want %.6f", i, probs[i], want[i])
+		}
+	}
+}
+
+func TestFairProbabilities_EvenMarketIsSymmetric(t *testing.T) {
+	for _, method := range []types.MarginMethod{
+		types.MarginMultiplicative, types.MarginAdditive, types.MarginPower,
+		types.MarginShin, types.MarginOddsRatio,
+	} {
+		probs, err := FairProbabilities([]float64{1.91, 1.91}, method)
+		if err != nil {
+			t.Fatalf("%s: FairProbabilities() error: %v", method, err)
+		}
+		if !floatEquals(probs[0], 0.5, 1e-6) || !floatEquals(probs[1], 0.5, 1e-6) {
+			t.Errorf("%s: probs = %v, want [0.5 0.5]", method, probs)
+		}
+	}
+}
+
+func TestFairProbabilities_LongshotBias(t *testing.T) {
+	// Shin and power remove more margin from the longshot than the
+	// multiplicative method does.
+	odds := []float64{1.25, 4.5}
+	mult, _ := FairProbabilities(odds, types.MarginMultiplicative)
+
+	for _, method := range []types.MarginMethod{types.MarginShin, types.MarginPower} {
+		probs, err := FairProbabilities(odds, method)
+		if err != nil {
+			t.Fatalf("%s: FairProbabilities() error: %v", method, err)
+		}
+		if probs[1] >= mult[1] {
+			t.Errorf("%s: longshot probability %.4f, want below multiplicative %.4f", method, probs[1], mult[1])
+		}
+	}
+}
+
+func TestFairProbabilities_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		odds   []float64
+		method types.MarginMethod
+	}{
+		{"single outcome", []float64{2.0}, types.MarginMultiplicative},
+		{"odds too low", []float64{1.0, 2.0}, types.MarginPower},
+		{"unknown method", []float64{1.9, 1.9}, "magic"},
+		{"shin on underround book", []float64{2.1, 2.1}, types.MarginShin},
+		{"additive negative probability", []float64{1.5, 1.5, 50.0}, types.MarginAdditive},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := FairProbabilities(tt.odds, tt.method); err == nil {
+				t.Error("FairProbabilities() expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestParseMethod(t *testing.T) {
+	if method, err := ParseMethod("shin"); err != nil || method != types.MarginShin {
+		t.Errorf("ParseMethod(shin) = %v, %v", method, err)
+	}
+	if _, err := ParseMethod("vig"); err == nil {
+		t.Error("ParseMethod(vig) expected error, got nil")
+	}
+}
+
+func TestOverround(t *testing.T) {
+	if got := Overround([]float64{1.91, 1.91}); !floatEquals(got, 2/1.91-1, 1e-9) {
+		t.Errorf("Overround() = %.6f, want %.6f", got, 2/1.91-1)
+	}
+}
+
+func floatEquals(a, b, tolerance float64) bool {
+	return math.Abs(a-b) <= tolerance
+}
@@ -6,13 +6,73 @@ import (
 	"math"
 	"strconv"
 	"strings"
+
+	"github.com/codehakase/kelly/pkg/types"
 )
 
+// formatHints maps the prefixes of hinted odds ("hk:0.85") and the names
+// accepted by ParseOddsFormat to their formats.
+var formatHints = map[string]types.OddsFormat{
+	"dec": types.FormatDecimal, "decimal": types.FormatDecimal,
+	"pct": types.FormatPercentage, "percentage": types.FormatPercentage,
+	"frac": types.FormatFractional, "fractional": types.FormatFractional,
+	"us": types.FormatAmerican, "american": types.FormatAmerican,
+	"hk": types.FormatHongKong, "hongkong": types.FormatHongKong,
+	"my": types.FormatMalay, "malay": types.FormatMalay,
+	"id": types.FormatIndonesian, "indo": types.FormatIndonesian, "indonesian": types.FormatIndonesian,
+}
+
+// ParseOddsFormat returns the odds format with the given name or hint
+// prefix, e.g. "hk" or "malay".
+func ParseOddsFormat(name string) (types.OddsFormat, error) {
+	if format, ok := formatHints[strings.ToLower(strings.TrimSpace(name))]; ok {
+		return format, nil
+	}
+	return "", fmt.Errorf("unknown odds format '%s' (use decimal, percentage, fractional, american, hk, malay or indo)", name)
+}
+
+// ParseOdds converts odds to decimal, detecting decimal, percentage,
+// fractional and American odds from their form. A format hint prefix such
+// as "hk:0.85", "my:-0.80" or "id:-1.25" selects the format explicitly;
+// Hong Kong, Malay and Indonesian odds must be hinted.
 func ParseOdds(input string) (float64, error) {
+	return ParseOddsAs(input, "")
+}
+
+// ParseOddsAs converts odds in the given format to decimal. An empty format
+// detects it as ParseOdds does; a hint prefix on the odds overrides format.
+func ParseOddsAs(input string, format types.OddsFormat) (float64, error) {
 	input = strings.TrimSpace(input)
 	if input == "" {
 		return 0, errors.New("odds cannot be empty")
 	}
+	if hint, value, ok := strings.Cut(input, ":"); ok {
+		hinted, err := ParseOddsFormat(hint)
+		if err != nil {
+			return 0, fmt.Errorf("invalid odds '%s': %w", input, err)
+		}
+		input, format = strings.TrimSpace(value), hinted
+	}
+
+	switch format {
+	case "":
+	case types.FormatDecimal:
+		return parseDecimal(input)
+	case types.FormatPercentage:
+		return parsePercentage(input)
+	case types.FormatFractional:
+		return parseFractional(input)
+	case types.FormatAmerican:
+		return parseAmerican(input)
+	case types.FormatHongKong:
+		return parseHongKong(input)
+	case types.FormatMalay:
+		return parseMalay(input)
+	case types.FormatIndonesian:
+		return parseIndonesian(input)
+	default:
+		return 0, fmt.Errorf("unknown odds format '%s'", format)
+	}
 
 	if strings.HasSuffix(input, "%") {
 		return parsePercentage(input)
@@ -85,6 +145,10 @@ func parseAmerican(input string) (float64, error) {
 	if american == 0 {
 		return 0, fmt.Errorf("American odds cannot be zero")
 	}
+	// Signed odds under 100 are Malay or Indonesian, never American.
+	if math.Abs(american) < 100 {
+		return 0, fmt.Errorf("American odds must be at least +100 or at most -100, got: %s (use my: or id: for Malay or Indonesian odds)", input)
+	}
 
 	if american > 0 {
 		return (american / 100.0) + 1.0, nil
@@ -92,6 +156,73 @@ func parseAmerican(input string) (float64, error) {
 	return (100.0 / math.Abs(american)) + 1.0, nil
 }
 
+// parseHongKong converts Hong Kong odds, the net win per unit staked.
+func parseHongKong(input string) (float64, error) {
+	hk, err := strconv.ParseFloat(input, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid Hong Kong odds '%s': %w", input, err)
+	}
+	if hk <= 0 {
+		return 0, fmt.Errorf("Hong Kong odds must be positive, got: %s", input)
+	}
+	return hk + 1.0, nil
+}
+
+// parseMalay converts Malay odds: positive odds up to 1 are the net win per
+// unit staked, negative odds the stake needed to win one unit.
+func parseMalay(input string) (float64, error) {
+	malay, err := strconv.ParseFloat(input, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid Malay odds '%s': %w", input, err)
+	}
+	if malay == 0 || math.Abs(malay) > 1 {
+		return 0, fmt.Errorf("Malay odds must be between -1 and 1 and not zero, got: %s", input)
+	}
+	if malay > 0 {
+		return malay + 1.0, nil
+	}
+	return 1.0/math.Abs(malay) + 1.0, nil
+}
+
+// parseIndonesian converts Indonesian odds, American odds divided by 100.
+func parseIndonesian(input string) (float64, error) {
+	indo, err := strconv.ParseFloat(input, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid Indonesian odds '%s': %w", input, err)
+	}
+	if math.Abs(indo) < 1 {
+		return 0, fmt.Errorf("Indonesian odds must be at least +1 or at most -1, got: %s", input)
+	}
+	if indo > 0 {
+		return indo + 1.0, nil
+	}
+	return 1.0/math.Abs(indo) + 1.0, nil
+}
+
+// ParsePlaceTerms parses each-way place terms, the fraction of the win odds
+// paid on a place, given as a fraction ("1/4") or a decimal ("0.25").
+func ParsePlaceTerms(input string) (float64, error) {
+	input = strings.TrimSpace(input)
+	var fraction float64
+	if strings.Contains(input, "/") {
+		odds, err := parseFractional(input)
+		if err != nil {
+			return 0, fmt.Errorf("invalid place terms '%s': %w", input, err)
+		}
+		fraction = odds - 1.0
+	} else {
+		var err error
+		fraction, err = strconv.ParseFloat(input, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid place terms '%s': %w", input, err)
+		}
+	}
+	if fraction <= 0 || fraction > 1 {
+		return 0, fmt.Errorf("place terms must be between 0 and 1, got: %s", input)
+	}
+	return fraction, nil
+}
+
 func ImpliedProbability(decimalOdds float64) float64 {
 	if decimalOdds <= 0 {
 		return 0
@@ -3,6 +3,8 @@ package parser
 import (
 	"math"
 	"testing"
+
+	"github.com/codehakase/kelly/pkg/types"
 )
 
 func TestParseOdds(t *testing.T) {
@@ -45,6 +47,16 @@ func TestParseOdds(t *testing.T) {
 		{"american +200", "+200", 3.0, false},
 		{"american -200", "-200", 1.5, false},
 
+		// Hinted formats
+		{"hong kong 0.85", "hk:0.85", 1.85, false},
+		{"malay positive", "my:0.80", 1.8, false},
+		{"malay negative", "my:-0.80", 2.25, false},
+		{"malay hint in capitals", "MY: -0.5", 3.0, false},
+		{"indonesian negative", "id:-1.25", 1.8, false},
+		{"indonesian positive", "indo:1.50", 2.5, false},
+		{"decimal hint", "dec:2.5", 2.5, false},
+		{"american hint", "us:+150", 2.5, false},
+
 		// Error cases
 		{"empty string", "", 0, true},
 		{"invalid decimal", "abc", 0, true},
@@ -57,6 +69,10 @@ func TestParseOdds(t *testing.T) {
 		{"invalid american", "+abc", 0, true},
 		{"american zero", "+0", 0, true},
 		{"decimal less than 1", "0.5", 0, true},
+		{"unhinted malay", "-0.80", 0, true},
+		{"unhinted indonesian", "+1.50", 0, true},
+		{"unknown hint", "xx:2.0", 0, true},
+		{"malay beyond 1", "my:-1.5", 0, true},
 	}
 
 	for _, tt := range tests {
@@ -203,6 +219,46 @@ func TestParseFractional(t *testing.T) {
 	}
 }
 
+func TestParsePlaceTerms(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected float64
+		wantErr  bool
+	}{
+		{"quarter odds", "1/4", 0.25, false},
+		{"fifth odds", " 1/5 ", 0.2, false},
+		{"decimal", "0.25", 0.25, false},
+		{"full odds", "1/1", 1.0, false},
+		{"above one", "5/4", 0, true},
+		{"zero", "0", 0, true},
+		{"invalid", "quarter", 0, true},
+		{"division by zero", "1/0", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := ParsePlaceTerms(tt.input)
+
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ParsePlaceTerms(%q) expected error, got nil", tt.input)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Errorf("ParsePlaceTerms(%q) unexpected error: %v", tt.input, err)
+				return
+			}
+
+			if !floatEquals(result, tt.expected, 0.0001) {
+				t.Errorf("ParsePlaceTerms(%q) = %v, want %v", tt.input, result, tt.expected)
+			}
+		})
+	}
+}
+
 func TestParseAmerican(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -218,6 +274,8 @@ func TestParseAmerican(t *testing.T) {
 		{"-200", "-200", 1.5, false},
 		{"invalid", "+abc", 0, true},
 		{"zero", "+0", 0, true},
+		{"under 100", "+50", 0, true},
+		{"over -100", "-80", 0, true},
 	}
 
 	for _, tt := range tests {
@@ -243,6 +301,74 @@ func TestParseAmerican(t *testing.T) {
 	}
 }
 
+func TestParseOddsAs(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		format   types.OddsFormat
+		expected float64
+		wantErr  bool
+	}{
+		{"hong kong", "0.85", types.FormatHongKong, 1.85, false},
+		{"malay negative", "-0.80", types.FormatMalay, 2.25, false},
+		{"malay positive", "0.5", types.FormatMalay, 1.5, false},
+		{"indonesian negative", "-1.25", types.FormatIndonesian, 1.8, false},
+		{"indonesian positive", "1.5", types.FormatIndonesian, 2.5, false},
+		{"detected", "+250", "", 3.5, false},
+		{"hint overrides format", "hk:0.5", types.FormatMalay, 1.5, false},
+		{"hong kong negative", "-0.5", types.FormatHongKong, 0, true},
+		{"malay zero", "0", types.FormatMalay, 0, true},
+		{"indonesian between -1 and 1", "0.5", types.FormatIndonesian, 0, true},
+		{"decimal that is malay", "-0.80", types.FormatDecimal, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := ParseOddsAs(tt.input, tt.format)
+
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ParseOddsAs(%q, %q) expected error, got nil", tt.input, tt.format)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Errorf("ParseOddsAs(%q, %q) unexpected error: %v", tt.input, tt.format, err)
+				return
+			}
+
+			if !floatEquals(result, tt.expected, 0.0001) {
+				t.Errorf("ParseOddsAs(%q, %q) = %v, want %v", tt.input, tt.format, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseOddsFormat(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    types.OddsFormat
+		wantErr bool
+	}{
+		{"hk", types.FormatHongKong, false},
+		{"Malay", types.FormatMalay, false},
+		{"indo", types.FormatIndonesian, false},
+		{"id", types.FormatIndonesian, false},
+		{"american", types.FormatAmerican, false},
+		{"euro", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseOddsFormat(tt.name)
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("ParseOddsFormat(%q) = %q, %v; want %q", tt.name, got, err, tt.want)
+			}
+		})
+	}
+}
+
 func TestImpliedProbability(t *testing.T) {
 	tests := []struct {
 		name     string
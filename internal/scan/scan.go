@@ -0,0 +1,243 @@
+package scan
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/codehakase/kelly/internal/calculator"
+	"github.com/codehakase/kelly/internal/parser"
+	"github.com/codehakase/kelly/pkg/money"
+	"github.com/codehakase/kelly/pkg/types"
+)
+
+// Quote is one bookmaker's decimal odds for an outcome of an event. Odds
+// of zero list the outcome without a price, as a suspended market does.
+type Quote struct {
+	Event   string
+	Outcome string
+	Odds    float64
+}
+
+// Book is the odds offered by one bookmaker.
+type Book struct {
+	Name   string
+	Quotes []Quote
+}
+
+// LoadDir reads every .csv and .json file in dir as a bookmaker named after
+// the file.
+//
+// CSV files need event, outcome and odds columns. JSON files map each event
+// to its outcomes and their odds:
+//
+//	{"Arsenal v Spurs": {"Home": 2.1, "Draw": "3.4", "Away": "5/2"}}
+//
+// Odds may be in any format accepted by parser.ParseOdds. An empty odds
+// cell or a null lists an outcome the book has no price for.
+func LoadDir(dir string) ([]Book, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var books []Book
+	for _, entry := range entries {
+		ext := strings.ToLower(filepath.Ext(entry.Name()))
+		if entry.IsDir() || (ext != ".csv" && ext != ".json") {
+			continue
+		}
+
+		path := filepath.Join(dir, entry.Name())
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		book := Book{Name: strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name()))}
+		if ext == ".csv" {
+			book.Quotes, err = readCSV(f)
+		} else {
+			book.Quotes, err = readJSON(f)
+		}
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		books = append(books, book)
+	}
+
+	if len(books) == 0 {
+		return nil, fmt.Errorf("no .csv or .json odds files in %s", dir)
+	}
+	return books, nil
+}
+
+func readCSV(r io.Reader) ([]Quote, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("reading header: %w", err)
+	}
+
+	cols := map[string]int{"event": -1, "outcome": -1, "odds": -1}
+	for i, name := range header {
+		if _, ok := cols[strings.ToLower(strings.TrimSpace(name))]; ok {
+			cols[strings.ToLower(strings.TrimSpace(name))] = i
+		}
+	}
+	for name, i := range cols {
+		if i < 0 {
+			return nil, fmt.Errorf("missing '%s' column", name)
+		}
+	}
+
+	var quotes []Quote
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		var odds float64
+		if raw := strings.TrimSpace(record[cols["odds"]]); raw != "" {
+			if odds, err = parser.ParseOdds(raw); err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+		}
+		quotes = append(quotes, Quote{
+			Event:   strings.TrimSpace(record[cols["event"]]),
+			Outcome: strings.TrimSpace(record[cols["outcome"]]),
+			Odds:    odds,
+		})
+	}
+	return quotes, nil
+}
+
+func readJSON(r io.Reader) ([]Quote, error) {
+	var events map[string]map[string]any
+	if err := json.NewDecoder(r).Decode(&events); err != nil {
+		return nil, err
+	}
+
+	// Maps have no order; sort so results are stable between runs.
+	var quotes []Quote
+	for _, event := range sortedKeys(events) {
+		for _, outcome := range sortedKeys(events[event]) {
+			var odds float64
+			var err error
+			switch v := events[event][outcome].(type) {
+			case nil:
+			case float64:
+				odds = v
+			case string:
+				if odds, err = parser.ParseOdds(v); err != nil {
+					return nil, fmt.Errorf("%s / %s: %w", event, outcome, err)
+				}
+			default:
+				return nil, fmt.Errorf("%s / %s: odds must be a number, string or null", event, outcome)
+			}
+			quotes = append(quotes, Quote{Event: event, Outcome: outcome, Odds: odds})
+		}
+	}
+	return quotes, nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// market collects the best price for each outcome of one event.
+type market struct {
+	name    string
+	options []types.Option
+	index   map[string]int
+}
+
+// quoted reports whether some book prices every outcome of the market.
+func (m *market) quoted() bool {
+	for _, opt := range m.options {
+		if opt.Odds <= 1.0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Scan takes the best price for every outcome of every event across books
+// and returns the events priced under 100%, with arbitrage stakes for
+// total. Events and outcomes are matched by name, ignoring case. An
+// event's outcomes are every outcome any book lists for it, and an event
+// with an outcome no book prices is skipped: without it the market is
+// incomplete and could pass for an arbitrage. An outcome no book lists at
+// all cannot be seen, so books must list every outcome of their events.
+func Scan(books []Book, total money.Amount, currency string) (*types.ScanResult, error) {
+	if total <= 0 {
+		return nil, errors.New("total must be positive")
+	}
+
+	var markets []*market
+	byEvent := make(map[string]*market)
+	for _, book := range books {
+		for _, q := range book.Quotes {
+			eventKey := strings.ToLower(q.Event)
+			m, ok := byEvent[eventKey]
+			if !ok {
+				m = &market{name: q.Event, index: make(map[string]int)}
+				byEvent[eventKey] = m
+				markets = append(markets, m)
+			}
+
+			outcomeKey := strings.ToLower(q.Outcome)
+			i, ok := m.index[outcomeKey]
+			if !ok {
+				i = len(m.options)
+				m.index[outcomeKey] = i
+				m.options = append(m.options, types.Option{Name: q.Outcome})
+			}
+			if q.Odds > 1.0 && q.Odds > m.options[i].Odds {
+				m.options[i].Odds = q.Odds
+				m.options[i].Bookmaker = book.Name
+			}
+		}
+	}
+
+	result := &types.ScanResult{Books: len(books), Events: len(markets), Arbitrages: []types.ScanEvent{}}
+	calc := calculator.NewCalculator(types.MethodArbitrage)
+	for _, m := range markets {
+		if len(m.options) < 2 || !m.quoted() {
+			continue
+		}
+		res, err := calc.Calculate(&types.CalculationInput{
+			Method: types.MethodArbitrage, Options: m.options, TotalStake: total, Currency: currency,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", m.name, err)
+		}
+		if res.Summary.MarketEfficiency < 1.0 {
+			result.Arbitrages = append(result.Arbitrages, types.ScanEvent{Event: m.name, Result: *res})
+		}
+	}
+
+	sort.SliceStable(result.Arbitrages, func(i, j int) bool {
+		a, b := result.Arbitrages[i].Result.Summary, result.Arbitrages[j].Result.Summary
+		if a.MinROI != b.MinROI {
+			return a.MinROI > b.MinROI
+		}
+		return a.MarketEfficiency < b.MarketEfficiency
+	})
+	return result, nil
+}
@@ -0,0 +1,158 @@
+package scan
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/codehakase/kelly/pkg/money"
+)
+
+func writeBooks(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestLoadDir(t *testing.T) {
+	dir := writeBooks(t, map[string]string{
+		"bet9ja.csv":     "event,outcome,odds\nArsenal v Spurs,Home,2.10\nArsenal v Spurs,Away,3/2\n",
+		"sportybet.json": `{"Arsenal v Spurs": {"Home": 1.9, "Away": "2.3", "Draw": null}}`,
+		"betway.csv":     "event,outcome,odds\nArsenal v Spurs,Draw,\n",
+		"notes.txt":      "ignored",
+	})
+
+	books, err := LoadDir(dir)
+	if err != nil {
+		t.Fatalf("LoadDir() error: %v", err)
+	}
+	if len(books) != 3 {
+		t.Fatalf("LoadDir() = %d books, want 3", len(books))
+	}
+	if books[0].Name != "bet9ja" || len(books[0].Quotes) != 2 || books[0].Quotes[1].Odds != 2.5 {
+		t.Errorf("CSV book = %+v", books[0])
+	}
+	// Unpriced outcomes are listed with odds of zero.
+	if books[1].Name != "betway" || len(books[1].Quotes) != 1 || books[1].Quotes[0].Odds != 0 {
+		t.Errorf("CSV book without a price = %+v", books[1])
+	}
+	if books[2].Name != "sportybet" || len(books[2].Quotes) != 3 || books[2].Quotes[0].Outcome != "Away" ||
+		books[2].Quotes[1].Outcome != "Draw" || books[2].Quotes[1].Odds != 0 {
+		t.Errorf("JSON book = %+v", books[2])
+	}
+}
+
+func TestLoadDir_Errors(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+	}{
+		{"empty dir", map[string]string{}},
+		{"missing column", map[string]string{"a.csv": "event,odds\nX,2.0\n"}},
+		{"bad odds", map[string]string{"a.csv": "event,outcome,odds\nX,Home,abc\n"}},
+		{"bad json", map[string]string{"a.json": `{"X": {"Home": true}}`}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := LoadDir(writeBooks(t, tt.files)); err == nil {
+				t.Error("LoadDir() expected error")
+			}
+		})
+	}
+}
+
+func TestScan(t *testing.T) {
+	books := []Book{
+		{Name: "alpha", Quotes: []Quote{
+			{"Arsenal v Spurs", "Home", 2.20}, {"Arsenal v Spurs", "Away", 1.70},
+			{"Chelsea v Fulham", "Home", 2.00}, {"Chelsea v Fulham", "Away", 1.90},
+			{"Leeds v Hull", "Home", 2.10}, {"Leeds v Hull", "Away", 2.00},
+		}},
+		{Name: "beta", Quotes: []Quote{
+			{"arsenal v spurs", "home", 2.00}, {"arsenal v spurs", "away", 2.00},
+			{"Chelsea v Fulham", "Home", 1.80}, {"Chelsea v Fulham", "Away", 1.95},
+			{"Leeds v Hull", "Home", 2.05}, {"Leeds v Hull", "Away", 2.15},
+			{"Derby v Stoke", "Home", 1.50},
+		}},
+	}
+
+	result, err := Scan(books, money.FromFloat(1000), "$")
+	if err != nil {
+		t.Fatalf("Scan() error: %v", err)
+	}
+	if result.Books != 2 || result.Events != 4 {
+		t.Errorf("Books = %d, Events = %d; want 2, 4", result.Books, result.Events)
+	}
+
+	// Arsenal: 1/2.2 + 1/2.0 = 0.9545; Leeds: 1/2.1 + 1/2.15 = 0.9413;
+	// Chelsea: 1/2.0 + 1/1.95 = 1.0128 is no arbitrage.
+	if len(result.Arbitrages) != 2 {
+		t.Fatalf("Scan() found %d arbitrages, want 2", len(result.Arbitrages))
+	}
+	best := result.Arbitrages[0]
+	if best.Event != "Leeds v Hull" {
+		t.Errorf("best arbitrage = %s, want Leeds v Hull", best.Event)
+	}
+	if best.Result.Options[0].Bookmaker != "alpha" || best.Result.Options[1].Bookmaker != "beta" {
+		t.Errorf("legs placed at %s and %s, want alpha and beta",
+			best.Result.Options[0].Bookmaker, best.Result.Options[1].Bookmaker)
+	}
+	if best.Result.Summary.MarketEfficiency >= 1 || best.Result.TotalStake != money.FromFloat(1000) {
+		t.Errorf("best arbitrage summary = %+v", best.Result.Summary)
+	}
+
+	second := result.Arbitrages[1]
+	if second.Result.Options[0].Odds != 2.2 || second.Result.Options[1].Odds != 2.0 {
+		t.Errorf("Arsenal v Spurs should use the best price per outcome, got %+v", second.Result.Options)
+	}
+	if best.Result.Summary.MinROI < second.Result.Summary.MinROI {
+		t.Error("arbitrages should be sorted by ROI, best first")
+	}
+
+	if _, err := Scan(books, 0, "$"); err == nil {
+		t.Error("Scan() should reject a non-positive total")
+	}
+}
+
+func TestScan_IncompleteMarket(t *testing.T) {
+	// Home and away alone are priced under 100% (1/2.2 + 1/2.5 = 0.85),
+	// but no book prices the draw, so the market is not an arbitrage.
+	books := []Book{
+		{Name: "alpha", Quotes: []Quote{
+			{"Derby v Stoke", "Home", 2.2}, {"Derby v Stoke", "Draw", 0}, {"Derby v Stoke", "Away", 2.0},
+			{"Leeds v Hull", "Home", 2.1}, {"Leeds v Hull", "Draw", 1.0}, {"Leeds v Hull", "Away", 2.15},
+		}},
+		{Name: "beta", Quotes: []Quote{
+			{"Derby v Stoke", "Away", 2.5},
+			{"Leeds v Hull", "Draw", 9.0},
+		}},
+	}
+
+	result, err := Scan(books, money.FromFloat(1000), "$")
+	if err != nil {
+		t.Fatalf("Scan() error: %v", err)
+	}
+	if result.Events != 2 {
+		t.Errorf("Events = %d, want 2", result.Events)
+	}
+	// Leeds is complete once beta prices the draw: 0.476 + 0.111 + 0.465.
+	// That is over 100%, so it is no arbitrage either.
+	if len(result.Arbitrages) != 0 {
+		t.Errorf("Scan() found %+v, want no arbitrages in incomplete or overround markets", result.Arbitrages)
+	}
+
+	books[1].Quotes[1].Odds = 30
+	result, err = Scan(books, money.FromFloat(1000), "$")
+	if err != nil {
+		t.Fatalf("Scan() error: %v", err)
+	}
+	if len(result.Arbitrages) != 1 || result.Arbitrages[0].Event != "Leeds v Hull" || len(result.Arbitrages[0].Result.Options) != 3 {
+		t.Errorf("Scan() = %+v, want only the complete Leeds v Hull market", result.Arbitrages)
+	}
+}
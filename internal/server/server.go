@@ -0,0 +1,132 @@
+// Package server serves kelly's calculators as a JSON API over HTTP.
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/codehakase/kelly/internal/calculator"
+	"github.com/codehakase/kelly/internal/formatter"
+	"github.com/codehakase/kelly/internal/parser"
+	"github.com/codehakase/kelly/internal/validator"
+	"github.com/codehakase/kelly/pkg/types"
+)
+
+// maxBody caps the size of a request body.
+const maxBody = 1 << 20
+
+// New returns a server for Handler on addr. Its timeouts keep a slow client
+// from holding a connection open indefinitely.
+func New(addr string) *http.Server {
+	return &http.Server{
+		Addr:              addr,
+		Handler:           Handler(),
+		ReadHeaderTimeout: 5 * time.Second,
+		ReadTimeout:       10 * time.Second,
+		WriteTimeout:      30 * time.Second,
+		IdleTimeout:       60 * time.Second,
+	}
+}
+
+// Handler returns the API:
+//
+//	POST /calculate          a CalculationInput in, its CalculationResult out
+//	GET  /convert?odds=5/2   the odds in every format, read as ?format=
+//	                         when given; a + in American odds is %2B
+//
+// Errors come back as {"error": "..."} with a 400 status for input that
+// cannot be read, 413 for a body over maxBody and 422 for input that cannot
+// be calculated.
+func Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /calculate", calculate)
+	mux.HandleFunc("GET /convert", convert)
+	return limitBody(mux)
+}
+
+// limitBody caps the body of every request at maxBody.
+func limitBody(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.Body = http.MaxBytesReader(w, r.Body, maxBody)
+		h.ServeHTTP(w, r)
+	})
+}
+
+func calculate(w http.ResponseWriter, r *http.Request) {
+	var input types.CalculationInput
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&input); err != nil {
+		status := http.StatusBadRequest
+		if tooLarge := new(http.MaxBytesError); errors.As(err, &tooLarge) {
+			status = http.StatusRequestEntityTooLarge
+		}
+		writeError(w, status, fmt.Errorf("reading calculation input: %w", err))
+		return
+	}
+	if input.Method == "" {
+		input.Method = types.MethodArbitrage
+	}
+
+	if err := validator.ValidateCalculationInput(&input); err != nil {
+		writeError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+	result, err := calculator.NewCalculator(input.Method).Calculate(&input)
+	if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// conversion is one format of the odds returned by /convert.
+type conversion struct {
+	Format types.OddsFormat `json:"format"`
+	Name   string           `json:"name"`
+	Odds   string           `json:"odds"`
+}
+
+func convert(w http.ResponseWriter, r *http.Request) {
+	raw := r.URL.Query().Get("odds")
+	if raw == "" {
+		writeError(w, http.StatusBadRequest, errors.New("convert requires ?odds="))
+		return
+	}
+	var format types.OddsFormat
+	if name := r.URL.Query().Get("format"); name != "" {
+		var err error
+		if format, err = parser.ParseOddsFormat(name); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+	odds, err := parser.ParseOddsAs(raw, format)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if odds <= 1.0 {
+		writeError(w, http.StatusUnprocessableEntity, fmt.Errorf("odds must be greater than 1.0, got '%s'", raw))
+		return
+	}
+
+	var conversions []conversion
+	for _, c := range formatter.Conversions(odds) {
+		conversions = append(conversions, conversion{Format: c.Format, Name: formatter.OddsName(c.Format), Odds: c.Odds})
+	}
+	writeJSON(w, http.StatusOK, conversions)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,114 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/codehakase/kelly/pkg/money"
+	"github.com/codehakase/kelly/pkg/types"
+)
+
+func TestCalculate(t *testing.T) {
+	body := `{"options": [{"odds": 2.1}, {"odds": 2.1}], "total_stake": 1000}`
+	rec := httptest.NewRecorder()
+	Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/calculate", strings.NewReader(body)))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body)
+	}
+	var result types.CalculationResult
+	if err := json.NewDecoder(rec.Body).Decode(&result); err != nil {
+		t.Fatal(err)
+	}
+	if result.Method != types.MethodArbitrage || len(result.Options) != 2 {
+		t.Fatalf("result = %+v, want a two-option arbitrage", result)
+	}
+	if result.Options[0].Stake != money.FromFloat(500) || !result.Summary.GuaranteedProfit {
+		t.Errorf("stake = %.2f, guaranteed = %v; want 500 and true", result.Options[0].Stake.Float64(), result.Summary.GuaranteedProfit)
+	}
+}
+
+func TestCalculate_Errors(t *testing.T) {
+	tests := []struct {
+		name, body string
+		want       int
+	}{
+		{"not json", `odds: 2.1`, http.StatusBadRequest},
+		{"unknown field", `{"odds_c": 2.1}`, http.StatusBadRequest},
+		{"no total", `{"options": [{"odds": 2.1}, {"odds": 2.1}]}`, http.StatusUnprocessableEntity},
+		{"body too large", `{"options": [` + strings.Repeat(`{"odds": 2.1},`, maxBody/10) + `{"odds": 2.1}]}`, http.StatusRequestEntityTooLarge},
+		{"kelly without probabilities", `{"method": "kelly", "options": [{"odds": 2.1}, {"odds": 2.1}], "total_stake": 100}`, http.StatusUnprocessableEntity},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/calculate", strings.NewReader(tt.body)))
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d", rec.Code, tt.want)
+			}
+			var body map[string]string
+			if err := json.NewDecoder(rec.Body).Decode(&body); err != nil || body["error"] == "" {
+				t.Errorf("body = %v, want an error message", body)
+			}
+		})
+	}
+}
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		query string
+		want  int
+		odds  string
+	}{
+		{"odds=5/2", http.StatusOK, "3.50"},
+		{"odds=0.95&format=hk", http.StatusOK, "1.95"},
+		{"odds=%2B150", http.StatusOK, "2.50"},
+		{"", http.StatusBadRequest, ""},
+		{"odds=abc", http.StatusBadRequest, ""},
+		{"odds=2.5&format=roman", http.StatusBadRequest, ""},
+		{"odds=1.0", http.StatusUnprocessableEntity, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/convert?"+tt.query, nil))
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.want, rec.Body)
+			}
+			if tt.want != http.StatusOK {
+				return
+			}
+			var conversions []conversion
+			if err := json.NewDecoder(rec.Body).Decode(&conversions); err != nil {
+				t.Fatal(err)
+			}
+			if conversions[0].Format != types.FormatDecimal || conversions[0].Odds != tt.odds {
+				t.Errorf("first conversion = %+v, want decimal %s", conversions[0], tt.odds)
+			}
+		})
+	}
+}
+
+func TestHandler_MethodNotAllowed(t *testing.T) {
+	rec := httptest.NewRecorder()
+	Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/calculate", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET /calculate status = %d, want 405", rec.Code)
+	}
+}
+
+func TestNew(t *testing.T) {
+	srv := New("localhost:0")
+	if srv.Addr != "localhost:0" || srv.Handler == nil {
+		t.Fatalf("New() = %+v, want the API on localhost:0", srv)
+	}
+	if srv.ReadHeaderTimeout <= 0 || srv.ReadTimeout <= 0 || srv.WriteTimeout <= 0 || srv.IdleTimeout <= 0 {
+		t.Errorf("timeouts = %v / %v / %v / %v, want all set",
+			srv.ReadHeaderTimeout, srv.ReadTimeout, srv.WriteTimeout, srv.IdleTimeout)
+	}
+}
@@ -0,0 +1,162 @@
+package simulate
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"sort"
+
+	"github.com/codehakase/kelly/internal/journal"
+	"github.com/codehakase/kelly/pkg/types"
+)
+
+// Config controls a simulation. Probabilities are the true chance of each
+// option winning; any remainder is the chance that none of them does.
+// RuinThreshold is the fraction of the starting bankroll at or below which
+// a sequence counts as ruined and stops.
+type Config struct {
+	Runs          int
+	Bets          int
+	Seed          uint64
+	RuinThreshold float64
+	Probabilities []float64
+}
+
+// outcome is one way a bet can settle: its probability and the change in
+// bankroll it causes, as a fraction of the amount the result allocates.
+type outcome struct {
+	prob, ret float64
+}
+
+// Run repeats the bet described by result over cfg.Runs sequences of
+// cfg.Bets bets. Every bet stakes the same fractions of the current
+// bankroll that result stakes of its total, so the bankroll compounds.
+func Run(result *types.CalculationResult, cfg Config) (*types.SimulationResult, error) {
+	outcomes, err := outcomes(result, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	rng := rand.New(rand.NewPCG(cfg.Seed, cfg.Seed^0x9e3779b97f4a7c15))
+	finals := make([]float64, cfg.Runs)
+	drawdowns := make([]float64, cfg.Runs)
+	growths := make([]float64, cfg.Runs)
+	ruined := 0
+
+	for run := 0; run < cfg.Runs; run++ {
+		wealth, peak, maxDrawdown := 1.0, 1.0, 0.0
+		for bet := 0; bet < cfg.Bets; bet++ {
+			wealth *= 1.0 + pick(outcomes, rng.Float64()).ret
+			if wealth > peak {
+				peak = wealth
+			}
+			maxDrawdown = math.Max(maxDrawdown, 1.0-wealth/peak)
+			if wealth <= cfg.RuinThreshold {
+				ruined++
+				break
+			}
+		}
+		finals[run] = wealth * result.TotalStake.Float64()
+		drawdowns[run] = maxDrawdown
+		growths[run] = math.Log(math.Max(wealth, math.SmallestNonzeroFloat64)) / float64(cfg.Bets)
+	}
+
+	var expectedGrowth float64
+	for _, o := range outcomes {
+		if o.prob > 0 {
+			expectedGrowth += o.prob * math.Log(math.Max(1.0+o.ret, math.SmallestNonzeroFloat64))
+		}
+	}
+
+	return &types.SimulationResult{
+		Method:             result.Method,
+		Currency:           result.Currency,
+		StartingBankroll:   result.TotalStake,
+		Runs:               cfg.Runs,
+		Bets:               cfg.Bets,
+		Seed:               cfg.Seed,
+		RuinThreshold:      cfg.RuinThreshold,
+		FinalBankroll:      distribution(finals),
+		MaxDrawdown:        distribution(drawdowns),
+		RuinProbability:    float64(ruined) / float64(cfg.Runs),
+		MedianGrowthRate:   percentile(sorted(growths), 0.5),
+		ExpectedGrowthRate: expectedGrowth,
+	}, nil
+}
+
+func outcomes(result *types.CalculationResult, cfg Config) ([]outcome, error) {
+	if cfg.Runs <= 0 || cfg.Bets <= 0 {
+		return nil, errors.New("runs and bets must be positive")
+	}
+	if cfg.RuinThreshold < 0 || cfg.RuinThreshold >= 1 {
+		return nil, errors.New("ruin threshold must be between 0 and 1")
+	}
+	if result.TotalStake <= 0 {
+		return nil, errors.New("result has no bankroll to simulate")
+	}
+	if len(cfg.Probabilities) != len(result.Options) {
+		return nil, fmt.Errorf("got %d probabilities for %d options", len(cfg.Probabilities), len(result.Options))
+	}
+
+	var sum float64
+	outcomes := make([]outcome, 0, len(result.Options)+1)
+	for i, p := range cfg.Probabilities {
+		if p < 0 || p > 1 {
+			return nil, fmt.Errorf("probability for %s must be between 0 and 1", types.OptionLabel(i))
+		}
+		sum += p
+		outcomes = append(outcomes, outcome{p, journal.SettlementPnL(result, i).Float64() / result.TotalStake.Float64()})
+	}
+	if sum > 1.0+1e-9 {
+		return nil, fmt.Errorf("probabilities sum to %.4f, must not exceed 1", sum)
+	}
+	if rest := 1.0 - sum; rest > 1e-9 {
+		outcomes = append(outcomes, outcome{rest, journal.SettlementPnL(result, -1).Float64() / result.TotalStake.Float64()})
+	}
+	return outcomes, nil
+}
+
+// pick returns the outcome that the uniform draw u falls in.
+func pick(outcomes []outcome, u float64) outcome {
+	for _, o := range outcomes {
+		if u < o.prob {
+			return o
+		}
+		u -= o.prob
+	}
+	return outcomes[len(outcomes)-1]
+}
+
+func distribution(values []float64) types.Distribution {
+	s := sorted(values)
+	var sum float64
+	for _, v := range s {
+		sum += v
+	}
+	return types.Distribution{
+		Mean:   sum / float64(len(s)),
+		P5:     percentile(s, 0.05),
+		P25:    percentile(s, 0.25),
+		Median: percentile(s, 0.5),
+		P75:    percentile(s, 0.75),
+		P95:    percentile(s, 0.95),
+	}
+}
+
+func sorted(values []float64) []float64 {
+	s := append([]float64(nil), values...)
+	sort.Float64s(s)
+	return s
+}
+
+// percentile interpolates linearly between the closest ranks of sorted s.
+func percentile(s []float64, q float64) float64 {
+	if len(s) == 0 {
+		return 0
+	}
+	pos := q * float64(len(s)-1)
+	lo := int(math.Floor(pos))
+	hi := int(math.Ceil(pos))
+	return s[lo] + (s[hi]-s[lo])*(pos-float64(lo))
+}
@@ -0,0 +1,123 @@
+package simulate
+
+import (
+	"math"
+	"testing"
+
+	"github.com/codehakase/kelly/pkg/money"
+	"github.com/codehakase/kelly/pkg/types"
+)
+
+// kellyBet stakes 10% of the bankroll on even money with a 55% chance of
+// winning, the full Kelly fraction.
+func kellyBet() *types.CalculationResult {
+	return &types.CalculationResult{
+		Method:     types.MethodKelly,
+		TotalStake: money.FromFloat(1000),
+		Options:    []types.Option{{Name: "A", Odds: 2.0, Stake: money.FromFloat(100)}},
+	}
+}
+
+func TestRun_Reproducible(t *testing.T) {
+	cfg := Config{Runs: 500, Bets: 50, Seed: 42, RuinThreshold: 0.01, Probabilities: []float64{0.55}}
+
+	first, err := Run(kellyBet(), cfg)
+	if err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	second, err := Run(kellyBet(), cfg)
+	if err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	if first.FinalBankroll != second.FinalBankroll {
+		t.Errorf("Run() with the same seed differs: %+v vs %+v", first.FinalBankroll, second.FinalBankroll)
+	}
+
+	cfg.Seed = 43
+	third, _ := Run(kellyBet(), cfg)
+	if first.FinalBankroll == third.FinalBankroll {
+		t.Error("Run() with a different seed should differ")
+	}
+}
+
+func TestRun_Statistics(t *testing.T) {
+	result, err := Run(kellyBet(), Config{
+		Runs: 20000, Bets: 100, Seed: 1, RuinThreshold: 0.01, Probabilities: []float64{0.55},
+	})
+	if err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+
+	// g = 0.55 ln 1.1 + 0.45 ln 0.9
+	want := 0.55*math.Log(1.1) + 0.45*math.Log(0.9)
+	if math.Abs(result.ExpectedGrowthRate-want) > 1e-9 {
+		t.Errorf("ExpectedGrowthRate = %.6f, want %.6f", result.ExpectedGrowthRate, want)
+	}
+	if math.Abs(result.MedianGrowthRate-want) > 0.002 {
+		t.Errorf("MedianGrowthRate = %.6f, want about %.6f", result.MedianGrowthRate, want)
+	}
+
+	d := result.FinalBankroll
+	if !(d.P5 <= d.P25 && d.P25 <= d.Median && d.Median <= d.P75 && d.P75 <= d.P95) {
+		t.Errorf("FinalBankroll percentiles are not ordered: %+v", d)
+	}
+	if result.RuinProbability != 0 {
+		t.Errorf("RuinProbability = %.4f, want 0 at 10%% stakes over 100 bets", result.RuinProbability)
+	}
+	if result.MaxDrawdown.Median <= 0 || result.MaxDrawdown.P95 >= 1 {
+		t.Errorf("MaxDrawdown = %+v, want between 0 and 1", result.MaxDrawdown)
+	}
+}
+
+func TestRun_Ruin(t *testing.T) {
+	allIn := &types.CalculationResult{
+		TotalStake: money.FromFloat(100),
+		Options:    []types.Option{{Name: "A", Odds: 2.0, Stake: money.FromFloat(100)}},
+	}
+	result, err := Run(allIn, Config{Runs: 2000, Bets: 10, Seed: 7, Probabilities: []float64{0.5}})
+	if err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+
+	// Staking everything survives ten bets only by winning all ten.
+	want := 1 - math.Pow(0.5, 10)
+	if math.Abs(result.RuinProbability-want) > 0.01 {
+		t.Errorf("RuinProbability = %.4f, want about %.4f", result.RuinProbability, want)
+	}
+}
+
+func TestRun_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  Config
+	}{
+		{"no runs", Config{Runs: 0, Bets: 10, Probabilities: []float64{0.5}}},
+		{"no bets", Config{Runs: 10, Bets: 0, Probabilities: []float64{0.5}}},
+		{"wrong count", Config{Runs: 10, Bets: 10, Probabilities: []float64{0.5, 0.5}}},
+		{"out of range", Config{Runs: 10, Bets: 10, Probabilities: []float64{1.5}}},
+		{"bad ruin", Config{Runs: 10, Bets: 10, RuinThreshold: 1, Probabilities: []float64{0.5}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Run(kellyBet(), tt.cfg); err == nil {
+				t.Error("Run() expected error")
+			}
+		})
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	s := []float64{1, 2, 3, 4, 5}
+	tests := []struct {
+		q    float64
+		want float64
+	}{
+		{0, 1}, {0.5, 3}, {1, 5}, {0.25, 2}, {0.1, 1.4},
+	}
+	for _, tt := range tests {
+		if got := percentile(s, tt.q); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("percentile(%v) = %v, want %v", tt.q, got, tt.want)
+		}
+	}
+}
@@ -154,6 +154,8 @@ func HelpOverlay(width, height int) string {
 	sb.WriteString(keyStyle.Render("Enter") + descStyle.Render("Calculate allocation") + "\n")
 	sb.WriteString(keyStyle.Render("m") + descStyle.Render("Cycle calculation method") + "\n")
 	sb.WriteString(keyStyle.Render("c") + descStyle.Render("Toggle comparison mode") + "\n")
+	sb.WriteString(keyStyle.Render("Ctrl+N") + descStyle.Render("Add an option") + "\n")
+	sb.WriteString(keyStyle.Render("Ctrl+X") + descStyle.Render("Remove the last option") + "\n")
 	sb.WriteString(keyStyle.Render("r") + descStyle.Render("Reset all inputs") + "\n\n")
 
 	sb.WriteString(sectionStyle.Render("General") + "\n")
@@ -163,7 +165,8 @@ func HelpOverlay(width, height int) string {
 	sb.WriteString(sectionStyle.Render("Methods") + "\n")
 	sb.WriteString(descStyle.Render("• Arbitrage: Guaranteed profit\n"))
 	sb.WriteString(descStyle.Render("• Kelly: Growth optimization\n"))
-	sb.WriteString(descStyle.Render("• Proportional: Simple inverse allocation\n\n"))
+	sb.WriteString(descStyle.Render("• Kelly-Simultaneous: Joint growth optimization\n"))
+	sb.WriteString(descStyle.Render("• Matched: Back/lay free bet conversion\n\n"))
 
 	sb.WriteString(helpDescStyle.Render("Press ? or Esc to close"))
 
@@ -8,28 +8,54 @@ import (
 	"github.com/codehakase/kelly/internal/calculator"
 	"github.com/codehakase/kelly/internal/parser"
 	"github.com/codehakase/kelly/internal/ui/components"
+	"github.com/codehakase/kelly/pkg/money"
 	"github.com/codehakase/kelly/pkg/types"
 )
 
 const (
-	fieldOddsA = iota
-	fieldOddsB
-	fieldTotal
-	fieldNameA
-	fieldNameB
-	fieldProbA
-	fieldProbB
-	fieldCount
+	minOptions = 2
+	maxOptions = 6
 )
 
+// optionFields groups the inputs describing a single option.
+type optionFields struct {
+	odds, name, prob components.ValidatedInput
+}
+
+func newOptionFields(i int) optionFields {
+	letter := string(rune('A' + i))
+	oddsPlaceholder, probPlaceholder := "4.20 or 24% or 16/5", "0.25 (for Kelly)"
+	switch i {
+	case 0:
+		oddsPlaceholder, probPlaceholder = "2.56 or 39% or 3/2", "0.55 (for Kelly)"
+	case 1:
+		oddsPlaceholder, probPlaceholder = "3.85 or 26% or 5/2", "0.40 (for Kelly)"
+	}
+
+	f := optionFields{
+		odds: components.NewValidatedInput("Odds "+letter, oddsPlaceholder, validateOdds),
+		name: components.NewValidatedInput("Name "+letter, types.OptionLabel(i), nil),
+		prob: components.NewValidatedInput("Prob "+letter, probPlaceholder, validateProbability),
+	}
+	f.name.SetValue(types.OptionLabel(i))
+	return f
+}
+
 type Model struct {
-	oddsAInput, oddsBInput, totalInput components.ValidatedInput
-	nameAInput, nameBInput             components.ValidatedInput
-	probAInput, probBInput             components.ValidatedInput
+	options    []optionFields
+	totalInput components.ValidatedInput
+
+	kellyFractionInput, maxStakeInput, minEdgeInput components.ValidatedInput
+	commissionInput, betTypeInput                   components.ValidatedInput
+	roundToInput, hedgeTargetInput                  components.ValidatedInput
 
 	activeField int
 	method      types.CalculationMethod
 	currency    string
+	oddsFormat  types.OddsFormat
+	bankroll    *types.Bankroll
+	pending     money.Amount
+	defaults    Defaults
 	result      *types.CalculationResult
 	err         error
 
@@ -42,23 +68,106 @@ type Model struct {
 func NewModel() Model {
 	m := Model{method: types.MethodArbitrage, currency: "₦"}
 
-	m.oddsAInput = components.NewValidatedInput("Odds A", "2.56 or 39% or 3/2", validateOdds)
-	m.oddsBInput = components.NewValidatedInput("Odds B", "3.85 or 26% or 5/2", validateOdds)
+	for i := 0; i < minOptions; i++ {
+		m.options = append(m.options, newOptionFields(i))
+	}
 	m.totalInput = components.NewValidatedInput("Total", "10000", validateTotal)
-	m.nameAInput = components.NewValidatedInput("Name A", "Option A", nil)
-	m.nameBInput = components.NewValidatedInput("Name B", "Option B", nil)
-	m.probAInput = components.NewValidatedInput("Prob A", "0.55 (for Kelly)", validateProbability)
-	m.probBInput = components.NewValidatedInput("Prob B", "0.40 (for Kelly)", validateProbability)
-
-	m.nameAInput.SetValue("Option A")
-	m.nameBInput.SetValue("Option B")
-	m.oddsAInput.Focus()
+	m.kellyFractionInput = components.NewValidatedInput("Kelly Frac", "0.25 (default 1)", validateKellyFraction)
+	m.maxStakeInput = components.NewValidatedInput("Max Stake %", "5 (optional)", validateMaxStakePct)
+	m.minEdgeInput = components.NewValidatedInput("Min Edge", "0.02 (optional)", validateMinEdge)
+	m.commissionInput = components.NewValidatedInput("Commission", "0.02 (lay)", validateCommission)
+	m.betTypeInput = components.NewValidatedInput("Bet Type", "qualifying, free-snr, free-sr", validateBetType)
+	m.roundToInput = components.NewValidatedInput("Round To", "5 (optional)", validateRoundTo)
+	m.hedgeTargetInput = components.NewValidatedInput("Hedge Target", "equal, free-bet, break-even", validateHedgeTarget)
+	m.options[0].odds.Focus()
 
 	return m
 }
 
 func (m Model) Init() tea.Cmd { return nil }
 
+// WithBankroll shows the bankroll in the title bar and uses its balance,
+// less pending, the stakes of its unsettled bets, as the total for Kelly
+// methods when Total is left empty.
+func (m Model) WithBankroll(b types.Bankroll, pending money.Amount) Model {
+	m.bankroll = &b
+	m.pending = pending
+	if b.Currency != "" {
+		m.currency = b.Currency
+	}
+	return m
+}
+
+// Defaults are the values the form starts with and returns to when it is
+// reset, as they would be typed. Empty fields show their placeholders.
+type Defaults struct {
+	Method   types.CalculationMethod
+	Currency string
+
+	Total, KellyFraction, MaxStakePct, MinEdge string
+	Commission, BetType, RoundTo, HedgeTarget  string
+}
+
+// WithDefaults fills the form from d. A method the TUI cannot cycle to,
+// such as each-way, is ignored.
+func (m Model) WithDefaults(d Defaults) Model {
+	m.defaults = d
+	switch d.Method {
+	case types.MethodArbitrage, types.MethodKelly, types.MethodKellySimultaneous,
+		types.MethodMatched, types.MethodHedge:
+		m.method = d.Method
+	}
+	if d.Currency != "" {
+		m.currency = d.Currency
+	}
+	m.fillDefaults()
+	return m
+}
+
+func (m *Model) fillDefaults() {
+	for _, field := range []struct {
+		input *components.ValidatedInput
+		value string
+	}{
+		{&m.totalInput, m.defaults.Total},
+		{&m.kellyFractionInput, m.defaults.KellyFraction},
+		{&m.maxStakeInput, m.defaults.MaxStakePct},
+		{&m.minEdgeInput, m.defaults.MinEdge},
+		{&m.commissionInput, m.defaults.Commission},
+		{&m.betTypeInput, m.defaults.BetType},
+		{&m.roundToInput, m.defaults.RoundTo},
+		{&m.hedgeTargetInput, m.defaults.HedgeTarget},
+	} {
+		if field.value != "" {
+			field.input.SetValue(field.value)
+		}
+	}
+}
+
+// WithOddsFormat shows the odds of the result in the given format instead
+// of decimal.
+func (m Model) WithOddsFormat(format types.OddsFormat) Model {
+	m.oddsFormat = format
+	return m
+}
+
+// total returns the amount to allocate: the Total input, or the bankroll
+// balance for Kelly methods when Total is empty.
+func (m *Model) total() (money.Amount, bool) {
+	if m.totalInput.Value() == "" && m.bankroll != nil && m.method.RequiresProbabilities() {
+		available := m.bankroll.Balance() - m.pending
+		return available, available > 0
+	}
+	if !m.totalInput.IsValid() {
+		return 0, false
+	}
+	var total float64
+	if _, err := fmt.Sscanf(m.totalInput.Value(), "%f", &total); err != nil {
+		return 0, false
+	}
+	return money.FromFloat(total), true
+}
+
 func validateOdds(input string) error {
 	odds, err := parser.ParseOdds(input)
 	if err != nil {
@@ -92,63 +201,174 @@ func validateProbability(input string) error {
 	return nil
 }
 
-func (m *Model) getInputField(idx int) *components.ValidatedInput {
-	switch idx {
-	case fieldOddsA:
-		return &m.oddsAInput
-	case fieldOddsB:
-		return &m.oddsBInput
-	case fieldTotal:
-		return &m.totalInput
-	case fieldNameA:
-		return &m.nameAInput
-	case fieldNameB:
-		return &m.nameBInput
-	case fieldProbA:
-		return &m.probAInput
-	case fieldProbB:
-		return &m.probBInput
+func validateKellyFraction(input string) error {
+	var fraction float64
+	if _, err := fmt.Sscanf(input, "%f", &fraction); err != nil {
+		return fmt.Errorf("invalid number")
+	}
+	if fraction <= 0 || fraction > 1 {
+		return fmt.Errorf("must be between 0 and 1")
+	}
+	return nil
+}
+
+func validateMaxStakePct(input string) error {
+	var pct float64
+	if _, err := fmt.Sscanf(input, "%f", &pct); err != nil {
+		return fmt.Errorf("invalid number")
+	}
+	if pct <= 0 || pct > 100 {
+		return fmt.Errorf("must be between 0 and 100")
+	}
+	return nil
+}
+
+func validateMinEdge(input string) error {
+	var edge float64
+	if _, err := fmt.Sscanf(input, "%f", &edge); err != nil {
+		return fmt.Errorf("invalid number")
+	}
+	if edge < 0 {
+		return fmt.Errorf("must not be negative")
+	}
+	return nil
+}
+
+func validateCommission(input string) error {
+	var rate float64
+	if _, err := fmt.Sscanf(input, "%f", &rate); err != nil {
+		return fmt.Errorf("invalid number")
+	}
+	if rate < 0 || rate >= 1 {
+		return fmt.Errorf("must be between 0 and 1")
+	}
+	return nil
+}
+
+func validateRoundTo(input string) error {
+	var step float64
+	if _, err := fmt.Sscanf(input, "%f", &step); err != nil {
+		return fmt.Errorf("invalid number")
+	}
+	if step <= 0 {
+		return fmt.Errorf("must be positive")
+	}
+	return nil
+}
+
+func validateHedgeTarget(input string) error {
+	switch types.HedgeTarget(input) {
+	case types.HedgeEqual, types.HedgeFreeBet, types.HedgeBreakEven:
+		return nil
 	default:
-		return &m.oddsAInput
+		return fmt.Errorf("must be equal, free-bet or break-even")
 	}
 }
 
-func (m *Model) focusField(idx int) tea.Cmd {
-	m.oddsAInput.Blur()
-	m.oddsBInput.Blur()
+func validateBetType(input string) error {
+	switch types.BetType(input) {
+	case types.BetQualifying, types.BetFreeSNR, types.BetFreeSR:
+		return nil
+	default:
+		return fmt.Errorf("must be qualifying, free-snr or free-sr")
+	}
+}
+
+// fields returns the inputs shown for the current method in tab order:
+// every odds input, total, every name, then any method-specific inputs.
+func (m *Model) fields() []*components.ValidatedInput {
+	var fields []*components.ValidatedInput
+	for i := range m.options {
+		fields = append(fields, &m.options[i].odds)
+	}
+	fields = append(fields, &m.totalInput)
+	for i := range m.options {
+		fields = append(fields, &m.options[i].name)
+	}
+
+	switch {
+	case m.method.RequiresProbabilities():
+		for i := range m.options {
+			fields = append(fields, &m.options[i].prob)
+		}
+		fields = append(fields, &m.kellyFractionInput, &m.maxStakeInput, &m.minEdgeInput)
+	case m.method == types.MethodMatched:
+		fields = append(fields, &m.commissionInput, &m.betTypeInput)
+	case m.method == types.MethodHedge:
+		fields = append(fields, &m.hedgeTargetInput)
+	}
+	if m.roundsStakes() {
+		fields = append(fields, &m.roundToInput)
+	}
+	return fields
+}
+
+func (m *Model) getInputField(idx int) *components.ValidatedInput {
+	fields := m.fields()
+	if idx < 0 || idx >= len(fields) {
+		return fields[0]
+	}
+	return fields[idx]
+}
+
+func (m *Model) blurAll() {
+	for i := range m.options {
+		m.options[i].odds.Blur()
+		m.options[i].name.Blur()
+		m.options[i].prob.Blur()
+	}
 	m.totalInput.Blur()
-	m.nameAInput.Blur()
-	m.nameBInput.Blur()
-	m.probAInput.Blur()
-	m.probBInput.Blur()
+	m.kellyFractionInput.Blur()
+	m.maxStakeInput.Blur()
+	m.minEdgeInput.Blur()
+	m.commissionInput.Blur()
+	m.betTypeInput.Blur()
+	m.roundToInput.Blur()
+	m.hedgeTargetInput.Blur()
+}
+
+// roundsStakes reports whether the current method can round its stakes;
+// matched and hedge stakes follow from a fixed bet.
+func (m *Model) roundsStakes() bool {
+	return m.method != types.MethodMatched && m.method != types.MethodHedge
+}
+
+func (m *Model) focusField(idx int) tea.Cmd {
+	m.blurAll()
+	if idx < 0 || idx >= len(m.fields()) {
+		idx = 0
+	}
 	m.activeField = idx
 	return m.getInputField(idx).Focus()
 }
 
 func (m *Model) nextField() tea.Cmd {
-	next := m.activeField + 1
-	if m.method != types.MethodKelly && (next == fieldProbA || next == fieldProbB) {
-		next = fieldOddsA
-	}
-	if next >= fieldCount {
-		next = fieldOddsA
-	}
-	return m.focusField(next)
+	return m.focusField((m.activeField + 1) % len(m.fields()))
 }
 
 func (m *Model) prevField() tea.Cmd {
-	prev := m.activeField - 1
-	if prev < 0 {
-		if m.method == types.MethodKelly {
-			prev = fieldProbB
-		} else {
-			prev = fieldNameB
-		}
+	count := len(m.fields())
+	return m.focusField((m.activeField - 1 + count) % count)
+}
+
+// addOption appends an empty option and focuses its odds input.
+func (m *Model) addOption() tea.Cmd {
+	if len(m.options) >= maxOptions {
+		return nil
 	}
-	if m.method != types.MethodKelly && (prev == fieldProbA || prev == fieldProbB) {
-		prev = fieldNameB
+	m.options = append(m.options, newOptionFields(len(m.options)))
+	m.calculate()
+	return m.focusField(len(m.options) - 1)
+}
+
+// removeOption drops the last option, keeping at least two.
+func (m *Model) removeOption() tea.Cmd {
+	if len(m.options) <= minOptions {
+		return nil
 	}
-	return m.focusField(prev)
+	m.options = m.options[:len(m.options)-1]
+	m.calculate()
+	return m.focusField(0)
 }
 
 func (m *Model) cycleMethod() {
@@ -156,10 +376,15 @@ func (m *Model) cycleMethod() {
 	case types.MethodArbitrage:
 		m.method = types.MethodKelly
 	case types.MethodKelly:
-		m.method = types.MethodProportional
-	case types.MethodProportional:
+		m.method = types.MethodKellySimultaneous
+	case types.MethodKellySimultaneous:
+		m.method = types.MethodMatched
+	case types.MethodMatched:
+		m.method = types.MethodHedge
+	case types.MethodHedge:
 		m.method = types.MethodArbitrage
 	}
+	m.focusField(m.activeField)
 	m.calculate()
 }
 
@@ -167,54 +392,71 @@ func (m *Model) calculate() {
 	m.result = nil
 	m.err = nil
 
-	if !m.oddsAInput.IsValid() || !m.oddsBInput.IsValid() || !m.totalInput.IsValid() {
+	total, ok := m.total()
+	if !ok {
 		return
 	}
-
-	oddsA, err := parser.ParseOdds(m.oddsAInput.Value())
-	if err != nil {
-		m.err = err
-		return
-	}
-
-	oddsB, err := parser.ParseOdds(m.oddsBInput.Value())
-	if err != nil {
-		m.err = err
-		return
-	}
-
-	var total float64
-	if _, err = fmt.Sscanf(m.totalInput.Value(), "%f", &total); err != nil {
-		m.err = fmt.Errorf("invalid total: %w", err)
-		return
+	for _, f := range m.options {
+		if !f.odds.IsValid() {
+			return
+		}
 	}
 
-	var probA, probB float64
-	if m.method == types.MethodKelly {
-		if m.probAInput.Value() != "" {
-			fmt.Sscanf(m.probAInput.Value(), "%f", &probA)
+	options := make([]types.Option, len(m.options))
+	for i, f := range m.options {
+		odds, err := parser.ParseOdds(f.odds.Value())
+		if err != nil {
+			m.err = err
+			return
 		}
-		if m.probBInput.Value() != "" {
-			fmt.Sscanf(m.probBInput.Value(), "%f", &probB)
+
+		var prob float64
+		if m.method.RequiresProbabilities() {
+			if f.prob.Value() != "" {
+				fmt.Sscanf(f.prob.Value(), "%f", &prob)
+			}
+			if prob == 0 {
+				m.err = fmt.Errorf("Kelly method requires probability estimates")
+				return
+			}
 		}
-		if probA == 0 || probB == 0 {
-			m.err = fmt.Errorf("Kelly method requires probability estimates")
-			return
+
+		name := f.name.Value()
+		if name == "" {
+			name = types.OptionLabel(i)
 		}
+		options[i] = types.Option{Name: name, Odds: odds, Probability: prob}
 	}
 
-	nameA := m.nameAInput.Value()
-	if nameA == "" {
-		nameA = "Option A"
+	input := &types.CalculationInput{
+		Method: m.method, Options: options, TotalStake: total, Currency: m.currency,
 	}
-	nameB := m.nameBInput.Value()
-	if nameB == "" {
-		nameB = "Option B"
+	if m.roundsStakes() {
+		input.RoundTo = optionalFloat(m.roundToInput)
 	}
-
-	input := &types.CalculationInput{
-		Method: m.method, OddsA: oddsA, OddsB: oddsB, TotalStake: total,
-		ProbA: probA, ProbB: probB, NameA: nameA, NameB: nameB, Currency: m.currency,
+	switch {
+	case m.method.RequiresProbabilities():
+		input.KellyFraction = optionalFloat(m.kellyFractionInput)
+		input.MaxStakePct = optionalFloat(m.maxStakeInput)
+		input.MinEdge = optionalFloat(m.minEdgeInput)
+	case m.method == types.MethodMatched:
+		if len(options) != 2 {
+			m.err = fmt.Errorf("matched method requires exactly 2 options: back and lay")
+			return
+		}
+		input.Options[1].Side = types.SideLay
+		input.Options[1].Commission = optionalFloat(m.commissionInput)
+		if m.betTypeInput.IsValid() {
+			input.BetType = types.BetType(m.betTypeInput.Value())
+		}
+	case m.method == types.MethodHedge:
+		if len(options) != 2 {
+			m.err = fmt.Errorf("hedge method requires exactly 2 options: the position and the hedge")
+			return
+		}
+		if m.hedgeTargetInput.IsValid() {
+			input.HedgeTarget = types.HedgeTarget(m.hedgeTargetInput.Value())
+		}
 	}
 
 	calc := calculator.NewCalculator(m.method)
@@ -226,15 +468,32 @@ func (m *Model) calculate() {
 	m.result = result
 }
 
+// optionalFloat returns the value of an optional numeric input, or zero when
+// it is empty or invalid.
+func optionalFloat(vi components.ValidatedInput) float64 {
+	if !vi.IsValid() {
+		return 0
+	}
+	var v float64
+	fmt.Sscanf(vi.Value(), "%f", &v)
+	return v
+}
+
 func (m *Model) reset() {
-	m.oddsAInput.Reset()
-	m.oddsBInput.Reset()
+	m.options = nil
+	for i := 0; i < minOptions; i++ {
+		m.options = append(m.options, newOptionFields(i))
+	}
 	m.totalInput.Reset()
-	m.nameAInput.SetValue("Option A")
-	m.nameBInput.SetValue("Option B")
-	m.probAInput.Reset()
-	m.probBInput.Reset()
+	m.kellyFractionInput.Reset()
+	m.maxStakeInput.Reset()
+	m.minEdgeInput.Reset()
+	m.commissionInput.Reset()
+	m.betTypeInput.Reset()
+	m.roundToInput.Reset()
+	m.hedgeTargetInput.Reset()
+	m.fillDefaults()
 	m.result = nil
 	m.err = nil
-	m.focusField(fieldOddsA)
+	m.focusField(0)
 }
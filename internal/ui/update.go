@@ -39,6 +39,10 @@ func (m Model) handleKeypress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	case "enter":
 		m.calculate()
 		return m, nil
+	case "ctrl+n":
+		return m, m.addOption()
+	case "ctrl+x":
+		return m, m.removeOption()
 	case "m":
 		if !m.isTypingLetter() {
 			m.cycleMethod()
@@ -6,6 +6,7 @@ import (
 
 	"github.com/charmbracelet/lipgloss"
 
+	"github.com/codehakase/kelly/internal/formatter"
 	"github.com/codehakase/kelly/internal/ui/components"
 	"github.com/codehakase/kelly/pkg/types"
 )
@@ -35,6 +36,13 @@ func (m Model) View() string {
 func (m Model) renderTitle() string {
 	title := "KELLY • Stake Calculator"
 	method := fmt.Sprintf("Method: %s", strings.ToUpper(string(m.method)))
+	if m.bankroll != nil {
+		balance := fmt.Sprintf("%s%.2f", m.bankroll.Currency, m.bankroll.Balance().Float64())
+		if m.pending > 0 {
+			balance += fmt.Sprintf(" (%s%.2f pending)", m.bankroll.Currency, m.pending.Float64())
+		}
+		method = fmt.Sprintf("Bankroll: %s %s │ %s", m.bankroll.Name, balance, method)
+	}
 
 	titleStyle := lipgloss.NewStyle().Foreground(ColorPrimaryText).Bold(true)
 	width := m.width
@@ -59,20 +67,31 @@ func (m Model) renderInputPanel() string {
 	sb.WriteString(lipgloss.NewStyle().Foreground(ColorAccentFocus).Bold(true).Render("INPUT PARAMETERS"))
 	sb.WriteString("\n\n")
 
-	colWidth := 35
-	leftCol := lipgloss.NewStyle().Width(colWidth)
-	rightCol := lipgloss.NewStyle().Width(colWidth)
-
-	leftContent := m.oddsAInput.View() + "\n" + m.nameAInput.View()
-	rightContent := m.oddsBInput.View() + "\n" + m.nameBInput.View()
-	if m.method == types.MethodKelly {
-		leftContent += "\n" + m.probAInput.View()
-		rightContent += "\n" + m.probBInput.View()
+	columns := make([]string, len(m.options))
+	for i, f := range m.options {
+		columns[i] = f.odds.View() + "\n" + f.name.View()
+		if m.method.RequiresProbabilities() {
+			columns[i] += "\n" + f.prob.View()
+		}
 	}
 
-	sb.WriteString(lipgloss.JoinHorizontal(lipgloss.Top, leftCol.Render(leftContent), "  ", rightCol.Render(rightContent)))
+	sb.WriteString(renderColumns(columns))
 	sb.WriteString("\n\n")
 	sb.WriteString(m.totalInput.View())
+	switch {
+	case m.method.RequiresProbabilities():
+		sb.WriteString("\n" + m.kellyFractionInput.View())
+		sb.WriteString("\n" + m.maxStakeInput.View())
+		sb.WriteString("\n" + m.minEdgeInput.View())
+	case m.method == types.MethodMatched:
+		sb.WriteString("\n" + m.commissionInput.View())
+		sb.WriteString("\n" + m.betTypeInput.View())
+	case m.method == types.MethodHedge:
+		sb.WriteString("\n" + m.hedgeTargetInput.View())
+	}
+	if m.roundsStakes() {
+		sb.WriteString("\n" + m.roundToInput.View())
+	}
 
 	return lipgloss.NewStyle().
 		Border(lipgloss.RoundedBorder()).BorderForeground(ColorBorder).Padding(1, 2).
@@ -88,19 +107,33 @@ func (m Model) renderAllocationBreakdown() string {
 	sb.WriteString(lipgloss.NewStyle().Foreground(ColorAccentFocus).Bold(true).Render("ALLOCATION BREAKDOWN"))
 	sb.WriteString("\n\n")
 
-	colWidth := 35
-	leftCol := lipgloss.NewStyle().Width(colWidth)
-	rightCol := lipgloss.NewStyle().Width(colWidth)
-
-	sb.WriteString(lipgloss.JoinHorizontal(lipgloss.Top,
-		leftCol.Render(m.renderOptionDetails(m.result.OptionA, "OPTION A")), "  ",
-		rightCol.Render(m.renderOptionDetails(m.result.OptionB, "OPTION B"))))
+	columns := make([]string, len(m.result.Options))
+	for i, opt := range m.result.Options {
+		columns[i] = m.renderOptionDetails(opt, strings.ToUpper(types.OptionLabel(i)))
+	}
+	sb.WriteString(renderColumns(columns))
 
 	return lipgloss.NewStyle().
 		Border(lipgloss.RoundedBorder()).BorderForeground(ColorBorder).Padding(1, 2).
 		Render(sb.String())
 }
 
+// renderColumns lays option columns out side by side, two per row.
+func renderColumns(columns []string) string {
+	colStyle := lipgloss.NewStyle().Width(35)
+
+	var rows []string
+	for i := 0; i < len(columns); i += 2 {
+		if i+1 < len(columns) {
+			rows = append(rows, lipgloss.JoinHorizontal(lipgloss.Top,
+				colStyle.Render(columns[i]), "  ", colStyle.Render(columns[i+1])))
+		} else {
+			rows = append(rows, colStyle.Render(columns[i]))
+		}
+	}
+	return strings.Join(rows, "\n\n")
+}
+
 func (m Model) renderOptionDetails(opt types.Option, header string) string {
 	var sb strings.Builder
 
@@ -114,15 +147,21 @@ func (m Model) renderOptionDetails(opt types.Option, header string) string {
 	}
 	sb.WriteString("\n\n")
 
-	sb.WriteString(labelStyle.Render("Odds") + valueStyle.Render(fmt.Sprintf("%.2f ", opt.Odds)) +
+	sb.WriteString(labelStyle.Render("Odds") + valueStyle.Render(formatter.FormatOdds(opt.Odds, m.oddsFormat)+" ") +
 		lipgloss.NewStyle().Foreground(ColorMuted).Render(fmt.Sprintf("(%.2f%%)", opt.ImpliedProbability*100)) + "\n")
 
-	sb.WriteString(labelStyle.Render("Stake") + valueStyle.Render(fmt.Sprintf("%s%.0f ", m.result.Currency, opt.Stake)) +
-		lipgloss.NewStyle().Foreground(ColorMuted).Render(fmt.Sprintf("(%.2f%%)", (opt.Stake/m.result.TotalStake)*100)) + "\n")
+	sb.WriteString(labelStyle.Render("Stake") + valueStyle.Render(fmt.Sprintf("%s%.0f ", m.result.Currency, opt.Stake.Float64())) +
+		lipgloss.NewStyle().Foreground(ColorMuted).Render(fmt.Sprintf("(%.2f%%)", (opt.Stake.Float64()/m.result.TotalStake.Float64())*100)) + "\n")
 
-	sb.WriteString(labelStyle.Render("Return") + valueStyle.Render(fmt.Sprintf("%s%.0f", m.result.Currency, opt.ReturnIfWins)) + "\n")
-	sb.WriteString(labelStyle.Render("Profit") + StyleProfit.Render(fmt.Sprintf("+%s%.0f", m.result.Currency, opt.ProfitIfWins)) + "\n")
+	if opt.Side == types.SideLay {
+		sb.WriteString(labelStyle.Render("Liability") + valueStyle.Render(fmt.Sprintf("%s%.0f", m.result.Currency, opt.Liability.Float64())) + "\n")
+	}
+	sb.WriteString(labelStyle.Render("Return") + valueStyle.Render(fmt.Sprintf("%s%.0f", m.result.Currency, opt.ReturnIfWins.Float64())) + "\n")
+	sb.WriteString(labelStyle.Render("Profit") + StyleProfit.Render(fmt.Sprintf("+%s%.0f", m.result.Currency, opt.ProfitIfWins.Float64())) + "\n")
 	sb.WriteString(labelStyle.Render("ROI") + StyleProfit.Render(fmt.Sprintf("+%.2f%%", opt.ROI*100)))
+	if opt.BindingConstraint != "" {
+		sb.WriteString("\n" + labelStyle.Render("Limited by") + StyleHighlight.Render(string(opt.BindingConstraint)))
+	}
 
 	return sb.String()
 }
@@ -139,7 +178,7 @@ func (m Model) renderSummary() string {
 	sb.WriteString(lipgloss.NewStyle().Foreground(ColorAccentFocus).Bold(true).Render("SUMMARY"))
 	sb.WriteString("\n\n")
 
-	sb.WriteString(labelStyle.Render("Total Invested") + valueStyle.Render(fmt.Sprintf("%s%.0f", m.result.Currency, m.result.TotalStake)) + "\n")
+	sb.WriteString(labelStyle.Render("Total Invested") + valueStyle.Render(fmt.Sprintf("%s%.0f", m.result.Currency, m.result.TotalStake.Float64())) + "\n")
 
 	sb.WriteString(labelStyle.Render("Guaranteed Profit"))
 	if m.result.Summary.GuaranteedProfit {
@@ -150,13 +189,20 @@ func (m Model) renderSummary() string {
 	sb.WriteString("\n")
 
 	sb.WriteString(labelStyle.Render("Profit Range") + StyleProfit.Render(fmt.Sprintf("%s%.0f - %s%.0f",
-		m.result.Currency, m.result.Summary.MinProfit, m.result.Currency, m.result.Summary.MaxProfit)) + "\n")
+		m.result.Currency, m.result.Summary.MinProfit.Float64(), m.result.Currency, m.result.Summary.MaxProfit.Float64())) + "\n")
 
 	sb.WriteString(labelStyle.Render("ROI Range") + StyleProfit.Render(fmt.Sprintf("%.2f%% - %.2f%%",
 		m.result.Summary.MinROI*100, m.result.Summary.MaxROI*100)) + "\n")
 
 	sb.WriteString(labelStyle.Render("Expected Value") + valueStyle.Render(fmt.Sprintf("%s%.0f (%.2f%%)",
-		m.result.Currency, m.result.Summary.ExpectedValue, (m.result.Summary.ExpectedValue/m.result.TotalStake)*100)) + "\n")
+		m.result.Currency, m.result.Summary.ExpectedValue.Float64(), (m.result.Summary.ExpectedValue.Float64()/m.result.TotalStake.Float64())*100)) + "\n")
+
+	if m.result.Method == types.MethodKellySimultaneous {
+		sb.WriteString(labelStyle.Render("Cash Reserve") + valueStyle.Render(fmt.Sprintf("%s%.0f",
+			m.result.Currency, m.result.Summary.CashReserve.Float64())) + "\n")
+		sb.WriteString(labelStyle.Render("Log Growth Rate") + valueStyle.Render(fmt.Sprintf("%.6f",
+			m.result.Summary.GrowthRate)) + "\n")
+	}
 
 	effPct := m.result.Summary.MarketEfficiency * 100
 	effStyle := valueStyle
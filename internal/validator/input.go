@@ -5,6 +5,10 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/codehakase/kelly/internal/calculator"
+	"github.com/codehakase/kelly/internal/fx"
+	"github.com/codehakase/kelly/internal/margin"
+	"github.com/codehakase/kelly/pkg/money"
 	"github.com/codehakase/kelly/pkg/types"
 )
 
@@ -42,9 +46,73 @@ func ValidateProbability(prob float64) error {
 	return nil
 }
 
-func ValidateTotalStake(total float64) error {
+func ValidateTotalStake(total money.Amount) error {
 	if total <= 0 {
-		return fmt.Errorf("total stake must be positive, got: %.2f", total)
+		return fmt.Errorf("total stake must be positive, got: %s", total)
+	}
+	return nil
+}
+
+func ValidateKellyFraction(fraction float64) error {
+	if fraction < 0 || fraction > 1 {
+		return fmt.Errorf("kelly fraction must be between 0 and 1, got: %.4f", fraction)
+	}
+	return nil
+}
+
+func ValidateMaxStakePct(pct float64) error {
+	if pct < 0 || pct > 100 {
+		return fmt.Errorf("max stake percentage must be between 0 and 100, got: %.2f", pct)
+	}
+	return nil
+}
+
+func ValidateMinEdge(edge float64) error {
+	if edge < 0 {
+		return fmt.Errorf("minimum edge must be non-negative, got: %.4f", edge)
+	}
+	return nil
+}
+
+func ValidateCommission(rate float64) error {
+	if rate < 0 || rate >= 1 {
+		return fmt.Errorf("commission must be between 0 and 1, got: %.4f", rate)
+	}
+	return nil
+}
+
+// ValidateTaxes checks a book's winnings tax and stake excise rates.
+func ValidateTaxes(tax, excise float64) error {
+	if tax < 0 || tax >= 1 {
+		return fmt.Errorf("winnings tax must be between 0 and 1, got: %.4f", tax)
+	}
+	if excise < 0 || excise >= 1 {
+		return fmt.Errorf("stake excise must be between 0 and 1, got: %.4f", excise)
+	}
+	return nil
+}
+
+func ValidateStakeLimits(minStake, maxStake money.Amount) error {
+	if minStake < 0 || maxStake < 0 {
+		return fmt.Errorf("stake limits must be non-negative, got: min %s, max %s", minStake, maxStake)
+	}
+	if maxStake > 0 && minStake > maxStake {
+		return fmt.Errorf("minimum stake %s exceeds maximum stake %s", minStake, maxStake)
+	}
+	return nil
+}
+
+func ValidateRounding(roundTo float64, mode types.RoundMode) error {
+	if roundTo < 0 {
+		return fmt.Errorf("round-to must be non-negative, got: %.2f", roundTo)
+	}
+	switch mode {
+	case "", types.RoundNearest, types.RoundDown, types.RoundStealth:
+	default:
+		return fmt.Errorf("invalid round mode '%s' (must be nearest, down or stealth)", mode)
+	}
+	if roundTo == 0 && (mode == types.RoundNearest || mode == types.RoundDown) {
+		return fmt.Errorf("round mode '%s' requires --round-to", mode)
 	}
 	return nil
 }
@@ -52,47 +120,229 @@ func ValidateTotalStake(total float64) error {
 func ValidateCalculationInput(input *types.CalculationInput) error {
 	var errs []error
 
-	if err := ValidateOdds(input.OddsA); err != nil {
-		errs = append(errs, fmt.Errorf("Option A: %w", err))
+	options := input.Outcomes()
+	lays := 0
+	if len(options) < 2 && input.Method != types.MethodEachWay {
+		errs = append(errs, fmt.Errorf("at least 2 options are required, got: %d", len(options)))
 	}
-	if err := ValidateOdds(input.OddsB); err != nil {
-		errs = append(errs, fmt.Errorf("Option B: %w", err))
+	for i, opt := range options {
+		if err := ValidateOdds(opt.Odds); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", types.OptionLabel(i), err))
+		}
+		if err := ValidateCommission(opt.Commission); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", types.OptionLabel(i), err))
+		}
+		if err := ValidateStakeLimits(opt.MinStake, opt.MaxStake); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", types.OptionLabel(i), err))
+		}
+		if err := ValidateTaxes(opt.TaxOnWinnings, opt.StakeExcise); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", types.OptionLabel(i), err))
+		} else if opt.Side == types.SideLay && opt.Taxed() {
+			errs = append(errs, fmt.Errorf("%s: winnings tax and stake excise apply to bookmaker back bets, not lays", types.OptionLabel(i)))
+		}
+		switch opt.Side {
+		case "", types.SideBack:
+		case types.SideLay:
+			lays++
+		default:
+			errs = append(errs, fmt.Errorf("%s: invalid side '%s' (must be back or lay)", types.OptionLabel(i), opt.Side))
+		}
+		if opt.SharpOdds != 0 {
+			if err := ValidateOdds(opt.SharpOdds); err != nil {
+				errs = append(errs, fmt.Errorf("%s sharp odds: %w", types.OptionLabel(i), err))
+			}
+		}
 	}
 	if err := ValidateTotalStake(input.TotalStake); err != nil {
 		errs = append(errs, err)
 	}
+	if err := ValidateKellyFraction(input.KellyFraction); err != nil {
+		errs = append(errs, err)
+	}
+	if err := ValidateMaxStakePct(input.MaxStakePct); err != nil {
+		errs = append(errs, err)
+	}
+	if err := ValidateMinEdge(input.MinEdge); err != nil {
+		errs = append(errs, err)
+	}
+	if err := ValidateRounding(input.RoundTo, input.RoundMode); err != nil {
+		errs = append(errs, err)
+	}
 
 	switch input.Method {
-	case types.MethodKelly:
-		if input.ProbA == 0 || input.ProbB == 0 {
-			errs = append(errs, errors.New("Kelly method requires probability estimates for both options (use --prob-a and --prob-b)"))
+	case types.MethodKelly, types.MethodKellySimultaneous:
+		var probSum float64
+		missing := false
+		for i, opt := range options {
+			if opt.Probability == 0 {
+				missing = true
+				continue
+			}
+			if err := ValidateProbability(opt.Probability); err != nil {
+				errs = append(errs, fmt.Errorf("%s probability: %w", types.OptionLabel(i), err))
+			}
+			probSum += opt.Probability
 		}
-		if input.ProbA != 0 {
-			if err := ValidateProbability(input.ProbA); err != nil {
-				errs = append(errs, fmt.Errorf("Option A probability: %w", err))
+		if input.FairFrom != "" {
+			if _, err := margin.ParseMethod(string(input.FairFrom)); err != nil {
+				errs = append(errs, err)
 			}
+		} else if missing {
+			errs = append(errs, errors.New("Kelly method requires probability estimates for all options (use --prob-a/--prob-b, --probs or --fair-from)"))
+		}
+		if !missing && probSum > 1.0 {
+			errs = append(errs, fmt.Errorf("warning: probabilities sum to %.4f (> 1.0)", probSum))
+		}
+	case types.MethodMatched:
+		if len(options) != 2 {
+			errs = append(errs, errors.New("matched method requires exactly 2 options: back odds and lay odds"))
+		}
+		switch input.BetType {
+		case "", types.BetQualifying, types.BetFreeSNR, types.BetFreeSR:
+		default:
+			errs = append(errs, fmt.Errorf("invalid bet type '%s' (must be qualifying, free-snr or free-sr)", input.BetType))
+		}
+		if input.Rounding() {
+			errs = append(errs, errors.New("stake rounding does not apply to the matched method; its back stake is the total"))
+		}
+	case types.MethodHedge:
+		if len(options) != 2 {
+			errs = append(errs, errors.New("hedge method requires exactly 2 options: the position held and the hedge bet"))
+		} else if options[0].Side == types.SideLay && options[1].Side == types.SideLay {
+			errs = append(errs, errors.New("a lay position must be hedged with a back bet"))
 		}
-		if input.ProbB != 0 {
-			if err := ValidateProbability(input.ProbB); err != nil {
-				errs = append(errs, fmt.Errorf("Option B probability: %w", err))
+		switch input.HedgeTarget {
+		case "", types.HedgeEqual, types.HedgeFreeBet, types.HedgeBreakEven:
+		default:
+			errs = append(errs, fmt.Errorf("invalid hedge target '%s' (must be equal, free-bet or break-even)", input.HedgeTarget))
+		}
+		if input.Rounding() {
+			errs = append(errs, errors.New("stake rounding does not apply to the hedge method; its total is the position's stake"))
+		}
+	case types.MethodEachWay:
+		if len(options) != 1 && len(options) != 3 {
+			errs = append(errs, errors.New("each-way method requires the selection's odds, optionally followed by exchange win and place lay odds"))
+		}
+		if input.PlaceFraction == 0 {
+			errs = append(errs, errors.New("each-way method requires --place-terms"))
+		} else if input.PlaceFraction < 0 || input.PlaceFraction > 1 {
+			errs = append(errs, fmt.Errorf("each-way place terms must be between 0 and 1, got: %.4f", input.PlaceFraction))
+		}
+		if input.Places < 1 {
+			errs = append(errs, fmt.Errorf("each-way places must be at least 1, got: %d", input.Places))
+		}
+		if input.PlaceProbability != 0 {
+			if err := ValidateProbability(input.PlaceProbability); err != nil {
+				errs = append(errs, fmt.Errorf("place probability: %w", err))
+			} else if len(options) > 0 && input.PlaceProbability < options[0].Probability {
+				errs = append(errs, errors.New("place probability cannot be below the win probability"))
 			}
 		}
-		if input.ProbA > 0 && input.ProbB > 0 {
-			if sum := input.ProbA + input.ProbB; sum > 1.0 {
-				errs = append(errs, fmt.Errorf("warning: probabilities sum to %.4f (> 1.0)", sum))
+		if input.Rounding() {
+			errs = append(errs, errors.New("stake rounding does not apply to the each-way method; its stake is split in two"))
+		}
+	case types.MethodParlay:
+		if len(options) > calculator.MaxParlayLegs {
+			errs = append(errs, fmt.Errorf("parlay method supports at most %d legs, got: %d", calculator.MaxParlayLegs, len(options)))
+		} else if _, _, err := calculator.SystemSizes(input.System, len(options)); err != nil {
+			errs = append(errs, err)
+		}
+		for i, opt := range options {
+			if opt.Probability == 0 {
+				continue
+			}
+			if err := ValidateProbability(opt.Probability); err != nil {
+				errs = append(errs, fmt.Errorf("%s probability: %w", types.OptionLabel(i), err))
 			}
 		}
+		if input.Rounding() {
+			errs = append(errs, errors.New("stake rounding does not apply to the parlay method; every line has the same stake"))
+		}
 	case types.MethodArbitrage, types.MethodProportional:
 		// No probability requirements
 	default:
 		errs = append(errs, fmt.Errorf("invalid calculation method: %s", input.Method))
 	}
 
-	if input.OddsA > 0 && input.OddsB > 0 {
-		marketEff := (1.0 / input.OddsA) + (1.0 / input.OddsB)
-		if input.Method == types.MethodArbitrage && marketEff >= 1.0 {
-			errs = append(errs, fmt.Errorf("warning: combined implied probability (%.2f%%) >= 100%% - no guaranteed profit", marketEff*100))
+	if input.AsianMarket != "" {
+		switch input.AsianMarket {
+		case types.AsianHandicap, types.AsianTotal:
+		default:
+			errs = append(errs, fmt.Errorf("invalid Asian market '%s' (must be handicap or total)", input.AsianMarket))
+		}
+		if input.Method != types.MethodArbitrage && input.Method != types.MethodKelly {
+			errs = append(errs, errors.New("Asian lines are only supported by the arbitrage and kelly methods"))
+		}
+		if len(options) != 2 {
+			errs = append(errs, fmt.Errorf("Asian lines require exactly 2 options, got: %d", len(options)))
 		}
+		for i, opt := range options {
+			if !calculator.ValidLine(opt.Line) {
+				errs = append(errs, fmt.Errorf("%s: line must be a whole, half or quarter goal, got: %g", types.OptionLabel(i), opt.Line))
+			}
+		}
+		if input.Rounding() {
+			errs = append(errs, errors.New("stake rounding does not apply to Asian lines"))
+		}
+	}
+
+	currencies := false
+	for _, opt := range options {
+		currencies = currencies || opt.Currency != ""
+	}
+	if input.Rates != nil || currencies {
+		if input.Rates == nil {
+			errs = append(errs, errors.New("option currencies require an exchange-rate table"))
+		}
+		if input.Method != types.MethodArbitrage {
+			errs = append(errs, errors.New("currencies are only supported by the arbitrage method"))
+		}
+		if lays > 0 {
+			errs = append(errs, errors.New("arbitrage across currencies supports back bets only"))
+		}
+		if input.AsianMarket != "" {
+			errs = append(errs, errors.New("Asian lines do not support currencies"))
+		}
+	}
+
+	laysAllowed := input.Method == types.MethodMatched || input.Method == types.MethodHedge ||
+		input.Method == types.MethodEachWay
+	if lays > 0 && !laysAllowed {
+		if input.Method != types.MethodArbitrage {
+			errs = append(errs, errors.New("lay bets are only supported by the arbitrage method"))
+		} else if len(options) != 2 || lays != 1 {
+			errs = append(errs, errors.New("lay bets require exactly one back and one lay option on the same selection"))
+		}
+	}
+
+	var marketEff float64
+	allPositive := true
+	for _, opt := range options {
+		if opt.Odds <= 0 || (opt.Side == types.SideLay && opt.Odds <= 1) {
+			allPositive = false
+			break
+		}
+		rate := types.FXRate{Buy: 1, Sell: 1}
+		if input.Rates != nil {
+			base := input.BaseCurrency
+			if base == "" {
+				base = input.Rates.Base
+			}
+			currency := opt.Currency
+			if currency == "" {
+				currency = base
+			}
+			var err error
+			if rate, err = fx.Rate(input.Rates, currency, base); err != nil {
+				errs = append(errs, err)
+				allPositive = false
+				break
+			}
+		}
+		marketEff += rate.Buy / (opt.EffectiveOdds() * rate.Sell)
+	}
+	if allPositive && input.Method == types.MethodArbitrage && marketEff >= 1.0 {
+		errs = append(errs, fmt.Errorf("warning: combined implied probability (%.2f%%) >= 100%% - no guaranteed profit", marketEff*100))
 	}
 
 	if len(errs) > 0 {
@@ -5,6 +5,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/codehakase/kelly/pkg/money"
 	"github.com/codehakase/kelly/pkg/types"
 )
 
@@ -88,7 +89,7 @@ func TestValidateTotalStake(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := ValidateTotalStake(tt.total)
+			err := ValidateTotalStake(money.FromFloat(tt.total))
 
 			if tt.wantErr && err == nil {
 				t.Errorf("ValidateTotalStake(%v) expected error, got nil", tt.total)
@@ -114,7 +115,7 @@ func TestValidateCalculationInput(t *testing.T) {
 				Method:     types.MethodArbitrage,
 				OddsA:      2.56,
 				OddsB:      3.85,
-				TotalStake: 10000,
+				TotalStake: money.FromFloat(10000),
 				NameA:      "Option A",
 				NameB:      "Option B",
 				Currency:   "₦",
@@ -127,7 +128,7 @@ func TestValidateCalculationInput(t *testing.T) {
 				Method:     types.MethodKelly,
 				OddsA:      2.1,
 				OddsB:      3.5,
-				TotalStake: 1000,
+				TotalStake: money.FromFloat(1000),
 				ProbA:      0.55,
 				ProbB:      0.40,
 				NameA:      "Team A",
@@ -142,7 +143,7 @@ func TestValidateCalculationInput(t *testing.T) {
 				Method:     types.MethodProportional,
 				OddsA:      2.0,
 				OddsB:      3.0,
-				TotalStake: 500,
+				TotalStake: money.FromFloat(500),
 				NameA:      "Option A",
 				NameB:      "Option B",
 				Currency:   "$",
@@ -155,7 +156,7 @@ func TestValidateCalculationInput(t *testing.T) {
 				Method:     types.MethodArbitrage,
 				OddsA:      0.5,
 				OddsB:      2.0,
-				TotalStake: 1000,
+				TotalStake: money.FromFloat(1000),
 			},
 			wantErr:     true,
 			errContains: "Option A",
@@ -166,7 +167,7 @@ func TestValidateCalculationInput(t *testing.T) {
 				Method:     types.MethodArbitrage,
 				OddsA:      2.0,
 				OddsB:      0.9,
-				TotalStake: 1000,
+				TotalStake: money.FromFloat(1000),
 			},
 			wantErr:     true,
 			errContains: "Option B",
@@ -177,7 +178,7 @@ func TestValidateCalculationInput(t *testing.T) {
 				Method:     types.MethodArbitrage,
 				OddsA:      2.0,
 				OddsB:      2.0,
-				TotalStake: -100,
+				TotalStake: money.FromFloat(-100),
 			},
 			wantErr:     true,
 			errContains: "total stake",
@@ -188,7 +189,7 @@ func TestValidateCalculationInput(t *testing.T) {
 				Method:     types.MethodKelly,
 				OddsA:      2.0,
 				OddsB:      2.0,
-				TotalStake: 1000,
+				TotalStake: money.FromFloat(1000),
 				ProbA:      0,
 				ProbB:      0,
 			},
@@ -201,7 +202,7 @@ func TestValidateCalculationInput(t *testing.T) {
 				Method:     types.MethodKelly,
 				OddsA:      2.0,
 				OddsB:      2.0,
-				TotalStake: 1000,
+				TotalStake: money.FromFloat(1000),
 				ProbA:      1.5,
 				ProbB:      0.4,
 			},
@@ -214,7 +215,7 @@ func TestValidateCalculationInput(t *testing.T) {
 				Method:     types.MethodKelly,
 				OddsA:      2.0,
 				OddsB:      2.0,
-				TotalStake: 1000,
+				TotalStake: money.FromFloat(1000),
 				ProbA:      0.5,
 				ProbB:      0,
 			},
@@ -227,7 +228,7 @@ func TestValidateCalculationInput(t *testing.T) {
 				Method:     types.MethodKelly,
 				OddsA:      2.0,
 				OddsB:      2.0,
-				TotalStake: 1000,
+				TotalStake: money.FromFloat(1000),
 				ProbA:      0.7,
 				ProbB:      0.6,
 			},
@@ -240,7 +241,7 @@ func TestValidateCalculationInput(t *testing.T) {
 				Method:     types.MethodArbitrage,
 				OddsA:      2.0,
 				OddsB:      2.0,
-				TotalStake: 1000,
+				TotalStake: money.FromFloat(1000),
 			},
 			wantErr:     true,
 			errContains: "no guaranteed profit",
@@ -251,18 +252,541 @@ func TestValidateCalculationInput(t *testing.T) {
 				Method:     types.MethodArbitrage,
 				OddsA:      0.5,
 				OddsB:      0.9,
-				TotalStake: -100,
+				TotalStake: money.FromFloat(-100),
 			},
 			wantErr:     true,
 			errContains: "multiple validation errors",
 		},
+		{
+			name: "valid three-way kelly input",
+			input: &types.CalculationInput{
+				Method: types.MethodKelly,
+				Options: []types.Option{
+					{Name: "Home", Odds: 2.9, Probability: 0.4},
+					{Name: "Draw", Odds: 3.6, Probability: 0.3},
+					{Name: "Away", Odds: 4.2, Probability: 0.3},
+				},
+				TotalStake: money.FromFloat(1000),
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid odds C",
+			input: &types.CalculationInput{
+				Method: types.MethodProportional,
+				Options: []types.Option{
+					{Odds: 2.9}, {Odds: 3.6}, {Odds: 1.0},
+				},
+				TotalStake: money.FromFloat(1000),
+			},
+			wantErr:     true,
+			errContains: "Option C",
+		},
+		{
+			name: "kelly with missing probability C",
+			input: &types.CalculationInput{
+				Method: types.MethodKelly,
+				Options: []types.Option{
+					{Odds: 2.9, Probability: 0.4}, {Odds: 3.6, Probability: 0.3}, {Odds: 4.2},
+				},
+				TotalStake: money.FromFloat(1000),
+			},
+			wantErr:     true,
+			errContains: "requires probability",
+		},
+		{
+			name: "single option",
+			input: &types.CalculationInput{
+				Method:     types.MethodProportional,
+				Options:    []types.Option{{Odds: 2.0}},
+				TotalStake: money.FromFloat(1000),
+			},
+			wantErr:     true,
+			errContains: "at least 2 options",
+		},
+		{
+			name: "kelly fraction out of range",
+			input: &types.CalculationInput{
+				Method:        types.MethodKelly,
+				OddsA:         2.1,
+				OddsB:         3.5,
+				ProbA:         0.55,
+				ProbB:         0.40,
+				TotalStake:    money.FromFloat(1000),
+				KellyFraction: 1.5,
+			},
+			wantErr:     true,
+			errContains: "kelly fraction",
+		},
+		{
+			name: "max stake percentage out of range",
+			input: &types.CalculationInput{
+				Method:      types.MethodKelly,
+				OddsA:       2.1,
+				OddsB:       3.5,
+				ProbA:       0.55,
+				ProbB:       0.40,
+				TotalStake:  money.FromFloat(1000),
+				MaxStakePct: 150,
+			},
+			wantErr:     true,
+			errContains: "max stake percentage",
+		},
+		{
+			name: "negative minimum edge",
+			input: &types.CalculationInput{
+				Method:     types.MethodKelly,
+				OddsA:      2.1,
+				OddsB:      3.5,
+				ProbA:      0.55,
+				ProbB:      0.40,
+				TotalStake: money.FromFloat(1000),
+				MinEdge:    -0.1,
+			},
+			wantErr:     true,
+			errContains: "minimum edge",
+		},
+		{
+			name: "kelly with fair probabilities",
+			input: &types.CalculationInput{
+				Method:     types.MethodKelly,
+				OddsA:      2.2,
+				OddsB:      1.9,
+				TotalStake: money.FromFloat(1000),
+				FairFrom:   types.MarginShin,
+			},
+			wantErr: false,
+		},
+		{
+			name: "kelly with unknown margin method",
+			input: &types.CalculationInput{
+				Method:     types.MethodKelly,
+				OddsA:      2.2,
+				OddsB:      1.9,
+				TotalStake: money.FromFloat(1000),
+				FairFrom:   "vig",
+			},
+			wantErr:     true,
+			errContains: "invalid margin method",
+		},
+		{
+			name: "valid back/lay hedge",
+			input: &types.CalculationInput{
+				Method: types.MethodArbitrage,
+				Options: []types.Option{
+					{Odds: 3.2, Side: types.SideBack},
+					{Odds: 3.1, Side: types.SideLay, Commission: 0.02},
+				},
+				TotalStake: money.FromFloat(1000),
+			},
+			wantErr: false,
+		},
+		{
+			name: "lay bet with kelly",
+			input: &types.CalculationInput{
+				Method: types.MethodKelly,
+				Options: []types.Option{
+					{Odds: 3.2, Probability: 0.4},
+					{Odds: 3.1, Probability: 0.4, Side: types.SideLay},
+				},
+				TotalStake: money.FromFloat(1000),
+			},
+			wantErr:     true,
+			errContains: "only supported by the arbitrage method",
+		},
+		{
+			name: "invalid commission",
+			input: &types.CalculationInput{
+				Method: types.MethodArbitrage,
+				Options: []types.Option{
+					{Odds: 3.2},
+					{Odds: 3.1, Side: types.SideLay, Commission: 1.5},
+				},
+				TotalStake: money.FromFloat(1000),
+			},
+			wantErr:     true,
+			errContains: "commission",
+		},
+		{
+			name: "valid winnings tax and stake excise",
+			input: &types.CalculationInput{
+				Method: types.MethodArbitrage,
+				Options: []types.Option{
+					{Odds: 2.6, TaxOnWinnings: 0.2, StakeExcise: 0.125},
+					{Odds: 2.55, TaxOnWinnings: 0.2},
+				},
+				TotalStake: money.FromFloat(10000),
+			},
+			wantErr: false,
+		},
+		{
+			name: "tax leaves no arbitrage",
+			input: &types.CalculationInput{
+				Method: types.MethodArbitrage,
+				Options: []types.Option{
+					{Odds: 2.1, TaxOnWinnings: 0.2},
+					{Odds: 2.1, TaxOnWinnings: 0.2},
+				},
+				TotalStake: money.FromFloat(1000),
+			},
+			wantErr:     true,
+			errContains: "no guaranteed profit",
+		},
+		{
+			name: "invalid stake excise",
+			input: &types.CalculationInput{
+				Method:     types.MethodArbitrage,
+				Options:    []types.Option{{Odds: 3.2, StakeExcise: 1}, {Odds: 3.1}},
+				TotalStake: money.FromFloat(1000),
+			},
+			wantErr:     true,
+			errContains: "stake excise",
+		},
+		{
+			name: "taxed lay",
+			input: &types.CalculationInput{
+				Method: types.MethodArbitrage,
+				Options: []types.Option{
+					{Odds: 3.2},
+					{Odds: 3.1, Side: types.SideLay, TaxOnWinnings: 0.2},
+				},
+				TotalStake: money.FromFloat(1000),
+			},
+			wantErr:     true,
+			errContains: "not lays",
+		},
+		{
+			name: "invalid side",
+			input: &types.CalculationInput{
+				Method: types.MethodArbitrage,
+				Options: []types.Option{
+					{Odds: 3.2},
+					{Odds: 3.1, Side: "short"},
+				},
+				TotalStake: money.FromFloat(1000),
+			},
+			wantErr:     true,
+			errContains: "invalid side",
+		},
+		{
+			name: "valid matched free bet",
+			input: &types.CalculationInput{
+				Method: types.MethodMatched,
+				Options: []types.Option{
+					{Odds: 4.0},
+					{Odds: 4.2, Side: types.SideLay, Commission: 0.02},
+				},
+				TotalStake: money.FromFloat(25),
+				BetType:    types.BetFreeSNR,
+			},
+			wantErr: false,
+		},
+		{
+			name: "matched with invalid bet type",
+			input: &types.CalculationInput{
+				Method:     types.MethodMatched,
+				OddsA:      4.0,
+				OddsB:      4.2,
+				TotalStake: money.FromFloat(25),
+				BetType:    "bonus",
+			},
+			wantErr:     true,
+			errContains: "invalid bet type",
+		},
+		{
+			name: "valid rounding",
+			input: &types.CalculationInput{
+				Method:     types.MethodArbitrage,
+				OddsA:      2.56,
+				OddsB:      3.85,
+				TotalStake: money.FromFloat(10000),
+				RoundTo:    5,
+				RoundMode:  types.RoundDown,
+			},
+			wantErr: false,
+		},
+		{
+			name: "stealth rounding without step",
+			input: &types.CalculationInput{
+				Method:     types.MethodArbitrage,
+				OddsA:      2.56,
+				OddsB:      3.85,
+				TotalStake: money.FromFloat(10000),
+				RoundMode:  types.RoundStealth,
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid round mode",
+			input: &types.CalculationInput{
+				Method:     types.MethodArbitrage,
+				OddsA:      2.56,
+				OddsB:      3.85,
+				TotalStake: money.FromFloat(10000),
+				RoundTo:    5,
+				RoundMode:  "up",
+			},
+			wantErr:     true,
+			errContains: "invalid round mode",
+		},
+		{
+			name: "round mode without step",
+			input: &types.CalculationInput{
+				Method:     types.MethodArbitrage,
+				OddsA:      2.56,
+				OddsB:      3.85,
+				TotalStake: money.FromFloat(10000),
+				RoundMode:  types.RoundDown,
+			},
+			wantErr:     true,
+			errContains: "requires --round-to",
+		},
+		{
+			name: "matched with rounding",
+			input: &types.CalculationInput{
+				Method:     types.MethodMatched,
+				OddsA:      4.0,
+				OddsB:      4.2,
+				TotalStake: money.FromFloat(25),
+				RoundTo:    5,
+			},
+			wantErr:     true,
+			errContains: "does not apply to the matched method",
+		},
+		{
+			name: "valid stake limits",
+			input: &types.CalculationInput{
+				Method: types.MethodArbitrage,
+				Options: []types.Option{
+					{Odds: 2.56, MaxStake: money.FromFloat(500)},
+					{Odds: 3.85, MinStake: money.FromFloat(10), MaxStake: money.FromFloat(2000)},
+				},
+				TotalStake: money.FromFloat(10000),
+			},
+			wantErr: false,
+		},
+		{
+			name: "minimum stake above maximum",
+			input: &types.CalculationInput{
+				Method: types.MethodArbitrage,
+				Options: []types.Option{
+					{Odds: 2.56, MinStake: money.FromFloat(600), MaxStake: money.FromFloat(500)},
+					{Odds: 3.85},
+				},
+				TotalStake: money.FromFloat(10000),
+			},
+			wantErr:     true,
+			errContains: "exceeds maximum stake",
+		},
+		{
+			name: "negative stake limit",
+			input: &types.CalculationInput{
+				Method: types.MethodArbitrage,
+				Options: []types.Option{
+					{Odds: 2.56},
+					{Odds: 3.85, MaxStake: money.FromFloat(-1)},
+				},
+				TotalStake: money.FromFloat(10000),
+			},
+			wantErr:     true,
+			errContains: "must be non-negative",
+		},
+		{
+			name: "valid hedge with a lay",
+			input: &types.CalculationInput{
+				Method: types.MethodHedge,
+				Options: []types.Option{
+					{Odds: 5.0},
+					{Odds: 3.0, Side: types.SideLay, Commission: 0.02},
+				},
+				TotalStake:  money.FromFloat(100),
+				HedgeTarget: types.HedgeFreeBet,
+			},
+			wantErr: false,
+		},
+		{
+			name: "hedge with three options",
+			input: &types.CalculationInput{
+				Method:     types.MethodHedge,
+				Options:    []types.Option{{Odds: 2.0}, {Odds: 3.0}, {Odds: 4.0}},
+				TotalStake: money.FromFloat(100),
+			},
+			wantErr:     true,
+			errContains: "hedge method requires exactly 2 options",
+		},
+		{
+			name: "invalid hedge target",
+			input: &types.CalculationInput{
+				Method:      types.MethodHedge,
+				OddsA:       3.0,
+				OddsB:       2.0,
+				TotalStake:  money.FromFloat(100),
+				HedgeTarget: "all-in",
+			},
+			wantErr:     true,
+			errContains: "invalid hedge target",
+		},
+		{
+			name: "valid each-way with exchange lays",
+			input: &types.CalculationInput{
+				Method:        types.MethodEachWay,
+				Options:       []types.Option{{Odds: 12.0}, {Odds: 11.5, Commission: 0.02}, {Odds: 3.2, Commission: 0.02}},
+				TotalStake:    money.FromFloat(20),
+				PlaceFraction: 0.25,
+				Places:        4,
+			},
+			wantErr: false,
+		},
+		{
+			name: "each-way without place terms",
+			input: &types.CalculationInput{
+				Method:     types.MethodEachWay,
+				Options:    []types.Option{{Odds: 9.0}},
+				TotalStake: money.FromFloat(20),
+				Places:     4,
+			},
+			wantErr:     true,
+			errContains: "requires --place-terms",
+		},
+		{
+			name: "each-way place probability below win probability",
+			input: &types.CalculationInput{
+				Method:           types.MethodEachWay,
+				Options:          []types.Option{{Odds: 9.0, Probability: 0.2}},
+				TotalStake:       money.FromFloat(20),
+				PlaceFraction:    0.2,
+				Places:           4,
+				PlaceProbability: 0.1,
+			},
+			wantErr:     true,
+			errContains: "cannot be below the win probability",
+		},
+		{
+			name: "valid yankee",
+			input: &types.CalculationInput{
+				Method:     types.MethodParlay,
+				Options:    []types.Option{{Odds: 2.1}, {Odds: 1.8}, {Odds: 3.0}, {Odds: 2.5}},
+				TotalStake: money.FromFloat(11),
+				System:     "yankee",
+			},
+			wantErr: false,
+		},
+		{
+			name: "system with the wrong number of legs",
+			input: &types.CalculationInput{
+				Method:     types.MethodParlay,
+				Options:    []types.Option{{Odds: 2.1}, {Odds: 1.8}, {Odds: 3.0}},
+				TotalStake: money.FromFloat(11),
+				System:     "yankee",
+			},
+			wantErr:     true,
+			errContains: "a yankee needs 4 legs",
+		},
+		{
+			name: "parlay combination larger than the legs",
+			input: &types.CalculationInput{
+				Method:     types.MethodParlay,
+				Options:    []types.Option{{Odds: 2.1}, {Odds: 1.8}, {Odds: 3.0}},
+				TotalStake: money.FromFloat(10),
+				System:     "2,4",
+			},
+			wantErr:     true,
+			errContains: "combination size 4",
+		},
+		{
+			name: "valid Asian handicap",
+			input: &types.CalculationInput{
+				Method:      types.MethodArbitrage,
+				Options:     []types.Option{{Odds: 2.1, Line: -0.25}, {Odds: 1.95, Line: 0.25}},
+				TotalStake:  money.FromFloat(1000),
+				AsianMarket: types.AsianHandicap,
+			},
+			wantErr: false,
+		},
+		{
+			name: "Asian line off the quarter goal",
+			input: &types.CalculationInput{
+				Method:      types.MethodArbitrage,
+				Options:     []types.Option{{Odds: 2.1, Line: -0.3}, {Odds: 1.95, Line: 0.3}},
+				TotalStake:  money.FromFloat(1000),
+				AsianMarket: types.AsianHandicap,
+			},
+			wantErr:     true,
+			errContains: "whole, half or quarter goal",
+		},
+		{
+			name: "Asian lines with an unsupported method",
+			input: &types.CalculationInput{
+				Method:      types.MethodProportional,
+				Options:     []types.Option{{Odds: 2.1, Line: 2.5}, {Odds: 1.95, Line: 2.5}},
+				TotalStake:  money.FromFloat(1000),
+				AsianMarket: types.AsianTotal,
+			},
+			wantErr:     true,
+			errContains: "only supported by the arbitrage and kelly methods",
+		},
+		{
+			name: "valid arbitrage across currencies",
+			input: &types.CalculationInput{
+				Method:     types.MethodArbitrage,
+				Options:    []types.Option{{Odds: 2.1, Currency: "NGN"}, {Odds: 2.2, Currency: "USD"}},
+				TotalStake: money.FromFloat(1000),
+				Rates:      &types.RateTable{Base: "NGN", Rates: map[string]types.FXRate{"USD": {Buy: 1550, Sell: 1500}}},
+			},
+			wantErr: false,
+		},
+		{
+			name: "stake limits and rounding across currencies",
+			input: &types.CalculationInput{
+				Method:     types.MethodArbitrage,
+				Options:    []types.Option{{Odds: 2.1, Currency: "NGN"}, {Odds: 2.2, Currency: "USD", MaxStake: money.FromFloat(20)}},
+				TotalStake: money.FromFloat(1000),
+				Rates:      &types.RateTable{Base: "NGN", Rates: map[string]types.FXRate{"USD": {Buy: 1550, Sell: 1500}}},
+				RoundTo:    5,
+			},
+			wantErr: false,
+		},
+		{
+			name: "currencies without rates",
+			input: &types.CalculationInput{
+				Method:     types.MethodArbitrage,
+				Options:    []types.Option{{Odds: 2.1, Currency: "NGN"}, {Odds: 2.2, Currency: "USD"}},
+				TotalStake: money.FromFloat(1000),
+			},
+			wantErr:     true,
+			errContains: "require an exchange-rate table",
+		},
+		{
+			name: "currencies with an unsupported method",
+			input: &types.CalculationInput{
+				Method:     types.MethodKelly,
+				Options:    []types.Option{{Odds: 2.1, Probability: 0.5}, {Odds: 2.2, Probability: 0.5, Currency: "USD"}},
+				TotalStake: money.FromFloat(1000),
+				Rates:      &types.RateTable{Base: "NGN", Rates: map[string]types.FXRate{"USD": {Buy: 1550, Sell: 1500}}},
+			},
+			wantErr:     true,
+			errContains: "only supported by the arbitrage method",
+		},
+		{
+			// 2.05 and 2.0 is a 98.8% book, but the spread takes a USD book
+			// at 2.0 to 1.94 in naira.
+			name: "spread leaves no arbitrage",
+			input: &types.CalculationInput{
+				Method:     types.MethodArbitrage,
+				Options:    []types.Option{{Odds: 2.05}, {Odds: 2.0, Currency: "USD"}},
+				TotalStake: money.FromFloat(1000),
+				Rates:      &types.RateTable{Base: "NGN", Rates: map[string]types.FXRate{"USD": {Buy: 1550, Sell: 1500}}},
+			},
+			wantErr:     true,
+			errContains: "no guaranteed profit",
+		},
 		{
 			name: "invalid method",
 			input: &types.CalculationInput{
 				Method:     "invalid",
 				OddsA:      2.0,
 				OddsB:      2.0,
-				TotalStake: 1000,
+				TotalStake: money.FromFloat(1000),
 			},
 			wantErr:     true,
 			errContains: "invalid calculation method",
@@ -336,7 +860,7 @@ func TestValidateCalculationInputStrict(t *testing.T) {
 		Method:     types.MethodArbitrage,
 		OddsA:      2.56,
 		OddsB:      3.85,
-		TotalStake: 10000,
+		TotalStake: money.FromFloat(10000),
 	}
 
 	err := ValidateCalculationInputStrict(input)
@@ -349,7 +873,7 @@ func TestValidateCalculationInputStrict(t *testing.T) {
 		Method:     types.MethodArbitrage,
 		OddsA:      0.5,
 		OddsB:      2.0,
-		TotalStake: 1000,
+		TotalStake: money.FromFloat(1000),
 	}
 
 	err = ValidateCalculationInputStrict(invalidInput)
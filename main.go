@@ -1,17 +1,23 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
 
 	tea "github.com/charmbracelet/bubbletea"
 
 	"github.com/codehakase/kelly/internal/calculator"
+	"github.com/codehakase/kelly/internal/cli"
 	"github.com/codehakase/kelly/internal/formatter"
+	"github.com/codehakase/kelly/internal/fx"
 	"github.com/codehakase/kelly/internal/parser"
 	"github.com/codehakase/kelly/internal/ui"
 	"github.com/codehakase/kelly/internal/validator"
+	"github.com/codehakase/kelly/pkg/money"
 	"github.com/codehakase/kelly/pkg/types"
 )
 
@@ -21,92 +27,319 @@ var (
 )
 
 func main() {
-	var (
-		oddsA       = flag.String("a", "", "Odds for Option A (required for CLI mode)")
-		oddsB       = flag.String("b", "", "Odds for Option B (required for CLI mode)")
-		total       = flag.Float64("t", 0, "Total amount to allocate (required for CLI mode)")
-		method      = flag.String("m", "arbitrage", "Calculation method (arbitrage, kelly, proportional)")
-		probA       = flag.Float64("pa", 0, "Probability for Option A (required for Kelly method)")
-		probB       = flag.Float64("pb", 0, "Probability for Option B (required for Kelly method)")
-		nameA       = flag.String("na", "Option A", "Name/label for Option A")
-		nameB       = flag.String("nb", "Option B", "Name/label for Option B")
-		currency    = flag.String("c", "₦", "Currency symbol")
-		format      = flag.String("f", "table", "Output format (table, json, csv)")
-		interactive = flag.Bool("i", false, "Force interactive TUI mode")
-		verbose     = flag.Bool("v", false, "Verbose output with explanations")
-		noColor     = flag.Bool("no-color", false, "Disable colored output")
-		compare     = flag.Bool("compare", false, "Compare all calculation methods")
-		version     = flag.Bool("version", false, "Show version information")
-	)
-
-	flag.StringVar(oddsA, "odds-a", "", "Odds for Option A")
-	flag.StringVar(oddsB, "odds-b", "", "Odds for Option B")
-	flag.Float64Var(total, "total", 0, "Total amount to allocate")
-	flag.StringVar(method, "method", "arbitrage", "Calculation method")
-	flag.Float64Var(probA, "prob-a", 0, "Probability for Option A")
-	flag.Float64Var(probB, "prob-b", 0, "Probability for Option B")
-	flag.StringVar(nameA, "name-a", "Option A", "Name for Option A")
-	flag.StringVar(nameB, "name-b", "Option B", "Name for Option B")
-	flag.BoolVar(verbose, "verbose", false, "Verbose output")
-
-	flag.Usage = printUsage
-	flag.Parse()
-
-	if *version {
-		fmt.Printf("Kelly Calculator %s (built %s)\n", Version, BuildTime)
-		os.Exit(0)
-	}
-
-	if len(os.Args) == 1 || *interactive {
-		runInteractive()
-	} else if *oddsA != "" && *oddsB != "" && *total > 0 {
-		runCLI(*oddsA, *oddsB, *total, *method, *probA, *probB,
-			*nameA, *nameB, *currency, *format, *verbose, *noColor, *compare)
-	} else {
-		if *oddsA != "" || *oddsB != "" || *total > 0 {
-			fmt.Fprintln(os.Stderr, "Error: CLI mode requires --odds-a, --odds-b, and --total")
-			fmt.Fprintln(os.Stderr, "Run with -h for usage information")
+	args := os.Args[1:]
+	switch {
+	case len(args) == 0:
+		tuiCommand.Run(nil)
+	case strings.HasPrefix(args[0], "-"):
+		// Bare flags are kelly calc's, with -i and --version besides.
+		bareCommand.Run(args)
+	default:
+		cmd := cli.Find(commands, args[0])
+		if cmd == nil {
+			fmt.Fprintf(os.Stderr, "✗ Error: unknown command '%s'\n\n", args[0])
+			printUsage(os.Stderr)
 			os.Exit(1)
 		}
-		runInteractive()
+		cmd.Run(args[1:])
 	}
 }
 
-func runInteractive() {
-	p := tea.NewProgram(ui.NewModel(), tea.WithAltScreen())
+// fatal reports err the way every subcommand does and exits with status 1.
+func fatal(err error) {
+	fmt.Fprintf(os.Stderr, "✗ Error: %v\n", err)
+	os.Exit(1)
+}
+
+// runInteractive opens the TUI with its fields filled from the market
+// flags. A currency given or configured beats the bankroll's.
+func runInteractive(fs *flag.FlagSet, market *marketFlags, display types.OddsFormat) {
+	model := ui.NewModel().WithOddsFormat(display)
+	if b, err := loadBankroll(market.bankroll); err == nil {
+		pending, err := pendingStakes(b)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "✗ Error: %v\n", err)
+			os.Exit(1)
+		}
+		model = model.WithBankroll(b, pending)
+	} else if market.bankroll != "" {
+		fmt.Fprintf(os.Stderr, "✗ Error: %v\n", err)
+		os.Exit(1)
+	}
+	model = model.WithDefaults(market.tuiDefaults(fs))
+
+	p := tea.NewProgram(model, tea.WithAltScreen())
 	if _, err := p.Run(); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
 }
 
-func runCLI(oddsAStr, oddsBStr string, total float64, methodStr string,
-	probA, probB float64, nameA, nameB, currency, format string,
-	verbose, noColor, compare bool) {
+// buildOptions turns either the --odds/--names/--probs lists or the
+// two-option shorthand flags into the options of a market, reading odds in
+// the given format (detected when empty).
+func buildOptions(oddsAStr, oddsBStr, nameA, nameB string, probA, probB float64,
+	oddsList, namesList, probsList string, format types.OddsFormat) ([]types.Option, error) {
 
-	decimalOddsA, err := parser.ParseOdds(oddsAStr)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "✗ Error parsing odds A: %v\n", err)
-		os.Exit(1)
+	if oddsList == "" {
+		decimalOddsA, err := parser.ParseOddsAs(oddsAStr, format)
+		if err != nil {
+			return nil, fmt.Errorf("parsing odds A: %w", err)
+		}
+		if oddsBStr == "" {
+			return []types.Option{{Name: nameA, Odds: decimalOddsA, Probability: probA}}, nil
+		}
+		decimalOddsB, err := parser.ParseOddsAs(oddsBStr, format)
+		if err != nil {
+			return nil, fmt.Errorf("parsing odds B: %w", err)
+		}
+		return []types.Option{
+			{Name: nameA, Odds: decimalOddsA, Probability: probA},
+			{Name: nameB, Odds: decimalOddsB, Probability: probB},
+		}, nil
 	}
 
-	decimalOddsB, err := parser.ParseOdds(oddsBStr)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "✗ Error parsing odds B: %v\n", err)
-		os.Exit(1)
+	oddsParts := strings.Split(oddsList, ",")
+	var names, probs []string
+	if namesList != "" {
+		names = strings.Split(namesList, ",")
+		if len(names) != len(oddsParts) {
+			return nil, fmt.Errorf("--names has %d entries, --odds has %d", len(names), len(oddsParts))
+		}
+	}
+	if probsList != "" {
+		probs = strings.Split(probsList, ",")
+		if len(probs) != len(oddsParts) {
+			return nil, fmt.Errorf("--probs has %d entries, --odds has %d", len(probs), len(oddsParts))
+		}
 	}
 
-	calcMethod := types.CalculationMethod(methodStr)
-	switch calcMethod {
-	case types.MethodArbitrage, types.MethodKelly, types.MethodProportional:
+	options := make([]types.Option, len(oddsParts))
+	for i, part := range oddsParts {
+		odds, err := parser.ParseOddsAs(part, format)
+		if err != nil {
+			return nil, fmt.Errorf("parsing odds for %s: %w", types.OptionLabel(i), err)
+		}
+		options[i] = types.Option{Name: types.OptionLabel(i), Odds: odds}
+		if names != nil {
+			options[i].Name = strings.TrimSpace(names[i])
+		}
+		if probs != nil {
+			prob, err := strconv.ParseFloat(strings.TrimSpace(probs[i]), 64)
+			if err != nil {
+				return nil, fmt.Errorf("parsing probability for %s: %w", types.OptionLabel(i), err)
+			}
+			options[i].Probability = prob
+		}
+	}
+	return options, nil
+}
+
+// applySharpOdds sets the reference odds used for margin removal from either
+// the --sharp list or the --sharp-a/--sharp-b shorthand.
+func applySharpOdds(options []types.Option, sharpA, sharpB, sharpList string, format types.OddsFormat) error {
+	var parts []string
+	switch {
+	case sharpList != "":
+		parts = strings.Split(sharpList, ",")
+		if len(parts) != len(options) {
+			return fmt.Errorf("--sharp has %d entries, market has %d options", len(parts), len(options))
+		}
+	case sharpA != "" || sharpB != "":
+		if len(options) != 2 {
+			return errors.New("--sharp-a/--sharp-b only apply to two-option markets, use --sharp")
+		}
+		parts = []string{sharpA, sharpB}
 	default:
-		fmt.Fprintf(os.Stderr, "✗ Error: Invalid method '%s'. Must be: arbitrage, kelly, or proportional\n", methodStr)
-		os.Exit(1)
+		return nil
+	}
+
+	for i, part := range parts {
+		if strings.TrimSpace(part) == "" {
+			continue
+		}
+		odds, err := parser.ParseOddsAs(part, format)
+		if err != nil {
+			return fmt.Errorf("parsing sharp odds for %s: %w", types.OptionLabel(i), err)
+		}
+		options[i].SharpOdds = odds
+	}
+	return nil
+}
+
+// applyExchangeTerms sets the back/lay side and commission rate of each
+// option from the --side and --commission lists.
+func applyExchangeTerms(options []types.Option, sides, commissions string) error {
+	if sides != "" {
+		parts := strings.Split(sides, ",")
+		if len(parts) != len(options) {
+			return fmt.Errorf("--side has %d entries, market has %d options", len(parts), len(options))
+		}
+		for i, part := range parts {
+			options[i].Side = types.Side(strings.ToLower(strings.TrimSpace(part)))
+		}
+	}
+
+	if commissions != "" {
+		parts := strings.Split(commissions, ",")
+		if len(parts) != 1 && len(parts) != len(options) {
+			return fmt.Errorf("--commission has %d entries, market has %d options", len(parts), len(options))
+		}
+		for i := range options {
+			part := parts[0]
+			if len(parts) > 1 {
+				part = parts[i]
+			}
+			rate, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+			if err != nil {
+				return fmt.Errorf("parsing commission for %s: %w", types.OptionLabel(i), err)
+			}
+			options[i].Commission = rate
+		}
+	}
+	return nil
+}
+
+// applyStakeLimits sets the bookmaker stake limits of the options from
+// comma-separated lists holding one amount for every option or one per
+// option. An empty entry leaves that option unlimited.
+func applyStakeLimits(options []types.Option, minStakes, maxStakes string) error {
+	for _, limit := range []struct {
+		flag, list string
+		set        func(*types.Option, float64)
+	}{
+		{"--min-stake", minStakes, func(o *types.Option, v float64) { o.MinStake = money.FromFloat(v) }},
+		{"--max-stake", maxStakes, func(o *types.Option, v float64) { o.MaxStake = money.FromFloat(v) }},
+	} {
+		if limit.list == "" {
+			continue
+		}
+		parts := strings.Split(limit.list, ",")
+		if len(parts) != 1 && len(parts) != len(options) {
+			return fmt.Errorf("%s has %d entries, market has %d options", limit.flag, len(parts), len(options))
+		}
+		for i := range options {
+			part := strings.TrimSpace(parts[0])
+			if len(parts) > 1 {
+				part = strings.TrimSpace(parts[i])
+			}
+			if part == "" {
+				continue
+			}
+			amount, err := strconv.ParseFloat(part, 64)
+			if err != nil {
+				return fmt.Errorf("parsing %s for %s: %w", limit.flag, types.OptionLabel(i), err)
+			}
+			limit.set(&options[i], amount)
+		}
 	}
+	return nil
+}
 
-	input := &types.CalculationInput{
-		Method: calcMethod, OddsA: decimalOddsA, OddsB: decimalOddsB, TotalStake: total,
-		ProbA: probA, ProbB: probB, NameA: nameA, NameB: nameB, Currency: currency,
+// applyTaxes sets each option's winnings tax and stake excise rates from
+// comma-separated lists holding one rate for every option or one per
+// option. An empty entry leaves that option untaxed.
+func applyTaxes(options []types.Option, taxes, excises string) error {
+	for _, rate := range []struct {
+		flag, list string
+		set        func(*types.Option, float64)
+	}{
+		{"--tax", taxes, func(o *types.Option, v float64) { o.TaxOnWinnings = v }},
+		{"--excise", excises, func(o *types.Option, v float64) { o.StakeExcise = v }},
+	} {
+		if rate.list == "" {
+			continue
+		}
+		parts := strings.Split(rate.list, ",")
+		if len(parts) != 1 && len(parts) != len(options) {
+			return fmt.Errorf("%s has %d entries, market has %d options", rate.flag, len(parts), len(options))
+		}
+		for i := range options {
+			part := strings.TrimSpace(parts[0])
+			if len(parts) > 1 {
+				part = strings.TrimSpace(parts[i])
+			}
+			if part == "" {
+				continue
+			}
+			v, err := strconv.ParseFloat(part, 64)
+			if err != nil {
+				return fmt.Errorf("parsing %s for %s: %w", rate.flag, types.OptionLabel(i), err)
+			}
+			rate.set(&options[i], v)
+		}
+	}
+	return nil
+}
+
+// applyAsianLines sets the options' Asian lines from a comma-separated list,
+// one line per option. A single line is option A's, with option B on the
+// complementary line: the opposite handicap, or the same goal total.
+func applyAsianLines(options []types.Option, market types.AsianMarket, lines string) error {
+	if lines == "" {
+		if market != "" {
+			return errors.New("--asian requires --lines")
+		}
+		return nil
+	}
+	if market == "" {
+		return errors.New("--lines requires --asian handicap or total")
+	}
+
+	parts := strings.Split(lines, ",")
+	if len(parts) != 1 && len(parts) != len(options) {
+		return fmt.Errorf("--lines has %d entries, market has %d options", len(parts), len(options))
+	}
+	for i, part := range parts {
+		line, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return fmt.Errorf("parsing --lines for %s: %w", types.OptionLabel(i), err)
+		}
+		options[i].Line = line
+	}
+	if len(parts) == 1 && len(options) == 2 {
+		options[1].Line = options[0].Line
+		if market == types.AsianHandicap {
+			options[1].Line = -options[0].Line
+		}
+	}
+	return nil
+}
+
+// applyCurrencies sets each option's currency from the comma-separated
+// --currencies list and loads the exchange rates to convert them with, from
+// ratesPath or the default rates file. An empty entry leaves that option in
+// the base currency.
+func applyCurrencies(options []types.Option, currencies, ratesPath, base string) (*types.RateTable, error) {
+	if currencies == "" {
+		if base != "" {
+			return nil, errors.New("--base requires --currencies")
+		}
+		return nil, nil
+	}
+
+	parts := strings.Split(currencies, ",")
+	if len(parts) != len(options) {
+		return nil, fmt.Errorf("--currencies has %d entries, market has %d options", len(parts), len(options))
+	}
+	for i, part := range parts {
+		options[i].Currency = fx.Code(part)
+	}
+
+	if ratesPath == "" {
+		var err error
+		if ratesPath, err = fx.DefaultPath(); err != nil {
+			return nil, err
+		}
+	}
+	return fx.Load(ratesPath)
+}
+
+func runCLI(input *types.CalculationInput, flags *calcFlags) {
+
+	if err := validMethod(input.Method); err != nil {
+		fmt.Fprintf(os.Stderr, "✗ Error: %v\n", err)
+		os.Exit(1)
 	}
 
 	if err := validator.ValidateCalculationInput(input); err != nil {
@@ -114,8 +347,8 @@ func runCLI(oddsAStr, oddsBStr string, total float64, methodStr string,
 		os.Exit(1)
 	}
 
-	if compare {
-		runComparison(input, format, verbose)
+	if flags.compare {
+		runComparison(input, &flags.output)
 		return
 	}
 
@@ -126,17 +359,24 @@ func runCLI(oddsAStr, oddsBStr string, total float64, methodStr string,
 		os.Exit(1)
 	}
 
-	output, err := formatOutput(result, format, verbose)
+	output, err := formatOutput(result, &flags.output)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "✗ Formatting error: %v\n", err)
 		os.Exit(1)
 	}
 	fmt.Println(output)
+
+	if flags.record {
+		if err := recordBet(result, flags.market.bankroll); err != nil {
+			fmt.Fprintf(os.Stderr, "✗ Journal error: %v\n", err)
+			os.Exit(1)
+		}
+	}
 }
 
-func runComparison(input *types.CalculationInput, format string, verbose bool) {
+func runComparison(input *types.CalculationInput, out *outputFlags) {
 	methods := []types.CalculationMethod{
-		types.MethodArbitrage, types.MethodKelly, types.MethodProportional,
+		types.MethodArbitrage, types.MethodKelly, types.MethodKellySimultaneous,
 	}
 
 	fmt.Println("╭─────────────────────────────────────────────────────────────────────╮")
@@ -147,10 +387,14 @@ func runComparison(input *types.CalculationInput, format string, verbose bool) {
 	for _, method := range methods {
 		input.Method = method
 
-		if method == types.MethodKelly && (input.ProbA == 0 || input.ProbB == 0) {
+		if method.RequiresProbabilities() && !hasProbabilities(input) {
 			fmt.Printf("─── %s (skipped: requires probabilities) ───\n\n", methodName(method))
 			continue
 		}
+		if input.AsianMarket != "" && method != types.MethodArbitrage && method != types.MethodKelly {
+			fmt.Printf("─── %s (skipped: does not support Asian lines) ───\n\n", methodName(method))
+			continue
+		}
 
 		calc := calculator.NewCalculator(method)
 		result, err := calc.Calculate(input)
@@ -160,20 +404,35 @@ func runComparison(input *types.CalculationInput, format string, verbose bool) {
 		}
 
 		fmt.Printf("─── %s ───\n", methodName(method))
-		output, _ := formatOutput(result, format, verbose)
+		output, _ := formatOutput(result, out)
 		fmt.Println(output)
 		fmt.Println()
 	}
 }
 
-func formatOutput(result *types.CalculationResult, format string, verbose bool) (string, error) {
-	switch types.OutputFormat(format) {
+func hasProbabilities(input *types.CalculationInput) bool {
+	if input.FairFrom != "" {
+		return true
+	}
+	for _, opt := range input.Outcomes() {
+		if opt.Probability == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// formatOutput renders the result in the output format, showing its odds
+// in the display format.
+func formatOutput(result *types.CalculationResult, out *outputFlags) (string, error) {
+	formatter.SetDisplayOdds(result, out.display)
+	switch types.OutputFormat(out.format) {
 	case types.OutputJSON:
 		return formatter.FormatJSON(result)
 	case types.OutputCSV:
 		return formatter.FormatCSV(result)
 	default:
-		return formatter.FormatTable(result, verbose), nil
+		return formatter.FormatTable(result, out.verbose), nil
 	}
 }
 
@@ -183,44 +442,17 @@ func methodName(method types.CalculationMethod) string {
 		return "ARBITRAGE (Guaranteed Profit)"
 	case types.MethodKelly:
 		return "KELLY CRITERION (Growth Optimization)"
-	case types.MethodProportional:
-		return "PROPORTIONAL (Inverse Odds)"
+	case types.MethodKellySimultaneous:
+		return "SIMULTANEOUS KELLY (Joint Growth Optimization)"
+	case types.MethodMatched:
+		return "MATCHED BETTING (Back/Lay Conversion)"
+	case types.MethodHedge:
+		return "HEDGE (Cash Out)"
+	case types.MethodEachWay:
+		return "EACH-WAY (Win and Place)"
+	case types.MethodParlay:
+		return "PARLAY (Accumulator / System)"
 	default:
 		return string(method)
 	}
 }
-
-func printUsage() {
-	fmt.Fprintf(os.Stderr, `Kelly - Optimal Betting Stake Calculator
-
-USAGE:
-  kelly                          Launch interactive TUI (default)
-  kelly [flags]                  Run calculation with CLI arguments
-
-EXAMPLES:
-  kelly
-  kelly -a 2.56 -b 3.85 -t 10000
-  kelly --odds-a 39%% --odds-b 26%% --total 10000
-  kelly -a 2.56 -b 3.85 -t 10000 --name-a "Davido" --name-b "Tyla" --currency "₦"
-  kelly -a 2.1 -b 3.5 -t 1000 --method kelly --prob-a 0.55 --prob-b 0.40
-  kelly -a 2.56 -b 3.85 -t 10000 -f json
-  kelly -a 2.56 -b 3.85 -t 10000 --compare
-
-FLAGS:
-`)
-	flag.PrintDefaults()
-	fmt.Fprintf(os.Stderr, `
-ODDS FORMATS:
-  Decimal:     2.5, 3.85
-  Percentage:  39%%, 26%%
-  Fractional:  3/2, 5/2
-  American:    +250, -150
-
-CALCULATION METHODS:
-  arbitrage     Guarantees profit regardless of outcome (default)
-  kelly         Maximizes growth based on probability estimates
-  proportional  Simple allocation inversely proportional to odds
-
-For more information, visit: https://github.com/codehakase/kelly
-`)
-}
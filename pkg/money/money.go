@@ -0,0 +1,150 @@
+// Package money does exact arithmetic on amounts of money. Amounts are held
+// as whole minor units (cents, kobo), so amounts that should add up do, and
+// results reconcile to the cent against bookmaker statements.
+package money
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Amount is an amount of money in minor units: hundredths of the currency.
+type Amount int64
+
+// FromFloat converts an amount in major units to the nearest minor unit,
+// rounding halves away from zero. The amount is read as its shortest
+// decimal form, so 1.005 rounds to 1.01 even though the float closest to it
+// is a little below.
+func FromFloat(v float64) Amount {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return 0
+	}
+	s := strconv.FormatFloat(math.Abs(v), 'f', -1, 64)
+	whole, frac, _ := strings.Cut(s, ".")
+	frac += "000"
+
+	units, _ := strconv.ParseInt(whole, 10, 64)
+	minor, _ := strconv.ParseInt(frac[:2], 10, 64)
+	a := Amount(units*100 + minor)
+	if frac[2] >= '5' {
+		a++
+	}
+	if v < 0 {
+		return -a
+	}
+	return a
+}
+
+// Float64 returns the amount in major units.
+func (a Amount) Float64() float64 {
+	return float64(a) / 100
+}
+
+// String formats the amount with two decimals.
+func (a Amount) String() string {
+	sign := ""
+	if a < 0 {
+		sign, a = "-", -a
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, a/100, a%100)
+}
+
+// MarshalJSON writes the amount as a JSON number in major units, as a float
+// holding it would be written.
+func (a Amount) MarshalJSON() ([]byte, error) {
+	return strconv.AppendFloat(nil, a.Float64(), 'f', -1, 64), nil
+}
+
+// UnmarshalJSON reads a JSON number in major units, rounded to the nearest
+// minor unit.
+func (a *Amount) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	v, err := strconv.ParseFloat(string(data), 64)
+	if err != nil {
+		return fmt.Errorf("amount %s is not a number", data)
+	}
+	*a = FromFloat(v)
+	return nil
+}
+
+// Round rounds an amount in major units to the nearest minor unit.
+func Round(v float64) float64 {
+	return FromFloat(v).Float64()
+}
+
+// Sum adds amounts in major units exactly, each rounded to a minor unit.
+func Sum(values ...float64) Amount {
+	var total Amount
+	for _, v := range values {
+		total += FromFloat(v)
+	}
+	return total
+}
+
+// Allocate splits total across shares in proportion to their weights, in
+// whole minor units that add up to exactly the total. Each share gets the
+// whole units of its exact amount, and the units left over go one each to
+// the shares with the largest remainders (largest remainder method). Ties
+// go to the earlier share. Negative weights count as zero; when no weight
+// is positive every share is zero.
+func Allocate(total Amount, weights []float64) []Amount {
+	shares := make([]Amount, len(weights))
+	var sum float64
+	for _, w := range weights {
+		sum += math.Max(0, w)
+	}
+	if sum <= 0 || total == 0 {
+		return shares
+	}
+
+	sign := Amount(1)
+	if total < 0 {
+		sign, total = -1, -total
+	}
+	remainders := make([]float64, len(weights))
+	left := total
+	for i, w := range weights {
+		exact := float64(total) * math.Max(0, w) / sum
+		shares[i] = Amount(math.Floor(exact + 1e-9))
+		remainders[i] = exact - float64(shares[i])
+		left -= shares[i]
+	}
+
+	order := make([]int, len(weights))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return remainders[order[a]] > remainders[order[b]]
+	})
+	for _, i := range order {
+		if left <= 0 {
+			break
+		}
+		if weights[i] > 0 {
+			shares[i]++
+			left--
+		}
+	}
+
+	for i := range shares {
+		shares[i] *= sign
+	}
+	return shares
+}
+
+// Split splits a total across shares in proportion to their weights, with
+// Allocate, returning the shares in major units.
+func Split(total Amount, weights []float64) []float64 {
+	amounts := Allocate(total, weights)
+	shares := make([]float64, len(amounts))
+	for i, a := range amounts {
+		shares[i] = a.Float64()
+	}
+	return shares
+}
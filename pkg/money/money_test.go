@@ -0,0 +1,109 @@
+package money
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestFromFloat(t *testing.T) {
+	tests := []struct {
+		value float64
+		want  Amount
+	}{
+		{0, 0},
+		{1.005, 101},
+		{2.675, 268},
+		{-1.005, -101},
+		{0.1 + 0.2, 30},
+		{10000, 1000000},
+		{3617.004999, 361700},
+		{-0.004, 0},
+	}
+
+	for _, tt := range tests {
+		if got := FromFloat(tt.value); got != tt.want {
+			t.Errorf("FromFloat(%v) = %d, want %d", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestAmount_String(t *testing.T) {
+	tests := map[Amount]string{0: "0.00", 5: "0.05", 123456: "1234.56", -101: "-1.01"}
+	for a, want := range tests {
+		if got := a.String(); got != want {
+			t.Errorf("Amount(%d).String() = %q, want %q", int64(a), got, want)
+		}
+	}
+}
+
+func TestAmount_JSON(t *testing.T) {
+	data, err := json.Marshal(struct {
+		Stake, Profit, Zero Amount
+	}{646349, -50, 0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"Stake":6463.49,"Profit":-0.5,"Zero":0}`; string(data) != want {
+		t.Errorf("Marshal() = %s, want %s", data, want)
+	}
+
+	var got struct{ A, B, C Amount }
+	if err := json.Unmarshal([]byte(`{"A": 6463.49, "B": 1.005, "C": null}`), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.A != 646349 || got.B != 101 || got.C != 0 {
+		t.Errorf("Unmarshal() = %+v, want 646349, 101 and 0", got)
+	}
+	if err := json.Unmarshal([]byte(`{"A": "ten"}`), &got); err == nil {
+		t.Error("Unmarshal() expected an error for a string")
+	}
+}
+
+func TestSum(t *testing.T) {
+	if got := Sum(0.1, 0.2, 0.3); got != 60 {
+		t.Errorf("Sum(0.1, 0.2, 0.3) = %s, want 0.60", got)
+	}
+}
+
+func TestAllocate(t *testing.T) {
+	tests := []struct {
+		name    string
+		total   Amount
+		weights []float64
+		want    []Amount
+	}{
+		{"even split", 1000, []float64{1, 1}, []Amount{500, 500}},
+		{"thirds", 1000, []float64{1, 1, 1}, []Amount{334, 333, 333}},
+		{"largest remainder wins the cent", 1001, []float64{0.3, 0.7}, []Amount{300, 701}},
+		{"zero weight gets nothing", 1000, []float64{1, 0, 1}, []Amount{500, 0, 500}},
+		{"no positive weight", 1000, []float64{0, -1}, []Amount{0, 0}},
+		{"negative total", -1000, []float64{1, 1, 1}, []Amount{-334, -333, -333}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Allocate(tt.total, tt.weights); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Allocate(%d, %v) = %v, want %v", tt.total, tt.weights, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplit_AddsUpToTotal(t *testing.T) {
+	totals := []Amount{1000000, 10001, 7, 1234567, 99999}
+	weights := [][]float64{
+		{1 / 1.56, 1 / 2.85},
+		{1 / 1.1, 1 / 2.4, 1 / 2.6},
+		{1 / 0.91, 1 / 0.91, 1 / 3.5, 1 / 9.0},
+	}
+
+	for _, total := range totals {
+		for _, w := range weights {
+			shares := Split(total, w)
+			if got := Sum(shares...); got != total {
+				t.Errorf("Split(%s, %v) = %v, adds up to %s", total, w, shares, got)
+			}
+		}
+	}
+}
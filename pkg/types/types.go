@@ -1,13 +1,32 @@
 package types
 
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/codehakase/kelly/pkg/money"
+)
+
 type CalculationMethod string
 
 const (
-	MethodArbitrage    CalculationMethod = "arbitrage"
-	MethodKelly        CalculationMethod = "kelly"
-	MethodProportional CalculationMethod = "proportional"
+	MethodArbitrage         CalculationMethod = "arbitrage"
+	MethodKelly             CalculationMethod = "kelly"
+	MethodProportional      CalculationMethod = "proportional" // retired; runs as MethodArbitrage
+	MethodKellySimultaneous CalculationMethod = "kelly-simultaneous"
+	MethodMatched           CalculationMethod = "matched"
+	MethodHedge             CalculationMethod = "hedge"
+	MethodEachWay           CalculationMethod = "each-way"
+	MethodParlay            CalculationMethod = "parlay"
 )
 
+// RequiresProbabilities reports whether the method needs a probability
+// estimate for every option.
+func (m CalculationMethod) RequiresProbabilities() bool {
+	return m == MethodKelly || m == MethodKellySimultaneous
+}
+
 type OddsFormat string
 
 const (
@@ -15,6 +34,25 @@ const (
 	FormatPercentage OddsFormat = "percentage"
 	FormatFractional OddsFormat = "fractional"
 	FormatAmerican   OddsFormat = "american"
+	// FormatHongKong is the net win per unit staked, e.g. 0.85 for 1.85.
+	FormatHongKong OddsFormat = "hongkong"
+	// FormatMalay is the net win per unit staked when positive (up to 1),
+	// or minus the stake needed to win one unit when negative.
+	FormatMalay OddsFormat = "malay"
+	// FormatIndonesian is American odds divided by 100.
+	FormatIndonesian OddsFormat = "indonesian"
+)
+
+// MarginMethod selects how the bookmaker margin is removed from a market to
+// derive fair probabilities.
+type MarginMethod string
+
+const (
+	MarginMultiplicative MarginMethod = "multiplicative"
+	MarginAdditive       MarginMethod = "additive"
+	MarginPower          MarginMethod = "power"
+	MarginShin           MarginMethod = "shin"
+	MarginOddsRatio      MarginMethod = "odds-ratio"
 )
 
 type OutputFormat string
@@ -25,44 +63,546 @@ const (
 	OutputCSV   OutputFormat = "csv"
 )
 
+// BetType distinguishes the bookmaker bets converted by matched betting.
+type BetType string
+
+const (
+	BetQualifying BetType = "qualifying"
+	// BetFreeSNR is a free bet whose stake is not returned with winnings.
+	BetFreeSNR BetType = "free-snr"
+	// BetFreeSR is a free bet whose stake is returned with winnings.
+	BetFreeSR BetType = "free-sr"
+)
+
+// HedgeTarget selects the outcome a hedge locks in for an open position.
+type HedgeTarget string
+
+const (
+	// HedgeEqual makes both results pay the same profit (or loss).
+	HedgeEqual HedgeTarget = "equal"
+	// HedgeFreeBet recovers the position's stake if the hedge wins, leaving
+	// all of the profit on the position.
+	HedgeFreeBet HedgeTarget = "free-bet"
+	// HedgeBreakEven breaks even if the position wins, moving all of the
+	// profit onto the hedge.
+	HedgeBreakEven HedgeTarget = "break-even"
+)
+
+// AsianMarket is the kind of Asian line a two-way market is priced on.
+type AsianMarket string
+
+const (
+	// AsianHandicap lines are goal handicaps on each side, e.g. -0.25 on
+	// the home side against +0.25 on the away side.
+	AsianHandicap AsianMarket = "handicap"
+	// AsianTotal lines are a goal total, option A over it and option B
+	// under it.
+	AsianTotal AsianMarket = "total"
+)
+
+// LineOutcome is how a bet on an Asian line settles. A quarter line splits
+// the stake across the two neighbouring lines, so half of it can win or
+// lose while the other half pushes.
+type LineOutcome string
+
+const (
+	LineWin      LineOutcome = "win"
+	LineHalfWin  LineOutcome = "half-win"
+	LinePush     LineOutcome = "push"
+	LineHalfLoss LineOutcome = "half-loss"
+	LineLoss     LineOutcome = "loss"
+)
+
+// RoundMode selects how stakes are rounded to bookmaker-friendly amounts.
+type RoundMode string
+
+const (
+	RoundNearest RoundMode = "nearest"
+	RoundDown    RoundMode = "down"
+	// RoundStealth rounds to a step that grows with the stake (5s in the
+	// hundreds, 50s in the thousands) so stakes look hand-picked.
+	RoundStealth RoundMode = "stealth"
+)
+
+// Side is the direction of a bet: backing a selection to win, or laying it
+// on a betting exchange.
+type Side string
+
+const (
+	SideBack Side = "back"
+	SideLay  Side = "lay"
+)
+
+// Constraint names the sizing limit that determined an option's stake.
+type Constraint string
+
+const (
+	ConstraintMinEdge       Constraint = "min-edge"
+	ConstraintKellyFraction Constraint = "kelly-fraction"
+	ConstraintMaxStake      Constraint = "max-stake"
+	ConstraintBankroll      Constraint = "bankroll"
+	ConstraintBookMin       Constraint = "book-min"
+	ConstraintBookMax       Constraint = "book-max"
+)
+
+// Option is a single outcome of a market. For a lay bet Stake is the
+// backer's stake and Liability the amount at risk, (Odds - 1) × Stake.
+// Commission is the rate charged on net winnings. TaxOnWinnings is the rate
+// a book withholds from net winnings after commission, and StakeExcise the
+// rate of excise it takes out of each stake before placing the bet; both
+// are already counted in ReturnIfWins, and GrossReturn is what the option
+// would return without them. MinStake and MaxStake are
+// the bookmaker's limits on Stake; zero means no limit. Line is the
+// option's Asian handicap or goal total. Legs lists the indices of the
+// parlay legs a combination is made of. DisplayOdds is Odds rendered in the
+// result's OddsFormat when that is not decimal. Currency is the code of the
+// book's currency in a market across currencies: Stake and ReturnIfWins are
+// then in it, and ProfitIfWins and ROI in the base currency.
 type Option struct {
-	Name               string  `json:"name"`
-	Odds               float64 `json:"odds"`
-	ImpliedProbability float64 `json:"implied_probability"`
-	Probability        float64 `json:"probability,omitempty"`
-	Stake              float64 `json:"stake"`
-	ReturnIfWins       float64 `json:"return_if_wins"`
-	ProfitIfWins       float64 `json:"profit_if_wins"`
-	ROI                float64 `json:"roi"`
+	Name               string       `json:"name"`
+	Bookmaker          string       `json:"bookmaker,omitempty"`
+	Currency           string       `json:"currency,omitempty"`
+	Odds               float64      `json:"odds"`
+	DisplayOdds        string       `json:"display_odds,omitempty"`
+	ImpliedProbability float64      `json:"implied_probability"`
+	Probability        float64      `json:"probability,omitempty"`
+	SharpOdds          float64      `json:"sharp_odds,omitempty"`
+	Side               Side         `json:"side,omitempty"`
+	Commission         float64      `json:"commission,omitempty"`
+	TaxOnWinnings      float64      `json:"tax_on_winnings,omitempty"`
+	StakeExcise        float64      `json:"stake_excise,omitempty"`
+	Line               float64      `json:"line,omitempty"`
+	Fraction           float64      `json:"fraction,omitempty"`
+	MinStake           money.Amount `json:"min_stake,omitempty"`
+	MaxStake           money.Amount `json:"max_stake,omitempty"`
+	Stake              money.Amount `json:"stake"`
+	Liability          money.Amount `json:"liability,omitempty"`
+	ReturnIfWins       money.Amount `json:"return_if_wins"`
+	GrossReturn        money.Amount `json:"gross_return,omitempty"`
+	ProfitIfWins       money.Amount `json:"profit_if_wins"`
+	ROI                float64      `json:"roi"`
+
+	BindingConstraint Constraint `json:"binding_constraint,omitempty"`
+
+	Legs []int `json:"legs,omitempty"`
 }
 
 type Summary struct {
-	GuaranteedProfit bool    `json:"guaranteed_profit"`
-	MinProfit        float64 `json:"min_profit"`
-	MaxProfit        float64 `json:"max_profit"`
-	ExpectedValue    float64 `json:"expected_value"`
-	MinROI           float64 `json:"min_roi"`
-	MaxROI           float64 `json:"max_roi"`
-	MarketEfficiency float64 `json:"market_efficiency"`
+	GuaranteedProfit bool         `json:"guaranteed_profit"`
+	MinProfit        money.Amount `json:"min_profit"`
+	MaxProfit        money.Amount `json:"max_profit"`
+	ExpectedValue    money.Amount `json:"expected_value"`
+	MinROI           float64      `json:"min_roi"`
+	MaxROI           float64      `json:"max_roi"`
+	MarketEfficiency float64      `json:"market_efficiency"`
+	GrowthRate       float64      `json:"growth_rate,omitempty"`
+	CashReserve      money.Amount `json:"cash_reserve,omitempty"`
+
+	FairFrom MarginMethod `json:"fair_from,omitempty"`
+
+	BetType        BetType `json:"bet_type,omitempty"`
+	ExtractionRate float64 `json:"extraction_rate,omitempty"`
+
+	HedgeTarget HedgeTarget `json:"hedge_target,omitempty"`
+
+	EachWay *EachWayOutcome `json:"each_way,omitempty"`
+	Parlay  *ParlaySummary  `json:"parlay,omitempty"`
+	Asian   *AsianOutcome   `json:"asian,omitempty"`
+	FX      *FXSummary      `json:"fx,omitempty"`
+
+	RoundTo      float64      `json:"round_to,omitempty"`
+	RoundMode    RoundMode    `json:"round_mode,omitempty"`
+	RoundingCost money.Amount `json:"rounding_cost,omitempty"`
+
+	// RequestedTotal is the total asked for when a stake limit scaled the
+	// position down to TotalStake.
+	RequestedTotal money.Amount `json:"requested_total,omitempty"`
+}
+
+// EachWayOutcome describes an each-way position: the selection, its place
+// terms and its profit for each way the selection can finish.
+type EachWayOutcome struct {
+	Selection     string       `json:"selection"`
+	PlaceFraction float64      `json:"place_fraction"`
+	Places        int          `json:"places"`
+	PlaceOdds     float64      `json:"place_odds"`
+	IfWins        money.Amount `json:"if_wins"`
+	IfPlaces      money.Amount `json:"if_places"`
+	IfLoses       money.Amount `json:"if_loses"`
+}
+
+// ParlaySummary describes a combined bet over independent legs. Each
+// combination line has the same UnitStake; KellyFraction is the share of a
+// bankroll per line that maximizes expected log growth, and KellyStake that
+// share of the total.
+type ParlaySummary struct {
+	System        string       `json:"system"`
+	Selections    []Option     `json:"selections"`
+	Lines         int          `json:"lines"`
+	UnitStake     money.Amount `json:"unit_stake"`
+	KellyFraction float64      `json:"kelly_fraction"`
+	KellyStake    money.Amount `json:"kelly_stake"`
+}
+
+// AsianOutcome describes a position on complementary Asian lines: its
+// profit when either side clears the line, and when the result lands on it
+// (Split), where the sides push or settle half of their stakes.
+type AsianOutcome struct {
+	Market           AsianMarket  `json:"market"`
+	Line             float64      `json:"line"`
+	Split            bool         `json:"split"`
+	SplitA           LineOutcome  `json:"split_a,omitempty"`
+	SplitB           LineOutcome  `json:"split_b,omitempty"`
+	SplitProbability float64      `json:"split_probability,omitempty"`
+	IfAWins          money.Amount `json:"if_a_wins"`
+	IfSplit          money.Amount `json:"if_split,omitempty"`
+	IfBWins          money.Amount `json:"if_b_wins"`
+}
+
+// FXRate is the price of one unit of a currency in another: what buying it
+// costs and what selling it fetches. The gap between them is the spread.
+type FXRate struct {
+	Buy  float64 `json:"buy"`
+	Sell float64 `json:"sell"`
 }
 
+// Mid returns the rate halfway between buying and selling.
+func (r FXRate) Mid() float64 {
+	return (r.Buy + r.Sell) / 2
+}
+
+// RateTable holds the rates of currencies, keyed by code, against a base
+// currency.
+type RateTable struct {
+	Base  string            `json:"base"`
+	Rates map[string]FXRate `json:"rates"`
+}
+
+// FXSummary describes a position across currencies: the base currency its
+// total and profits are counted in, the rate of each option's currency in
+// it, and what the spreads cost the worst result against mid rates.
+type FXSummary struct {
+	Base       string            `json:"base"`
+	Rates      map[string]FXRate `json:"rates"`
+	SpreadCost money.Amount      `json:"spread_cost"`
+}
+
+// CalculationResult is the outcome of a calculation. OddsFormat is the
+// format its odds are displayed in, decimal when empty.
 type CalculationResult struct {
 	Method     CalculationMethod `json:"method"`
-	TotalStake float64           `json:"total_stake"`
+	TotalStake money.Amount      `json:"total_stake"`
 	Currency   string            `json:"currency"`
-	OptionA    Option            `json:"option_a"`
-	OptionB    Option            `json:"option_b"`
+	OddsFormat OddsFormat        `json:"odds_format,omitempty"`
+	Options    []Option          `json:"options"`
 	Summary    Summary           `json:"summary"`
 }
 
+// resultFields has the fields of CalculationResult without its JSON methods.
+type resultFields CalculationResult
+
+// resultJSON is the JSON form of a CalculationResult. A result with two
+// options also carries them as option_a and option_b, the keys results had
+// before markets could have more outcomes, so readers of those keep working.
+type resultJSON struct {
+	resultFields
+	OptionA *Option `json:"option_a,omitempty"`
+	OptionB *Option `json:"option_b,omitempty"`
+}
+
+func (r CalculationResult) MarshalJSON() ([]byte, error) {
+	out := resultJSON{resultFields: resultFields(r)}
+	if len(r.Options) == 2 {
+		out.OptionA, out.OptionB = &r.Options[0], &r.Options[1]
+	}
+	return json.Marshal(out)
+}
+
+// UnmarshalJSON reads a result, taking its options from option_a and
+// option_b when it has no options list.
+func (r *CalculationResult) UnmarshalJSON(data []byte) error {
+	var in resultJSON
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+	*r = CalculationResult(in.resultFields)
+	if len(r.Options) == 0 && in.OptionA != nil && in.OptionB != nil {
+		r.Options = []Option{*in.OptionA, *in.OptionB}
+	}
+	return nil
+}
+
+type BetStatus string
+
+const (
+	BetPending BetStatus = "pending"
+	BetSettled BetStatus = "settled"
+	BetVoid    BetStatus = "void"
+)
+
+// Bet is a calculation recorded in the bet journal. Winner holds the label
+// of the winning option once settled ("none" when no option won).
+type Bet struct {
+	ID        int               `json:"id"`
+	Status    BetStatus         `json:"status"`
+	Bankroll  string            `json:"bankroll,omitempty"`
+	Result    CalculationResult `json:"result"`
+	Winner    string            `json:"winner,omitempty"`
+	PnL       money.Amount      `json:"pnl"`
+	PlacedAt  time.Time         `json:"placed_at"`
+	SettledAt *time.Time        `json:"settled_at,omitempty"`
+}
+
+type TransactionKind string
+
+const (
+	TransactionDeposit    TransactionKind = "deposit"
+	TransactionWithdrawal TransactionKind = "withdrawal"
+	TransactionBet        TransactionKind = "bet"
+)
+
+// Transaction is a single bankroll movement. Amount is signed: withdrawals
+// and losing bets are negative.
+type Transaction struct {
+	Kind   TransactionKind `json:"kind"`
+	Amount money.Amount    `json:"amount"`
+	BetID  int             `json:"bet_id,omitempty"`
+	Note   string          `json:"note,omitempty"`
+	At     time.Time       `json:"at"`
+}
+
+// Bankroll is a named pot of money made up of its transactions.
+type Bankroll struct {
+	Name         string        `json:"name"`
+	Currency     string        `json:"currency"`
+	Transactions []Transaction `json:"transactions"`
+}
+
+// Balance returns the sum of every transaction.
+func (b Bankroll) Balance() money.Amount {
+	var balance money.Amount
+	for _, tx := range b.Transactions {
+		balance += tx.Amount
+	}
+	return balance
+}
+
+// Distribution summarises a simulated quantity.
+type Distribution struct {
+	Mean   float64 `json:"mean"`
+	P5     float64 `json:"p5"`
+	P25    float64 `json:"p25"`
+	Median float64 `json:"median"`
+	P75    float64 `json:"p75"`
+	P95    float64 `json:"p95"`
+}
+
+// SimulationResult reports the outcomes of repeating a bet over many
+// simulated sequences. Growth rates are per bet, in log terms.
+type SimulationResult struct {
+	Method             CalculationMethod `json:"method"`
+	Currency           string            `json:"currency"`
+	StartingBankroll   money.Amount      `json:"starting_bankroll"`
+	Runs               int               `json:"runs"`
+	Bets               int               `json:"bets"`
+	Seed               uint64            `json:"seed"`
+	RuinThreshold      float64           `json:"ruin_threshold"`
+	FinalBankroll      Distribution      `json:"final_bankroll"`
+	MaxDrawdown        Distribution      `json:"max_drawdown"`
+	RuinProbability    float64           `json:"ruin_probability"`
+	MedianGrowthRate   float64           `json:"median_growth_rate"`
+	ExpectedGrowthRate float64           `json:"expected_growth_rate"`
+}
+
+// LedgerEntry is one row replayed by a backtest. Stakes holds the capital
+// committed to each option; Skipped explains why a row placed no bet.
+type LedgerEntry struct {
+	Line     int            `json:"line"`
+	Date     string         `json:"date,omitempty"`
+	Event    string         `json:"event,omitempty"`
+	Stakes   []money.Amount `json:"stakes,omitempty"`
+	Staked   money.Amount   `json:"staked"`
+	Winner   string         `json:"winner,omitempty"`
+	PnL      money.Amount   `json:"pnl"`
+	Bankroll money.Amount   `json:"bankroll"`
+	Skipped  string         `json:"skipped,omitempty"`
+}
+
+// BacktestResult reports a strategy replayed over historical markets. ROI
+// is profit over the starting bankroll, Yield is profit over the amount
+// staked; Sharpe and Sortino are per bet and not annualised.
+type BacktestResult struct {
+	Method           CalculationMethod `json:"method"`
+	Currency         string            `json:"currency"`
+	StartingBankroll money.Amount      `json:"starting_bankroll"`
+	FinalBankroll    money.Amount      `json:"final_bankroll"`
+	Rows             int               `json:"rows"`
+	Bets             int               `json:"bets"`
+	Skipped          int               `json:"skipped"`
+	Winners          int               `json:"winners"`
+	TotalStaked      money.Amount      `json:"total_staked"`
+	Profit           money.Amount      `json:"profit"`
+	ROI              float64           `json:"roi"`
+	Yield            float64           `json:"yield"`
+	MaxDrawdown      float64           `json:"max_drawdown"`
+	Sharpe           float64           `json:"sharpe"`
+	Sortino          float64           `json:"sortino"`
+	Ledger           []LedgerEntry     `json:"ledger"`
+}
+
+// ScanEvent is an event whose best prices across bookmakers form an
+// arbitrage; each option's Bookmaker says where to place that leg.
+type ScanEvent struct {
+	Event  string            `json:"event"`
+	Result CalculationResult `json:"result"`
+}
+
+// ScanResult lists the arbitrages found in a set of bookmaker odds, best
+// guaranteed ROI first.
+type ScanResult struct {
+	Books      int         `json:"books"`
+	Events     int         `json:"events"`
+	Arbitrages []ScanEvent `json:"arbitrages"`
+}
+
+// CalculationInput describes a market of mutually exclusive outcomes. Options
+// holds every outcome; when it is empty the two-option shorthand fields
+// (OddsA, OddsB, ...) describe the market instead. Its JSON form is what
+// the API served by kelly serve accepts.
 type CalculationInput struct {
-	Method     CalculationMethod
-	OddsA      float64
-	OddsB      float64
-	TotalStake float64
-	ProbA      float64
-	ProbB      float64
-	NameA      string
-	NameB      string
-	Currency   string
+	Method     CalculationMethod `json:"method,omitempty"`
+	Options    []Option          `json:"options,omitempty"`
+	OddsA      float64           `json:"odds_a,omitempty"`
+	OddsB      float64           `json:"odds_b,omitempty"`
+	TotalStake money.Amount      `json:"total_stake,omitempty"`
+	ProbA      float64           `json:"prob_a,omitempty"`
+	ProbB      float64           `json:"prob_b,omitempty"`
+	NameA      string            `json:"name_a,omitempty"`
+	NameB      string            `json:"name_b,omitempty"`
+	Currency   string            `json:"currency,omitempty"`
+
+	// KellyFraction scales Kelly stakes (e.g. 0.25 for quarter Kelly); zero
+	// means full Kelly. MaxStakePct caps each stake as a percentage of the
+	// total and MinEdge skips options whose edge (p × odds - 1) is smaller.
+	KellyFraction float64 `json:"kelly_fraction,omitempty"`
+	MaxStakePct   float64 `json:"max_stake_pct,omitempty"`
+	MinEdge       float64 `json:"min_edge,omitempty"`
+
+	// FairFrom derives missing probabilities by removing the margin from the
+	// options' SharpOdds, or from their Odds unless every option has
+	// SharpOdds set.
+	FairFrom MarginMethod `json:"fair_from,omitempty"`
+
+	// BetType selects the matched betting conversion; TotalStake is then
+	// the bookmaker back stake.
+	BetType BetType `json:"bet_type,omitempty"`
+
+	// HedgeTarget selects what the hedge method locks in; TotalStake is
+	// then the stake of the position held.
+	HedgeTarget HedgeTarget `json:"hedge_target,omitempty"`
+
+	// PlaceFraction and Places are the each-way place terms (e.g. 1/5 of
+	// the odds, 4 places); TotalStake is then the combined win and place
+	// stake. PlaceProbability is the chance of placing, winning included.
+	PlaceFraction    float64 `json:"place_fraction,omitempty"`
+	Places           int     `json:"places,omitempty"`
+	PlaceProbability float64 `json:"place_probability,omitempty"`
+
+	// System names the parlay bet over the options: a named system such as
+	// trixie or yankee, or comma-separated combination sizes such as "2,3".
+	// Empty means a single accumulator of every option.
+	System string `json:"system,omitempty"`
+
+	// AsianMarket prices two options on complementary Asian lines, given
+	// by their Line: handicaps that sum to zero, or the same goal total.
+	// The arbitrage and Kelly methods then allow for pushes and half
+	// results. ProbA and ProbB (or the options' probabilities) are the
+	// chances of each side clearing the line outright; the rest is the
+	// chance of the result landing on it.
+	AsianMarket AsianMarket `json:"asian_market,omitempty"`
+
+	// Rates converts between the options' currencies, set on each option
+	// as Currency, and BaseCurrency (the table's base when empty), in
+	// which TotalStake and profits are counted. Options without a
+	// currency are in the base currency. Only arbitrage supports it.
+	Rates        *RateTable `json:"rates,omitempty"`
+	BaseCurrency string     `json:"base_currency,omitempty"`
+
+	// RoundTo rounds back stakes to multiples of this amount using
+	// RoundMode (nearest when unset). Lay stakes are not rounded.
+	RoundTo   float64   `json:"round_to,omitempty"`
+	RoundMode RoundMode `json:"round_mode,omitempty"`
+}
+
+// Rounding reports whether stakes should be rounded.
+func (in *CalculationInput) Rounding() bool {
+	return in.RoundTo > 0 || in.RoundMode != ""
+}
+
+// EffectiveOdds returns the decimal odds paid per unit of capital at risk,
+// net of commission, winnings tax and stake excise. A lay bet risks its
+// liability to win the backer's stake, so it behaves like a back bet on the
+// selection losing.
+func (o Option) EffectiveOdds() float64 {
+	winnings := o.Odds - 1.0
+	if o.Side == SideLay {
+		winnings = 1.0 / (o.Odds - 1.0)
+	}
+	return (1.0 - o.StakeExcise) * (1.0 + o.NetWinnings(winnings))
+}
+
+// NetWinnings returns what winnings of w per unit placed pay after
+// commission and winnings tax.
+func (o Option) NetWinnings(w float64) float64 {
+	return w * (1.0 - o.Commission) * (1.0 - o.TaxOnWinnings)
+}
+
+// Taxed reports whether the option's book withholds tax on winnings or
+// charges excise on stakes.
+func (o Option) Taxed() bool {
+	return o.TaxOnWinnings > 0 || o.StakeExcise > 0
+}
+
+// Untaxed returns the option without winnings tax and stake excise, to
+// price its gross return.
+func (o Option) Untaxed() Option {
+	o.TaxOnWinnings, o.StakeExcise = 0, 0
+	return o
+}
+
+// Outcomes returns the options of the market, building them from the
+// two-option shorthand fields when Options is empty.
+func (in *CalculationInput) Outcomes() []Option {
+	if len(in.Options) > 0 {
+		return in.Options
+	}
+	return []Option{
+		{Name: in.NameA, Odds: in.OddsA, Probability: in.ProbA},
+		{Name: in.NameB, Odds: in.OddsB, Probability: in.ProbB},
+	}
+}
+
+// OptionLabel returns the positional label ("Option A", "Option B", ...) of
+// the option at index i.
+func OptionLabel(i int) string {
+	if i < 26 {
+		return "Option " + string(rune('A'+i))
+	}
+	return fmt.Sprintf("Option %d", i+1)
+}
+
+// currencySymbols maps the codes of common betting currencies to their
+// symbols.
+var currencySymbols = map[string]string{
+	"NGN": "₦", "USD": "$", "GBP": "£", "EUR": "€", "GHS": "GH₵", "KES": "KSh",
+	"ZAR": "R", "UGX": "USh", "TZS": "TSh", "CAD": "C$", "AUD": "A$", "INR": "₹",
+}
+
+// CurrencySymbol returns the symbol of a currency code, or the code and a
+// space when it has none here.
+func CurrencySymbol(code string) string {
+	if symbol, ok := currencySymbols[code]; ok {
+		return symbol
+	}
+	return code + " "
 }
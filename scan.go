@@ -0,0 +1,90 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/codehakase/kelly/internal/cli"
+	"github.com/codehakase/kelly/internal/formatter"
+	"github.com/codehakase/kelly/internal/scan"
+	"github.com/codehakase/kelly/pkg/money"
+	"github.com/codehakase/kelly/pkg/types"
+)
+
+var scanCommand = &cli.Command{
+	Name:    "scan",
+	Usage:   "scan --books dir/ -t 10000 [flags]",
+	Summary: "Find arbitrages across bookmaker odds files",
+	Help:    printScanUsage,
+	Flags:   withConfig(scanFlags),
+}
+
+// scanFlags defines the flags of "kelly scan", which looks for arbitrages
+// across the odds files of several bookmakers.
+func scanFlags(fs *flag.FlagSet) func(args []string) {
+	var (
+		books    = fs.String("books", "", "Directory of bookmaker odds files (.csv or .json), one per bookmaker")
+		total    = fs.Float64("total", 0, "Total amount to allocate to each arbitrage (required)")
+		currency = fs.String("currency", "₦", "Currency symbol")
+		format   = fs.String("format", "table", "Output format (table, json, csv)")
+		verbose  = fs.Bool("verbose", false, "Verbose output with explanations")
+	)
+	cli.Alias(fs, "t", "total")
+	cli.Alias(fs, "c", "currency")
+	cli.Alias(fs, "f", "format")
+	cli.Alias(fs, "v", "verbose")
+
+	return func(args []string) {
+		if *books == "" || *total <= 0 {
+			fatal(errors.New("scan requires --books and --total"))
+		}
+
+		loaded, err := scan.LoadDir(*books)
+		if err != nil {
+			fatal(err)
+		}
+		result, err := scan.Scan(loaded, money.FromFloat(*total), *currency)
+		if err != nil {
+			fatal(err)
+		}
+
+		var output string
+		switch types.OutputFormat(*format) {
+		case types.OutputJSON:
+			output, err = formatter.FormatScanJSON(result)
+		case types.OutputCSV:
+			output, err = formatter.FormatScanCSV(result)
+		default:
+			output = formatter.FormatScanTable(result, *verbose)
+		}
+		if err != nil {
+			fatal(err)
+		}
+		fmt.Println(strings.TrimRight(output, "\n"))
+	}
+}
+
+func printScanUsage(w io.Writer) {
+	fmt.Fprintf(w, `Kelly - Arbitrage Scanner
+
+USAGE:
+  kelly scan --books dir/ -t 10000 [flags]
+
+Each file in the directory holds one bookmaker's odds and is named after
+it. CSV files need event, outcome and odds columns; JSON files map events
+to outcomes and odds:
+
+  {"Arsenal v Spurs": {"Home": 2.1, "Draw": "3.4", "Away": "5/2"}}
+
+Events and outcomes are matched by name, ignoring case. The best price per
+outcome is taken across books, and every event priced under 100%% is listed
+with arbitrage stakes, best ROI first. An empty odds cell or null lists an
+outcome without a price; an event with an outcome no book prices is
+skipped, since a partial market can look like an arbitrage. Books must
+list every outcome of the events they quote.
+
+`)
+}
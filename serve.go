@@ -0,0 +1,54 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/codehakase/kelly/internal/cli"
+	"github.com/codehakase/kelly/internal/server"
+)
+
+var serveCommand = &cli.Command{
+	Name:    "serve",
+	Usage:   "serve [--addr host:port]",
+	Summary: "Serve the calculators as a JSON API",
+	Help:    printServeUsage,
+	Flags:   withConfig(serveFlags),
+}
+
+// serveFlags defines the flags of "kelly serve", which serves the
+// calculators over HTTP until it is stopped.
+func serveFlags(fs *flag.FlagSet) func(args []string) {
+	addr := fs.String("addr", "localhost:8080", "Address to listen on")
+
+	return func(args []string) {
+		fmt.Fprintf(os.Stderr, "Serving on http://%s\n", *addr)
+		if err := server.New(*addr).ListenAndServe(); err != nil {
+			fatal(err)
+		}
+	}
+}
+
+func printServeUsage(w io.Writer) {
+	fmt.Fprint(w, `Kelly - API Server
+
+USAGE:
+  kelly serve [--addr host:port]
+
+ENDPOINTS:
+  POST /calculate          Calculate a market sent as JSON
+  GET  /convert?odds=5/2   Show odds in every format (?format= to read them as one)
+
+The body of /calculate uses the field names of kelly's JSON input, such as
+method, options, total_stake and kelly_fraction; the result is the same
+JSON kelly calc -f json prints. Errors are returned as {"error": "..."}.
+Request bodies are limited to 1 MB, and slow clients are timed out.
+
+EXAMPLES:
+  kelly serve --addr :8080
+  curl -d '{"options": [{"odds": 2.56}, {"odds": 3.85}], "total_stake": 10000}' localhost:8080/calculate
+
+`)
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/codehakase/kelly/internal/calculator"
+	"github.com/codehakase/kelly/internal/cli"
+	"github.com/codehakase/kelly/internal/formatter"
+	"github.com/codehakase/kelly/internal/simulate"
+	"github.com/codehakase/kelly/internal/validator"
+	"github.com/codehakase/kelly/pkg/types"
+)
+
+var simulateCommand = &cli.Command{
+	Name:    "simulate",
+	Usage:   "simulate [market flags] [flags]",
+	Summary: "Monte Carlo simulation of a staking strategy",
+	Help:    printSimulateUsage,
+	Flags:   withConfig(simulateFlags),
+}
+
+// simulateFlags defines the flags of "kelly simulate", which repeats one
+// calculated bet many times and reports the spread of outcomes.
+func simulateFlags(fs *flag.FlagSet) func(args []string) {
+	var market marketFlags
+	market.register(fs)
+	var (
+		resultFile = fs.String("result", "", "JSON calculation result to simulate (- for stdin) instead of market flags")
+		trueProbs  = fs.String("true-probs", "", "Comma-separated true win probability per option (default: the result's probabilities)")
+		runs       = fs.Int("runs", 10000, "Number of simulated sequences")
+		bets       = fs.Int("bets", 100, "Number of bets per sequence")
+		seed       = fs.Uint64("seed", 0, "Random seed (default: time-based)")
+		ruin       = fs.Float64("ruin", 0.01, "Ruin threshold as a fraction of the starting bankroll")
+		format     = fs.String("format", "table", "Output format (table, json, csv)")
+	)
+	cli.Alias(fs, "f", "format")
+
+	return func(args []string) {
+		result, err := simulationBet(fs, &market, *resultFile)
+		if err != nil {
+			fatal(err)
+		}
+		if result.Summary.EachWay != nil {
+			fatal(fmt.Errorf("each-way bets cannot be simulated: their legs are not separate outcomes"))
+		}
+		if result.Summary.Parlay != nil {
+			fatal(fmt.Errorf("parlays cannot be simulated: their lines are not separate outcomes"))
+		}
+
+		probs, err := simulationProbabilities(result, *trueProbs)
+		if err != nil {
+			fatal(err)
+		}
+
+		if !cli.IsSet(fs, "seed") && *seed == 0 {
+			*seed = uint64(time.Now().UnixNano())
+		}
+		sim, err := simulate.Run(result, simulate.Config{
+			Runs: *runs, Bets: *bets, Seed: *seed, RuinThreshold: *ruin, Probabilities: probs,
+		})
+		if err != nil {
+			fatal(err)
+		}
+
+		var output string
+		switch types.OutputFormat(*format) {
+		case types.OutputJSON:
+			output, err = formatter.FormatSimulationJSON(sim)
+		case types.OutputCSV:
+			output, err = formatter.FormatSimulationCSV(sim)
+		default:
+			output = formatter.FormatSimulationTable(sim)
+		}
+		if err != nil {
+			fatal(err)
+		}
+		fmt.Println(strings.TrimRight(output, "\n"))
+	}
+}
+
+// simulationBet returns the bet to repeat: a result read from resultFile,
+// or one calculated from the market flags.
+func simulationBet(fs *flag.FlagSet, market *marketFlags, resultFile string) (*types.CalculationResult, error) {
+	if resultFile != "" {
+		return readResult(resultFile)
+	}
+	if !market.hasOdds() || !market.hasTotal() {
+		return nil, fmt.Errorf("simulate %v, or --result", errNoMarket)
+	}
+
+	input, err := market.input(fs)
+	if err != nil {
+		return nil, err
+	}
+	if err := validMethod(input.Method); err != nil {
+		return nil, err
+	}
+	if err := validator.ValidateCalculationInput(input); err != nil {
+		return nil, err
+	}
+	return calculator.NewCalculator(input.Method).Calculate(input)
+}
+
+// simulationProbabilities parses --true-probs, falling back to the
+// probabilities carried by a Kelly result.
+func simulationProbabilities(result *types.CalculationResult, list string) ([]float64, error) {
+	if list == "" {
+		probs := make([]float64, len(result.Options))
+		for i, opt := range result.Options {
+			if opt.Probability == 0 {
+				return nil, errors.New("--true-probs is required when the result has no probabilities")
+			}
+			probs[i] = opt.Probability
+		}
+		return probs, nil
+	}
+
+	parts := strings.Split(list, ",")
+	if len(parts) != len(result.Options) {
+		return nil, fmt.Errorf("--true-probs has %d entries, result has %d options", len(parts), len(result.Options))
+	}
+	probs := make([]float64, len(parts))
+	for i, part := range parts {
+		p, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return nil, fmt.Errorf("parsing true probability for %s: %w", types.OptionLabel(i), err)
+		}
+		probs[i] = p
+	}
+	return probs, nil
+}
+
+func printSimulateUsage(w io.Writer) {
+	fmt.Fprintf(w, `Kelly - Monte Carlo Simulation
+
+USAGE:
+  kelly simulate [market flags] [--true-probs p1,p2] [flags]
+  kelly simulate --result result.json --true-probs p1,p2 [flags]
+
+Each bet stakes the same fractions of the current bankroll as the
+calculated result stakes of its total, so the bankroll compounds.
+Probabilities that sum to less than 1 leave room for no option winning.
+
+EXAMPLES:
+  kelly simulate -a 2.1 -b 3.5 -t 1000 -m kelly -pa 0.55 -pb 0.40 --kelly-fraction 0.5
+  kelly simulate -a 2.0 -b 2.0 -t 1000 -m kelly -pa 0.55 -pb 0.45 --true-probs 0.52,0.48 --seed 42
+  kelly -a 2.56 -b 3.85 -t 1000 -f json | kelly simulate --result - --true-probs 0.4,0.27
+
+`)
+}